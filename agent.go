@@ -1,28 +1,46 @@
-// agent.go - Agent loop: iterates LLM calls <-> MCP tool execution
+// agent.go - Agent loop: iterates LLM calls <-> tool execution (MCP or built-in)
 package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-// maxConversationMessages caps the message history sent to the LLM to prevent
-// exceeding token limits. Keeps the system prompt + last N messages.
-const maxConversationMessages = 50
+// errToolDenied marks a tool call the user declined in the approval prompt.
+var errToolDenied = errors.New("denied by user")
+
+// errAgentCancelled marks a run stopped with the chat panel's cancel key.
+var errAgentCancelled = errors.New("cancelled by user")
+
+// Tool results recorded for calls cut short by a cancel.
+const (
+	notExecutedToolResult = "Not executed: the user cancelled the request before this tool ran."
+	interruptedToolResult = "Interrupted: the user cancelled the request while this tool was running; it may have partially completed."
+)
 
 // AgentResult holds the outcome of an agent run.
 type AgentResult struct {
 	Response string
-	Err      error
+	// Messages are the assistant tool-call and tool-result messages added during
+	// the run, in order. The final text response is not included.
+	Messages []ChatMessage
+	// Usage is the token usage of all LLM requests made during the run, by model.
+	Usage modelUsage
+	// Plan holds the mutating calls intercepted in plan mode, in order.
+	Plan []plannedCall
+	Err  error
 }
 
-// RunAgent executes the agent loop: LLM decides tool calls, MCP executes them,
-// results feed back until a text-only response. Sends live progress via p.Send().
+// RunAgent executes the agent loop: LLM decides tool calls, the executor (MCP or
+// built-in tools) runs them, results feed back until a text-only response.
+// Sends live progress via p.Send(). When ctx carries a plan (withToolPlan),
+// mutating calls are collected into AgentResult.Plan instead of executed.
 func RunAgent(ctx context.Context, p *tea.Program, client *LLMClient,
-	mcpClient *MCPClient, messages []ChatMessage, tools []ToolDef) AgentResult {
+	executor ToolExecutor, messages []ChatMessage, tools []ToolDef) AgentResult {
 
 	// Build allowed tool name set for validation
 	allowedTools := make(map[string]bool, len(tools))
@@ -30,121 +48,142 @@ func RunAgent(ctx context.Context, p *tea.Program, client *LLMClient,
 		allowedTools[t.Function.Name] = true
 	}
 
+	history := newHistoryTrimmer(client, tools, historySummaryFrom(ctx))
+	start := len(messages)
+	result := func(response string, err error) AgentResult {
+		usage := client.TakeUsage()
+		var planned []plannedCall
+		if plan := toolPlanFrom(ctx); plan != nil {
+			planned = plan.calls
+		}
+		return AgentResult{Response: response, Messages: messages[start:], Usage: usage, Plan: planned, Err: err}
+	}
+
 	for i := 0; i < MaxAgentIterations; i++ {
 		// Check context cancellation
 		if err := ctx.Err(); err != nil {
-			return AgentResult{Err: fmt.Errorf("cancelled: %w", err)}
+			return result("", fmt.Errorf("%w: %w", errAgentCancelled, err))
 		}
 
-		// Trim conversation history to stay within token limits
-		trimmed := trimMessages(messages, maxConversationMessages)
+		// Trim conversation history to the model's token budget
+		trimmed := history.trim(ctx, messages)
 
-		resp, err := client.Chat(ctx, trimmed, tools)
+		resp, err := client.ChatStream(ctx, trimmed, tools, streamToProgram(p))
 		if err != nil {
-			return AgentResult{Err: fmt.Errorf("LLM error: %w", err)}
+			if ctx.Err() != nil {
+				return result("", fmt.Errorf("%w: %w", errAgentCancelled, ctx.Err()))
+			}
+			return result("", fmt.Errorf("LLM error: %w", err))
 		}
 
 		// No tool calls — final text response
 		if len(resp.ToolCalls) == 0 {
-			return AgentResult{Response: resp.Content}
+			return result(resp.Content, nil)
 		}
 
-		// Append assistant message with tool calls
-		messages = append(messages, resp)
-
-		// Execute each tool call
-		for _, tc := range resp.ToolCalls {
-			// Check context cancellation between tool calls
-			if err := ctx.Err(); err != nil {
-				return AgentResult{Err: fmt.Errorf("cancelled: %w", err)}
+		// Tool results must reference their call, so fill in missing IDs first
+		for j := range resp.ToolCalls {
+			if resp.ToolCalls[j].ID == "" {
+				resp.ToolCalls[j].ID = fmt.Sprintf("call_%d_%d", i, j)
 			}
+		}
 
-			// Validate tool call has required fields
-			if tc.ID == "" {
-				tc.ID = fmt.Sprintf("call_%d_%d", i, 0)
-			}
+		// Append assistant message with tool calls
+		messages = append(messages, resp)
 
-			// Validate tool name against known tools
-			if !allowedTools[tc.Function.Name] {
-				errMsg := fmt.Sprintf("Unknown tool '%s' — not calling it", tc.Function.Name)
-				p.Send(chatToolDoneMsg{name: tc.Function.Name, result: errMsg, err: fmt.Errorf("unknown tool")})
-				messages = append(messages, ChatMessage{
-					Role:       "tool",
-					Content:    errMsg,
-					ToolCallID: tc.ID,
-				})
-				continue
+		// Validate and approve calls one at a time, then run the approved ones.
+		// The audit log names the model that chose them, which may be a fallback.
+		toolCtx := withAuditModel(ctx, client.LastModel())
+		outputs := make([]string, len(resp.ToolCalls))
+		jobs := prepareToolCalls(toolCtx, p, resp.ToolCalls, allowedTools, outputs)
+		runToolCalls(toolCtx, p, executor, jobs, outputs, client.ParallelTools)
+
+		// Append tool results to conversation in the original call order
+		for j, tc := range resp.ToolCalls {
+			if outputs[j] == "" {
+				outputs[j] = notExecutedToolResult
 			}
-
-			// Notify UI that tool execution is starting
-			p.Send(chatToolStartMsg{
-				name: tc.Function.Name,
-				args: tc.Function.Arguments,
+			messages = append(messages, ChatMessage{
+				Role:       "tool",
+				Content:    outputs[j],
+				ToolCallID: tc.ID,
 			})
+		}
 
-			// Parse arguments
-			var args map[string]interface{}
-			if tc.Function.Arguments != "" {
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-					errMsg := fmt.Sprintf("Invalid arguments for %s: %s", tc.Function.Name, err.Error())
-					p.Send(chatToolDoneMsg{name: tc.Function.Name, result: errMsg, err: err})
-					messages = append(messages, ChatMessage{
-						Role:       "tool",
-						Content:    errMsg,
-						ToolCallID: tc.ID,
-					})
-					continue
-				}
-			}
-
-			// Execute tool via MCP
-			result, err := mcpClient.CallTool(ctx, tc.Function.Name, args)
+		if err := ctx.Err(); err != nil {
+			return result("", fmt.Errorf("%w: %w", errAgentCancelled, err))
+		}
+	}
 
-			if err != nil {
-				result = fmt.Sprintf("Error: %s", err.Error())
-			}
+	return result("", fmt.Errorf("agent exceeded maximum iterations (%d)", MaxAgentIterations))
+}
 
-			// Notify UI that tool execution completed
-			p.Send(chatToolDoneMsg{
-				name:   tc.Function.Name,
-				result: result,
-				err:    err,
-			})
+// cancelSummary describes what a cancelled run had already done, from the
+// messages it added. It is shown in the chat and kept in the conversation so
+// the model knows the state on the next request.
+func cancelSummary(messages []ChatMessage) string {
+	calls := make(map[string]FunctionCall)
+	for _, msg := range messages {
+		for _, tc := range msg.ToolCalls {
+			calls[tc.ID] = tc.Function
+		}
+	}
 
-			// Append tool result to conversation
-			messages = append(messages, ChatMessage{
-				Role:       "tool",
-				Content:    result,
-				ToolCallID: tc.ID,
-			})
+	var done, interrupted, skipped []string
+	for _, msg := range messages {
+		if msg.Role != "tool" {
+			continue
 		}
+		fn := calls[msg.ToolCallID]
+		call := fmt.Sprintf("%s %s", fn.Name, fn.Arguments)
+		switch msg.Content {
+		case notExecutedToolResult:
+			skipped = append(skipped, fn.Name)
+		case interruptedToolResult:
+			interrupted = append(interrupted, call)
+		default:
+			done = append(done, fmt.Sprintf("%s → %s", call, truncate(msg.Content, 80)))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("Cancelled by user.")
+	if len(done) == 0 && len(interrupted) == 0 {
+		b.WriteString(" No tools had run.")
+	}
+	for _, d := range done {
+		b.WriteString("\nExecuted: " + d)
+	}
+	for _, c := range interrupted {
+		b.WriteString("\nInterrupted (may have partially completed): " + c)
 	}
+	if len(skipped) > 0 {
+		b.WriteString("\nNot executed: " + strings.Join(skipped, ", "))
+	}
+	return b.String()
+}
 
-	return AgentResult{Err: fmt.Errorf("agent exceeded maximum iterations (%d)", MaxAgentIterations)}
+// notifyProgram sends a progress message to the UI, if there is one.
+func notifyProgram(p *tea.Program, msg tea.Msg) {
+	if p != nil {
+		p.Send(msg)
+	}
 }
 
-// trimMessages keeps the system prompt (first message) and the most recent messages
-// to stay within a reasonable token budget.
-func trimMessages(messages []ChatMessage, maxMessages int) []ChatMessage {
-	if len(messages) <= maxMessages {
-		return messages
+// streamToProgram returns a ChatStream callback that forwards content deltas to the UI.
+func streamToProgram(p *tea.Program) func(string) {
+	if p == nil {
+		return nil
+	}
+	return func(delta string) {
+		p.Send(chatStreamDeltaMsg{content: delta})
 	}
-	// Keep system prompt + tail
-	tail := messages[len(messages)-(maxMessages-1):]
-	result := make([]ChatMessage, 0, maxMessages)
-	result = append(result, messages[0]) // system prompt
-	result = append(result, tail...)
-	return result
 }
 
 // runAgentCmd creates a tea.Cmd that runs the agent loop in a goroutine.
 func runAgentCmd(ctx context.Context, p *tea.Program, client *LLMClient,
-	mcpClient *MCPClient, messages []ChatMessage, tools []ToolDef) tea.Cmd {
+	executor ToolExecutor, messages []ChatMessage, tools []ToolDef) tea.Cmd {
 	return func() tea.Msg {
-		result := RunAgent(ctx, p, client, mcpClient, messages, tools)
-		return chatAgentResultMsg{
-			response: result.Response,
-			err:      result.Err,
-		}
+		return newChatAgentResultMsg(RunAgent(ctx, p, client, executor, messages, tools))
 	}
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunAgentCancelDuringLLMCall(t *testing.T) {
+	requestStarted := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requestStarted)
+		// Hang like a slow model until the client aborts or the test ends
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-requestStarted
+		cancel()
+	}()
+
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m"})
+	done := make(chan AgentResult, 1)
+	go func() {
+		done <- RunAgent(ctx, nil, client, nil, []ChatMessage{{Role: "system"}, {Role: "user", Content: "hi"}}, nil)
+	}()
+
+	select {
+	case res := <-done:
+		if !errors.Is(res.Err, errAgentCancelled) {
+			t.Fatalf("expected cancelled error, got %v", res.Err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("RunAgent did not return after cancel")
+	}
+}
+
+func TestCancelSummary(t *testing.T) {
+	messages := []ChatMessage{
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "a", Function: FunctionCall{Name: "start_instance", Arguments: `{"name":"vm1"}`}},
+			{ID: "b", Function: FunctionCall{Name: "exec_command", Arguments: `{"name":"vm1","command":"apt upgrade"}`}},
+			{ID: "c", Function: FunctionCall{Name: "stop_instance", Arguments: `{"name":"vm2"}`}},
+		}},
+		{Role: "tool", ToolCallID: "a", Content: "Started vm1"},
+		{Role: "tool", ToolCallID: "b", Content: interruptedToolResult},
+		{Role: "tool", ToolCallID: "c", Content: notExecutedToolResult},
+	}
+
+	got := cancelSummary(messages)
+	for _, want := range []string{
+		`Executed: start_instance {"name":"vm1"} → Started vm1`,
+		"Interrupted (may have partially completed): exec_command",
+		"Not executed: stop_instance",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("summary missing %q:\n%s", want, got)
+		}
+	}
+
+	if got := cancelSummary(nil); !strings.Contains(got, "No tools had run") {
+		t.Fatalf("unexpected empty summary %q", got)
+	}
+}
@@ -0,0 +1,183 @@
+// audit.go - Append-only JSONL audit log of agent tool calls (~/.passgo/audit.jsonl)
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const auditLogFile = "audit.jsonl"
+
+// maxAuditLineBytes bounds one audit record when reading the log back.
+const maxAuditLineBytes = 1024 * 1024 // 1MB
+
+// auditEntry is one line of the audit log.
+type auditEntry struct {
+	Time       time.Time              `json:"ts"`
+	User       string                 `json:"user,omitempty"`
+	Session    string                 `json:"session,omitempty"`
+	Model      string                 `json:"model,omitempty"`
+	Tool       string                 `json:"tool"`
+	Args       map[string]interface{} `json:"args,omitempty"`
+	Result     string                 `json:"result,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	DurationMs int64                  `json:"duration_ms"`
+}
+
+// vm returns the instance the call targeted, if any.
+func (e auditEntry) vm() string {
+	name, _ := e.Args["name"].(string)
+	return name
+}
+
+// auditScope identifies who made the tool calls of one agent run.
+type auditScope struct {
+	session string
+	model   string
+}
+
+type auditScopeKey struct{}
+
+// withAuditScope attaches the session and model to ctx for the audit log.
+func withAuditScope(ctx context.Context, scope auditScope) context.Context {
+	return context.WithValue(ctx, auditScopeKey{}, scope)
+}
+
+// withAuditModel replaces the model in ctx's audit scope with the one that
+// actually chose the calls, such as a fallback model.
+func withAuditModel(ctx context.Context, model string) context.Context {
+	scope, ok := ctx.Value(auditScopeKey{}).(auditScope)
+	if !ok {
+		return ctx
+	}
+	scope.model = model
+	return withAuditScope(ctx, scope)
+}
+
+// auditModel returns the model in ctx's audit scope.
+func auditModel(ctx context.Context) string {
+	scope, _ := ctx.Value(auditScopeKey{}).(auditScope)
+	return scope.model
+}
+
+// auditLogMu serializes appends, since tool calls can finish concurrently.
+var auditLogMu sync.Mutex
+
+func auditLogPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".passgo", auditLogFile), nil
+}
+
+// auditUser names the local account running PassGo.
+var auditUser = sync.OnceValue(func() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+})
+
+// auditToolCall records one tool call. Failures are logged, never returned:
+// the audit log must not break the agent.
+func auditToolCall(ctx context.Context, name string, args map[string]interface{}, result string, callErr error, started time.Time) {
+	scope, _ := ctx.Value(auditScopeKey{}).(auditScope)
+	entry := auditEntry{
+		Time:       started,
+		User:       auditUser(),
+		Session:    scope.session,
+		Model:      scope.model,
+		Tool:       name,
+		Args:       args,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	} else {
+		entry.Result = truncate(result, 2000)
+	}
+	if err := appendAuditEntry(entry); err != nil && appLogger != nil {
+		appLogger.Printf("failed to write audit log: %v", err)
+	}
+}
+
+// appendAuditEntry appends one JSON line to ~/.passgo/audit.jsonl.
+func appendAuditEntry(e auditEntry) error {
+	path, err := auditLogPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	auditLogMu.Lock()
+	defer auditLogMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // #nosec G304 -- path from UserHomeDir
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadAuditEntries reads the audit log, newest first. Malformed lines are skipped.
+func loadAuditEntries() ([]auditEntry, error) {
+	path, err := auditLogPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path) // #nosec G304 -- path from UserHomeDir
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAuditLineBytes)
+	for scanner.Scan() {
+		var e auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	return entries, scanner.Err()
+}
+
+// filterAuditEntries keeps entries whose VM or tool name contains query
+// (case-insensitive). An empty query keeps everything.
+func filterAuditEntries(entries []auditEntry, query string) []auditEntry {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return entries
+	}
+	var out []auditEntry
+	for _, e := range entries {
+		if strings.Contains(strings.ToLower(e.vm()), query) || strings.Contains(strings.ToLower(e.Tool), query) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAuditLogAppendAndLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i, e := range []auditEntry{
+		{Time: base, Tool: "stop_instance", Args: map[string]interface{}{"name": "web1"}, Result: "Stopped web1"},
+		{Time: base.Add(time.Minute), Tool: "list_instances"},
+		{Time: base.Add(2 * time.Minute), Tool: "delete_instance", Args: map[string]interface{}{"name": "db1"}, Error: "denied by user"},
+	} {
+		if err := appendAuditEntry(e); err != nil {
+			t.Fatalf("append %d: %v", i, err)
+		}
+	}
+
+	entries, err := loadAuditEntries()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Tool != "delete_instance" || entries[2].Tool != "stop_instance" {
+		t.Fatalf("expected newest first, got %+v", entries)
+	}
+	if auditOutcome(entries[0]) != "denied" || auditOutcome(entries[2]) != "ok" {
+		t.Fatalf("unexpected outcomes %q / %q", auditOutcome(entries[0]), auditOutcome(entries[2]))
+	}
+}
+
+func TestFilterAuditEntries(t *testing.T) {
+	entries := []auditEntry{
+		{Tool: "stop_instance", Args: map[string]interface{}{"name": "web1"}},
+		{Tool: "start_instance", Args: map[string]interface{}{"name": "Web2"}},
+		{Tool: "list_instances"},
+	}
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"", 3},
+		{"web", 2},
+		{"WEB1", 1},
+		{"list", 1},
+		{"instance", 3},
+		{"db", 0},
+	}
+	for _, tt := range tests {
+		if got := filterAuditEntries(entries, tt.query); len(got) != tt.want {
+			t.Fatalf("filter %q: got %d entries, want %d", tt.query, len(got), tt.want)
+		}
+	}
+}
+
+func TestAuditToolCallRecordsScope(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ctx := withAuditScope(context.Background(), auditScope{session: "s1", model: "m1"})
+	auditToolCall(ctx, "stop_instance", map[string]interface{}{"name": "vm1"}, "", errors.New("boom"), time.Now())
+
+	entries, err := loadAuditEntries()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("load: %v (%d entries)", err, len(entries))
+	}
+	e := entries[0]
+	if e.Session != "s1" || e.Model != "m1" || e.vm() != "vm1" || e.Error != "boom" || e.Result != "" {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+}
+
+func TestAuditToolCallRecordsFallbackModel(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	ctx := withAuditScope(context.Background(), auditScope{session: "s1", model: "primary"})
+	auditToolCall(withAuditModel(ctx, "fallback"), "list_instances", nil, "ok", nil, time.Now())
+
+	entries, err := loadAuditEntries()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("load: %v (%d entries)", err, len(entries))
+	}
+	if e := entries[0]; e.Session != "s1" || e.Model != "fallback" {
+		t.Fatalf("expected the fallback model in the entry, got %+v", e)
+	}
+}
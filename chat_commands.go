@@ -0,0 +1,458 @@
+// chat_commands.go - Slash commands handled locally by the chat panel, with tab completion
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chatCommand is a slash command typed into the chat input. Commands never
+// reach the LLM.
+type chatCommand struct {
+	name string
+	args string // argument hint for the help listing, "" when none
+	desc string
+	// idleOnly commands are refused while a reply is in progress
+	idleOnly bool
+	run      func(m *chatModel, arg string) tea.Cmd
+}
+
+// chatCommands is the command table, in help-listing order. It is filled in
+// init because /help refers back to it.
+var chatCommands []chatCommand
+
+func init() {
+	chatCommands = []chatCommand{
+		{name: "help", desc: "List chat commands", run: (*chatModel).cmdHelp},
+		{name: "clear", desc: "Start a new conversation", idleOnly: true, run: (*chatModel).cmdClear},
+		{name: "model", args: "<name>", desc: "Show or change the model of the active profile", idleOnly: true, run: (*chatModel).cmdModel},
+		{name: "tools", desc: "List the tools available to the agent", run: (*chatModel).cmdTools},
+		{name: "export", desc: "Export this conversation to Markdown", run: (*chatModel).cmdExport},
+		{name: "retry", desc: "Send the last message again", idleOnly: true, run: (*chatModel).cmdRetry},
+		{name: "system", desc: "Show the system prompt sent with the next message", run: (*chatModel).cmdSystem},
+		{name: "vm", args: "<name>", desc: "Select a VM in the table", run: (*chatModel).cmdVM},
+		{name: "plan", args: "[action]", desc: "Toggle plan mode; actions: on, off, show, run, discard", idleOnly: true, run: (*chatModel).cmdPlan},
+		{name: "copy", args: "[n]", desc: "Copy code block n (default: the latest) to the clipboard", run: (*chatModel).cmdCopy},
+	}
+}
+
+// findChatCommand looks a command up by name (without the slash).
+func findChatCommand(name string) (chatCommand, bool) {
+	for _, c := range chatCommands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return chatCommand{}, false
+}
+
+// isChatCommand reports whether the input is a slash command rather than a
+// prompt. The first word must name a command, so "/etc/hosts is wrong" still
+// reaches the model; "//" sends a message that starts with a slash.
+func isChatCommand(text string) bool {
+	name, ok := chatCommandName(text)
+	if !ok {
+		return false
+	}
+	_, found := findChatCommand(strings.ToLower(name))
+	return found
+}
+
+// completesAsCommand reports whether Tab should complete the input as a slash
+// command: a known command and its argument, or a command name being typed.
+func completesAsCommand(text string) bool {
+	if isChatCommand(text) {
+		return true
+	}
+	name, ok := chatCommandName(text)
+	if !ok || strings.Contains(strings.TrimSpace(text), " ") {
+		return false
+	}
+	for _, c := range chatCommands {
+		if strings.HasPrefix(c.name, strings.ToLower(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// chatCommandName returns the first word of a slash-command line, without the
+// slash. It reports false for input that does not start with a single slash.
+func chatCommandName(text string) (string, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(text), "/")
+	if !ok || strings.HasPrefix(rest, "/") {
+		return "", false
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name, true
+}
+
+// runChatCommand executes a slash command line such as "/vm dev".
+func (m *chatModel) runChatCommand(text string) tea.Cmd {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(text), "/"), " ")
+	arg = strings.TrimSpace(arg)
+
+	m.input.Reset()
+	m.input.SetHeight(3)
+	m.recalcLayout()
+
+	cmd, ok := findChatCommand(strings.ToLower(name))
+	if !ok {
+		m.addChatError(fmt.Sprintf("Unknown command /%s. Type /help for the list.", name))
+		return nil
+	}
+	if cmd.idleOnly && m.thinking {
+		m.addChatError(fmt.Sprintf("/%s is not available while a reply is in progress.", cmd.name))
+		return nil
+	}
+	return cmd.run(m, arg)
+}
+
+func (m *chatModel) addChatSystem(content string) {
+	m.entries = append(m.entries, chatEntry{role: "system", content: content})
+	m.refreshViewport()
+}
+
+func (m *chatModel) addChatError(content string) {
+	m.entries = append(m.entries, chatEntry{role: "error", content: content})
+	m.refreshViewport()
+}
+
+func (m *chatModel) cmdHelp(string) tea.Cmd {
+	var b strings.Builder
+	b.WriteString("Commands (Tab completes):")
+	for _, c := range chatCommands {
+		usage := "/" + c.name
+		if c.args != "" {
+			usage += " " + c.args
+		}
+		fmt.Fprintf(&b, "\n  %-14s %s", usage, c.desc)
+	}
+	b.WriteString("\nOther text starting with / is sent as a message; start it with // to send /help and the like.")
+	m.addChatSystem(b.String())
+	return nil
+}
+
+func (m *chatModel) cmdClear(string) tea.Cmd {
+	m.newSession()
+	return nil
+}
+
+func (m *chatModel) cmdModel(arg string) tea.Cmd {
+	if arg == "" {
+		m.addChatSystem(fmt.Sprintf("Model: %s (profile %s). Use /model <name> to change it.", m.config.Model, m.config.Profile))
+		return nil
+	}
+	m.config.Model = arg
+	m.config.syncActiveProfile()
+	m.llmClient = NewLLMClient(m.config)
+	m.addChatSystem(fmt.Sprintf("Model set to %s for profile %s", arg, m.config.Profile))
+
+	cfg := m.config
+	return func() tea.Msg {
+		if err := saveLLMConfig(cfg); err != nil && appLogger != nil {
+			appLogger.Printf("failed to save LLM config: %v", err)
+		}
+		return nil
+	}
+}
+
+func (m *chatModel) cmdTools(string) tea.Cmd {
+	if !m.mcpReady {
+		if m.mcpInitFailed {
+			m.addChatSystem("No tools: initialization failed (" + m.mcpInitErr + ").")
+		} else {
+			m.addChatSystem("Tools are loaded with the first message.")
+		}
+		return nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d tools:", len(m.mcpTools))
+	for _, t := range m.mcpTools {
+		fmt.Fprintf(&b, "\n  %s — %s", t.Function.Name, firstLine(t.Function.Description))
+	}
+	m.addChatSystem(b.String())
+	return nil
+}
+
+func (m *chatModel) cmdExport(string) tea.Cmd {
+	if m.sessionID == "" {
+		m.addChatError("Nothing to export yet.")
+		return nil
+	}
+	// Save first so the export includes the latest exchange
+	snapshot := m.sessionSnapshot()
+	return tea.Sequence(saveChatSessionCmd(snapshot), exportChatSessionCmd(snapshot.ID))
+}
+
+// cmdRetry drops everything after the last user message and sends it again.
+func (m *chatModel) cmdRetry(string) tea.Cmd {
+	last := -1
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role == "user" {
+			last = i
+			break
+		}
+	}
+	if last < 0 {
+		m.addChatError("Nothing to retry yet.")
+		return nil
+	}
+	if m.sendBlocked() {
+		return nil
+	}
+	m.messages = m.messages[:last+1]
+	m.entries = append(m.entries, chatEntry{role: "system", content: "Retrying: " + truncate(m.messages[last].Content, 80)})
+	return m.startRun(m.newRunContext())
+}
+
+func (m *chatModel) cmdSystem(string) tea.Cmd {
+	m.addChatSystem(buildSystemPrompt(m.currentVMs))
+	return nil
+}
+
+func (m *chatModel) cmdVM(arg string) tea.Cmd {
+	if arg == "" {
+		m.addChatError("Usage: /vm <name>")
+		return nil
+	}
+	for _, vm := range m.currentVMs {
+		if strings.EqualFold(vm.info.Name, arg) {
+			name := vm.info.Name
+			return func() tea.Msg { return chatSelectVMMsg{name: name} }
+		}
+	}
+	m.addChatError(fmt.Sprintf("No VM named %q.", arg))
+	return nil
+}
+
+// codeBlocks returns the fenced code blocks of all assistant replies, numbered
+// from 1 in the same order renderEntries labels them.
+func (m chatModel) codeBlocks() []mdCodeBlock {
+	var blocks []mdCodeBlock
+	for _, e := range m.entries {
+		if e.role == "assistant" {
+			blocks = append(blocks, markdownCodeBlocks(e.content)...)
+		}
+	}
+	return blocks
+}
+
+func (m *chatModel) cmdCopy(arg string) tea.Cmd {
+	blocks := m.codeBlocks()
+	if len(blocks) == 0 {
+		m.addChatError("No code blocks to copy.")
+		return nil
+	}
+	n := len(blocks)
+	if arg != "" {
+		if _, err := fmt.Sscanf(arg, "%d", &n); err != nil || n < 1 || n > len(blocks) {
+			m.addChatError(fmt.Sprintf("No code block %s (1-%d).", arg, len(blocks)))
+			return nil
+		}
+	}
+	return copyToClipboardCmd(fmt.Sprintf("Code block %d", n), blocks[n-1].code)
+}
+
+// planArgs are the arguments /plan accepts, for completion.
+var planArgs = []string{"on", "off", "show", "run", "discard"}
+
+// cmdPlan toggles plan mode, in which mutating tool calls are collected into a
+// plan instead of executed, and runs or discards the collected plan.
+func (m *chatModel) cmdPlan(arg string) tea.Cmd {
+	switch strings.ToLower(arg) {
+	case "":
+		m.planMode = !m.planMode
+	case "on":
+		m.planMode = true
+	case "off":
+		m.planMode = false
+	case "show":
+		if len(m.plan) == 0 {
+			m.addChatSystem("No plan collected.")
+		} else {
+			m.addChatSystem(formatPlan(m.plan))
+		}
+		return nil
+	case "run":
+		return m.runPlan()
+	case "discard":
+		if len(m.plan) == 0 {
+			m.addChatError("No plan to discard.")
+			return nil
+		}
+		m.plan = nil
+		m.messages = append(m.messages, ChatMessage{Role: "assistant", Content: "The user discarded the plan. None of its calls were executed."})
+		m.addChatSystem("Plan discarded.")
+		return saveChatSessionCmd(m.sessionSnapshot())
+	default:
+		m.addChatError("Usage: /plan [on|off|show|run|discard]")
+		return nil
+	}
+	if m.planMode {
+		m.addChatSystem("Plan mode on: changes are collected into a plan instead of executed.")
+	} else {
+		m.addChatSystem("Plan mode off: changes run after approval.")
+	}
+	return nil
+}
+
+// runPlan executes the collected plan in one step.
+func (m *chatModel) runPlan() tea.Cmd {
+	if len(m.plan) == 0 {
+		m.addChatError("No plan to run. Turn on plan mode with /plan and ask for changes first.")
+		return nil
+	}
+	if m.toolExec == nil {
+		m.addChatError("Tools are not available to run the plan.")
+		return nil
+	}
+	calls := m.plan
+	m.plan = nil
+	m.thinking = true
+	m.addChatSystem(fmt.Sprintf("Running plan (%d steps)...", len(calls)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	ctx = withAuditScope(ctx, auditScope{session: m.sessionID, model: m.config.Model})
+	return tea.Batch(m.spinner.Tick, executePlanCmd(ctx, m.program, m.toolExec, calls))
+}
+
+// firstLine returns s up to its first newline.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// completeChatCommand completes a partially typed command line. It returns the
+// new input and, when the completion is ambiguous, the candidates.
+func completeChatCommand(input string, vmNames, models []string) (string, []string) {
+	trimmed := strings.TrimLeft(input, " ")
+	if !strings.HasPrefix(trimmed, "/") {
+		return input, nil
+	}
+	name, arg, hasArg := strings.Cut(trimmed[1:], " ")
+
+	if !hasArg {
+		var names []string
+		for _, c := range chatCommands {
+			if strings.HasPrefix(c.name, strings.ToLower(name)) {
+				names = append(names, c.name)
+			}
+		}
+		switch len(names) {
+		case 0:
+			return input, nil
+		case 1:
+			cmd, _ := findChatCommand(names[0])
+			if cmd.args != "" {
+				return "/" + cmd.name + " ", nil
+			}
+			return "/" + cmd.name, nil
+		}
+		return "/" + commonPrefix(names), prefixAll("/", names)
+	}
+
+	var options []string
+	switch strings.ToLower(name) {
+	case "vm":
+		options = vmNames
+	case "model":
+		options = models
+	case "plan":
+		options = planArgs
+	default:
+		return input, nil
+	}
+	arg = strings.TrimLeft(arg, " ")
+	var matches []string
+	for _, o := range options {
+		if strings.HasPrefix(strings.ToLower(o), strings.ToLower(arg)) {
+			matches = append(matches, o)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return input, nil
+	case 1:
+		return "/" + name + " " + matches[0], nil
+	}
+	prefix := commonPrefix(matches)
+	if len(prefix) < len(arg) {
+		prefix = arg // case-insensitive matches may share less than what was typed
+	}
+	return "/" + name + " " + prefix, matches
+}
+
+// commonPrefix returns the longest prefix shared by all of ss.
+func commonPrefix(ss []string) string {
+	if len(ss) == 0 {
+		return ""
+	}
+	prefix := ss[0]
+	for _, s := range ss[1:] {
+		// Shorten a whole rune at a time, so a multi-byte character is never cut
+		for !strings.HasPrefix(s, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+func prefixAll(p string, ss []string) []string {
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		out[i] = p + s
+	}
+	return out
+}
+
+// completeInput applies tab completion to the chat input (a slash command or a
+// trailing @mention), listing the candidates when Tab cannot narrow an
+// ambiguous completion any further.
+func (m *chatModel) completeInput() {
+	current := m.input.Value()
+	var completed string
+	var candidates []string
+	if completesAsCommand(current) {
+		completed, candidates = completeChatCommand(current, m.vmNames(), m.config.profileModels())
+	} else {
+		completed, candidates = completeMention(current, m.vmNames())
+	}
+	if completed != current {
+		m.input.SetValue(completed)
+		m.input.CursorEnd()
+		return
+	}
+	if len(candidates) > 1 {
+		m.addChatSystem(strings.Join(candidates, "  "))
+	}
+}
+
+// profileModels lists the distinct models of the configured profiles, sorted.
+func (c LLMConfig) profileModels() []string {
+	seen := map[string]bool{}
+	var models []string
+	for _, m := range append([]string{c.Model}, profileModelNames(c.Profiles)...) {
+		if m != "" && !seen[m] {
+			seen[m] = true
+			models = append(models, m)
+		}
+	}
+	sort.Strings(models)
+	return models
+}
+
+func profileModelNames(profiles []LLMProfile) []string {
+	names := make([]string, len(profiles))
+	for i, p := range profiles {
+		names[i] = p.Model
+	}
+	return names
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCompleteChatCommand(t *testing.T) {
+	vms := []string{"dev", "dev-db", "web"}
+	models := []string{"gpt-4o", "gpt-4o-mini", "llama3"}
+	tests := []struct {
+		input      string
+		want       string
+		candidates int
+	}{
+		{"/cl", "/clear", 0},
+		{"/mo", "/model ", 0},
+		{"/", "/", len(chatCommands)},
+		{"/xyz", "/xyz", 0},
+		{"/vm w", "/vm web", 0},
+		{"/vm d", "/vm dev", 2},
+		{"/vm DEV-", "/vm dev-db", 0},
+		{"/model gpt", "/model gpt-4o", 2},
+		{"/model ll", "/model llama3", 0},
+		{"/export x", "/export x", 0},
+		{"hello", "hello", 0},
+	}
+	for _, tt := range tests {
+		got, candidates := completeChatCommand(tt.input, vms, models)
+		if got != tt.want || len(candidates) != tt.candidates {
+			t.Fatalf("completeChatCommand(%q) = %q, %v; want %q with %d candidates",
+				tt.input, got, candidates, tt.want, tt.candidates)
+		}
+	}
+}
+
+func sendChatCommand(m chatModel, line string) (chatModel, tea.Cmd) {
+	m.input.SetValue(line)
+	return m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+}
+
+func TestChatCommandsStayLocal(t *testing.T) {
+	m := newChatModel()
+	m.focused = true
+	m.currentVMs = []vmData{{info: VMInfo{Name: "dev"}}}
+
+	m, _ = sendChatCommand(m, "/help")
+	last := m.entries[len(m.entries)-1]
+	if last.role != "system" || !strings.Contains(last.content, "/vm <name>") {
+		t.Fatalf("expected help listing, got %+v", last)
+	}
+	if len(m.messages) != 1 || m.thinking || m.input.Value() != "" {
+		t.Fatalf("command must not reach the LLM: %d messages, thinking=%v", len(m.messages), m.thinking)
+	}
+
+	m, cmd := sendChatCommand(m, "/vm DEV")
+	if cmd == nil {
+		t.Fatalf("expected /vm to ask root to select the VM")
+	}
+	if msg, ok := cmd().(chatSelectVMMsg); !ok || msg.name != "dev" {
+		t.Fatalf("unexpected /vm message %#v", msg)
+	}
+
+	m, _ = sendChatCommand(m, "/retry")
+	if last := m.entries[len(m.entries)-1]; last.role != "error" {
+		t.Fatalf("expected nothing to retry, got %+v", last)
+	}
+
+	m.messages = append(m.messages, ChatMessage{Role: "user", Content: "hi"})
+	m.sessionID = "s1"
+	m, _ = sendChatCommand(m, "/clear")
+	if m.sessionID != "" || len(m.messages) != 1 {
+		t.Fatalf("expected /clear to start a new session")
+	}
+}
+
+func TestSlashMessagesReachTheModel(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, tt := range []struct{ input, sent string }{
+		{"/etc/hosts is wrong on vm1", "/etc/hosts is wrong on vm1"},
+		{"/tmp fills up", "/tmp fills up"},
+		{"//plan is the word I meant", "/plan is the word I meant"},
+	} {
+		m := newChatModel()
+		m.focused = true
+		m.config = LLMConfig{BaseURL: "http://localhost:1/v1", Model: "m"}
+		m.mcpInitFailed = true
+
+		m, _ = sendChatCommand(m, tt.input)
+		last := m.messages[len(m.messages)-1]
+		if last.Role != "user" || last.Content != tt.sent {
+			t.Fatalf("%q: expected %q sent to the model, got %+v", tt.input, tt.sent, last)
+		}
+		for _, e := range m.entries {
+			if e.role == "error" {
+				t.Fatalf("%q: unexpected error %q", tt.input, e.content)
+			}
+		}
+		m.cancel()
+	}
+}
+
+func TestCompletesAsCommand(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"/", true},
+		{"/mo", true},
+		{"/vm d", true},
+		{"/etc/hosts", false},
+		{"/xyz", false},
+		{"//pl", false},
+		{"hello", false},
+	}
+	for _, tt := range tests {
+		if got := completesAsCommand(tt.input); got != tt.want {
+			t.Fatalf("completesAsCommand(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCommonPrefixKeepsRunesWhole(t *testing.T) {
+	if got := commonPrefix([]string{"café-é", "café-è"}); got != "café-" {
+		t.Fatalf("commonPrefix = %q", got)
+	}
+	if got := commonPrefix([]string{"é", "è"}); got != "" {
+		t.Fatalf("commonPrefix = %q", got)
+	}
+}
+
+func TestChatCommandRetryTruncatesToLastUserMessage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newChatModel()
+	m.focused = true
+	m.config = LLMConfig{BaseURL: "http://localhost:1/v1", Model: "m"}
+	m.mcpInitFailed = true // answer without tools
+	m.messages = append(m.messages,
+		ChatMessage{Role: "user", Content: "first"},
+		ChatMessage{Role: "assistant", Content: "one"},
+		ChatMessage{Role: "user", Content: "second"},
+		ChatMessage{Role: "assistant", Content: "broken"},
+	)
+
+	m, cmd := sendChatCommand(m, "/retry")
+	if cmd == nil || !m.thinking {
+		t.Fatalf("expected /retry to start a run")
+	}
+	if n := len(m.messages); n != 4 || m.messages[n-1].Content != "second" {
+		t.Fatalf("expected history to end at the last user message, got %+v", m.messages)
+	}
+
+	// Commands that change the conversation wait for the reply
+	m, _ = sendChatCommand(m, "/clear")
+	if last := m.entries[len(m.entries)-1]; last.role != "error" || len(m.messages) != 4 {
+		t.Fatalf("expected /clear to be refused while thinking, got %+v", last)
+	}
+	m.cancel()
+}
+
+func TestTableSelectVMClearsHidingFilter(t *testing.T) {
+	m := newTableModel()
+	m.setVMs([]vmData{{info: VMInfo{Name: "alpha"}}, {info: VMInfo{Name: "beta"}}})
+	m.filterText = "alp"
+	m.filterVisible = true
+	m.applyFilterAndSort()
+
+	if !m.selectVM("beta") {
+		t.Fatalf("expected beta to be found")
+	}
+	if m.filterText != "" {
+		t.Fatalf("expected filter to be cleared")
+	}
+	if vm, _ := m.selectedVM(); vm.Name != "beta" {
+		t.Fatalf("expected beta selected, got %q", vm.Name)
+	}
+	if m.selectVM("gamma") {
+		t.Fatalf("expected unknown VM not to be found")
+	}
+}
@@ -0,0 +1,176 @@
+// chat_mentions.go - @vmname mentions: attach a VM's full info, snapshot tree and mounts to a chat message
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// isMentionChar reports whether r can be part of a VM name in an @mention.
+func isMentionChar(r rune) bool {
+	return r == '-' || r == '_' || r == '.' ||
+		(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
+
+// parseVMMentions returns the known VMs mentioned as @name in text, once each,
+// in order of first mention. Unknown names are ignored.
+func parseVMMentions(text string, vmNames []string) []string {
+	known := make(map[string]bool, len(vmNames))
+	for _, n := range vmNames {
+		known[n] = true
+	}
+	var names []string
+	seen := map[string]bool{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isMentionChar(runes[i-1])) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && isMentionChar(runes[j]) {
+			j++
+		}
+		// A trailing dot ends the sentence, not the name
+		name := strings.TrimRight(string(runes[i+1:j]), ".")
+		if known[name] && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		i = j - 1
+	}
+	return names
+}
+
+// trailingMention returns the partial @mention the input ends with, if any.
+func trailingMention(input string) (string, bool) {
+	i := strings.LastIndexFunc(input, func(r rune) bool { return !isMentionChar(r) })
+	if i < 0 || input[i] != '@' {
+		return "", false
+	}
+	if i > 0 && isMentionChar(rune(input[i-1])) {
+		return "", false // e.g. an email address
+	}
+	return input[i+1:], true
+}
+
+// completeMention completes a trailing @mention from the VM names. It returns
+// the new input and, when the completion is ambiguous, the candidates.
+func completeMention(input string, vmNames []string) (string, []string) {
+	partial, ok := trailingMention(input)
+	if !ok {
+		return input, nil
+	}
+	base := input[:len(input)-len(partial)]
+	var matches []string
+	for _, n := range vmNames {
+		if strings.HasPrefix(strings.ToLower(n), strings.ToLower(partial)) {
+			matches = append(matches, n)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return input, nil
+	case 1:
+		return base + matches[0] + " ", nil
+	}
+	prefix := commonPrefix(matches)
+	if len(prefix) < len(partial) {
+		prefix = partial
+	}
+	return base + prefix, prefixAll("@", matches)
+}
+
+// vmDetailSources fetches the details attached for a mention; tests replace it.
+type vmDetailSources struct {
+	info      func(name string) (string, error)
+	snapshots func() (string, error)
+	mounts    func(name string) ([]MountInfo, error)
+}
+
+// multipassDetailSources reads the details from multipass, stopping when ctx is cancelled.
+func multipassDetailSources(ctx context.Context) vmDetailSources {
+	return vmDetailSources{
+		info:      func(name string) (string, error) { return runMultipassCommandContext(ctx, "info", name) },
+		snapshots: func() (string, error) { return runMultipassCommandContext(ctx, "list", "--snapshots") },
+		mounts:    func(name string) ([]MountInfo, error) { return getVMMountsContext(ctx, name) },
+	}
+}
+
+// vmMentionDetails renders the attachment for the mentioned VMs. A source that
+// fails is noted in place so the rest still reaches the model.
+func vmMentionDetails(src vmDetailSources, names []string) string {
+	var allSnaps []SnapshotInfo
+	snapOutput, snapErr := src.snapshots()
+	if snapErr == nil {
+		allSnaps = parseSnapshots(snapOutput)
+	}
+
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "DETAILS FOR @%s:\n", name)
+
+		if info, err := src.info(name); err != nil {
+			fmt.Fprintf(&b, "Info: unavailable (%v)\n", err)
+		} else {
+			b.WriteString(strings.TrimSpace(info) + "\n")
+		}
+
+		b.WriteString("Snapshots:\n")
+		var snaps []SnapshotInfo
+		for _, s := range allSnaps {
+			if s.Instance == name {
+				snaps = append(snaps, s)
+			}
+		}
+		switch {
+		case snapErr != nil:
+			fmt.Fprintf(&b, "  unavailable (%v)\n", snapErr)
+		case len(snaps) == 0:
+			b.WriteString("  none\n")
+		}
+		for _, e := range buildSnapTree(snaps) {
+			line := "  " + strings.Repeat("  ", e.depth) + "- " + e.snap.Name
+			if e.snap.Comment != "" && e.snap.Comment != "--" {
+				line += " (" + e.snap.Comment + ")"
+			}
+			b.WriteString(line + "\n")
+		}
+
+		b.WriteString("Mounts:\n")
+		mounts, err := src.mounts(name)
+		switch {
+		case err != nil:
+			fmt.Fprintf(&b, "  unavailable (%v)\n", err)
+		case len(mounts) == 0:
+			b.WriteString("  none\n")
+		}
+		sort.Slice(mounts, func(i, j int) bool { return mounts[i].TargetPath < mounts[j].TargetPath })
+		for _, mt := range mounts {
+			fmt.Fprintf(&b, "  - %s => %s\n", mt.SourcePath, mt.TargetPath)
+		}
+	}
+	return b.String()
+}
+
+// withMentionDetails appends the attachment to the user's text.
+func withMentionDetails(text, details string) string {
+	if details == "" {
+		return text
+	}
+	return text + "\n\n---\nAttached VM details (current as of this message):\n" + details
+}
+
+// resolveMentionsCmd fetches details for the mentioned VMs in the background.
+// ctx is the run's context, so ctrl+c stops the fetch and the run with it.
+func resolveMentionsCmd(ctx context.Context, text string, names []string) tea.Cmd {
+	return func() tea.Msg {
+		return chatMentionsResolvedMsg{ctx: ctx, text: text, names: names, details: vmMentionDetails(multipassDetailSources(ctx), names)}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseVMMentions(t *testing.T) {
+	vms := []string{"dev", "dev-db", "web.1"}
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"what snapshots does @dev have?", []string{"dev"}},
+		{"compare @dev-db and @dev, then @dev again", []string{"dev-db", "dev"}},
+		{"is @web.1 up? ask @web.1.", []string{"web.1"}},
+		{"mail me@dev please", nil},
+		{"@unknown and @", nil},
+	}
+	for _, tt := range tests {
+		if got := parseVMMentions(tt.text, vms); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("parseVMMentions(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCompleteMention(t *testing.T) {
+	vms := []string{"dev", "dev-db", "web"}
+	tests := []struct {
+		input      string
+		want       string
+		candidates int
+	}{
+		{"check @w", "check @web ", 0},
+		{"check @d", "check @dev", 2},
+		{"check @DEV-", "check @dev-db ", 0},
+		{"check @x", "check @x", 0},
+		{"me@d", "me@d", 0},
+		{"no mention", "no mention", 0},
+	}
+	for _, tt := range tests {
+		got, candidates := completeMention(tt.input, vms)
+		if got != tt.want || len(candidates) != tt.candidates {
+			t.Fatalf("completeMention(%q) = %q, %v; want %q with %d candidates",
+				tt.input, got, candidates, tt.want, tt.candidates)
+		}
+	}
+}
+
+func TestVMMentionDetails(t *testing.T) {
+	src := vmDetailSources{
+		info: func(name string) (string, error) { return "Name: " + name + "\nState: Running\n", nil },
+		snapshots: func() (string, error) {
+			return "Instance  Snapshot  Parent  Comment\n" +
+				"dev       base      --      clean install\n" +
+				"dev       child     base    --\n" +
+				"other     s1        --      --\n", nil
+		},
+		mounts: func(string) ([]MountInfo, error) { return nil, errors.New("boom") },
+	}
+	got := vmMentionDetails(src, []string{"dev"})
+	for _, want := range []string{
+		"DETAILS FOR @dev:",
+		"State: Running",
+		"  - base (clean install)\n    - child\n",
+		"Mounts:\n  unavailable (boom)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("details missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "s1") {
+		t.Fatalf("details include another VM's snapshot:\n%s", got)
+	}
+}
+
+func TestChatMentionAttachesDetailsBeforeRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newChatModel()
+	m.focused = true
+	m.config = LLMConfig{BaseURL: "http://localhost:1/v1", Model: "m"}
+	m.mcpInitFailed = true
+	m.currentVMs = []vmData{{info: VMInfo{Name: "dev"}}}
+
+	m.input.SetValue("why is @dev slow?")
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil || !m.thinking {
+		t.Fatalf("expected mention details to be fetched")
+	}
+	if len(m.messages) != 1 {
+		t.Fatalf("message must wait for the details, got %d messages", len(m.messages))
+	}
+
+	m, _ = m.Update(chatMentionsResolvedMsg{ctx: context.Background(), text: "why is @dev slow?", names: []string{"dev"}, details: "DETAILS FOR @dev:\n"})
+	last := m.messages[len(m.messages)-1]
+	if last.Role != "user" || !strings.HasPrefix(last.Content, "why is @dev slow?") || !strings.Contains(last.Content, "DETAILS FOR @dev") {
+		t.Fatalf("unexpected user message %q", last.Content)
+	}
+	if m.cancel == nil {
+		t.Fatalf("expected the run to start")
+	}
+	m.cancel()
+}
+
+func TestChatMentionFetchCanBeCancelled(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newChatModel()
+	m.focused = true
+	m.config = LLMConfig{BaseURL: "http://localhost:1/v1", Model: "m"}
+	m.currentVMs = []vmData{{info: VMInfo{Name: "dev"}}}
+
+	m.input.SetValue("why is @dev slow?")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.cancel == nil {
+		t.Fatalf("ctrl+c must be able to cancel while details are fetched")
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if !m.cancelling {
+		t.Fatalf("expected ctrl+c to cancel the fetch")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	m, cmd := m.Update(chatMentionsResolvedMsg{ctx: ctx, text: "why is @dev slow?", names: []string{"dev"}})
+	if m.thinking || m.cancel != nil || m.cancelling || cmd != nil {
+		t.Fatalf("a cancelled fetch must not start the run")
+	}
+	if last := m.messages[len(m.messages)-1]; last.Role != "user" || last.Content != "why is @dev slow?" {
+		t.Fatalf("expected the message kept without details, got %+v", last)
+	}
+}
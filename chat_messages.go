@@ -1,34 +1,96 @@
 // chat_messages.go - Chat-specific tea.Msg types and tea.Cmd factories
 package main
 
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
 // chatToolStartMsg is sent when a tool call begins executing.
 type chatToolStartMsg struct {
 	name string
 	args string
 }
 
-// chatToolDoneMsg is sent when a tool call completes.
+// chatToolDoneMsg is sent when a tool call completes. args matches the start
+// message, so concurrent calls to the same tool can be told apart.
 type chatToolDoneMsg struct {
 	name   string
+	args   string
 	result string
 	err    error
 }
 
-// chatAgentResultMsg carries the final agent response.
+// chatToolPlannedMsg is sent when plan mode records a tool call instead of running it.
+type chatToolPlannedMsg struct {
+	name string
+	args string
+}
+
+// chatPlanDoneMsg carries the outcome of /plan run. results holds the output of
+// each call that ran, in order; calls after a failure have none.
+type chatPlanDoneMsg struct {
+	calls   []plannedCall
+	results []string
+	err     error
+}
+
+// chatStreamDeltaMsg carries a fragment of the assistant's reply as it streams in.
+type chatStreamDeltaMsg struct {
+	content string
+}
+
+// chatCopiedMsg reports the result of /copy. via names the fallback used, if any.
+type chatCopiedMsg struct {
+	what string
+	via  string
+	err  error
+}
+
+// chatLLMRetryMsg reports an LLM request being retried or falling back to another model.
+type chatLLMRetryMsg struct {
+	text string
+}
+
+// chatApprovalRequestMsg asks the user to approve a mutating or destructive tool call.
+// The agent goroutine blocks until a value is sent on reply.
+type chatApprovalRequestMsg struct {
+	name  string
+	args  map[string]interface{}
+	risk  toolRisk
+	reply chan<- bool
+}
+
+// chatAgentResultMsg carries the final agent response and the tool messages
+// exchanged on the way to it.
 type chatAgentResultMsg struct {
 	response string
+	messages []ChatMessage
+	usage    modelUsage
+	plan     []plannedCall
 	err      error
 }
 
+func newChatAgentResultMsg(r AgentResult) chatAgentResultMsg {
+	return chatAgentResultMsg{response: r.Response, messages: r.Messages, usage: r.Usage, plan: r.Plan, err: r.Err}
+}
+
 // chatMCPReadyMsg is sent when MCP client is initialized and tools are available.
 type chatMCPReadyMsg struct {
 	tools []ToolDef
 	err   error
 }
 
-// chatMCPInitDoneMsg carries the MCP client and tools back to the model safely.
+// chatMCPDownMsg is sent when an MCP server stopped and could not be restarted.
+type chatMCPDownMsg struct {
+	server string
+	err    error
+}
+
+// chatMCPInitDoneMsg carries the tool router and tools back to the model safely.
 type chatMCPInitDoneMsg struct {
-	client *MCPClient
+	router *ToolRouter
 	tools  []ToolDef
 	err    error
 }
@@ -37,3 +99,112 @@ type chatMCPInitDoneMsg struct {
 type chatMCPDownloadProgressMsg struct {
 	message string
 }
+
+// chatSessionsLoadedMsg carries the saved session list for the picker. After a
+// rename or delete it names the session, so an open chat can follow along.
+type chatSessionsLoadedMsg struct {
+	sessions  []ChatSession
+	err       error
+	renamedID string
+	title     string // new title of renamedID
+	deletedID string
+}
+
+// chatSessionLoadedMsg carries a session to resume in the chat panel.
+type chatSessionLoadedMsg struct {
+	session ChatSession
+	err     error
+}
+
+// chatSessionExportedMsg reports where a Markdown export was written.
+type chatSessionExportedMsg struct {
+	path string
+	err  error
+}
+
+// chatMentionsResolvedMsg carries the details fetched for the VMs @mentioned in
+// a message; the run starts once they arrive.
+type chatMentionsResolvedMsg struct {
+	ctx     context.Context // the run's context, created before the fetch
+	text    string
+	names   []string
+	details string
+}
+
+// chatSelectVMMsg asks root to select a VM in the table and focus it (/vm).
+type chatSelectVMMsg struct {
+	name string
+}
+
+// chatNewSessionMsg asks root to start a fresh chat session.
+type chatNewSessionMsg struct{}
+
+func listChatSessionsCmd() tea.Cmd {
+	return func() tea.Msg {
+		sessions, err := listChatSessions()
+		return chatSessionsLoadedMsg{sessions: sessions, err: err}
+	}
+}
+
+func loadChatSessionCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		session, err := loadChatSession(id)
+		return chatSessionLoadedMsg{session: session, err: err}
+	}
+}
+
+// renameChatSessionCmd renames a session and reloads the list.
+func renameChatSessionCmd(id, title string) tea.Cmd {
+	return func() tea.Msg {
+		if err := renameChatSession(id, title); err != nil {
+			return chatSessionsLoadedMsg{err: err}
+		}
+		sessions, err := listChatSessions()
+		return chatSessionsLoadedMsg{sessions: sessions, err: err, renamedID: id, title: title}
+	}
+}
+
+// deleteChatSessionCmd deletes a session and reloads the list.
+func deleteChatSessionCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		if err := deleteChatSession(id); err != nil {
+			return chatSessionsLoadedMsg{err: err}
+		}
+		sessions, err := listChatSessions()
+		return chatSessionsLoadedMsg{sessions: sessions, err: err, deletedID: id}
+	}
+}
+
+func exportChatSessionCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		path, err := exportChatSession(id)
+		return chatSessionExportedMsg{path: path, err: err}
+	}
+}
+
+// chatUsageRecordedMsg carries the day's usage totals after a run was added to the ledger.
+type chatUsageRecordedMsg struct {
+	day   string
+	today usageTotals
+}
+
+// recordUsageCmd adds a run's usage to ~/.passgo/usage.json; failures are only logged.
+func recordUsageCmd(day string, t usageTotals) tea.Cmd {
+	return func() tea.Msg {
+		today, err := recordDailyUsage(day, t)
+		if err != nil && appLogger != nil {
+			appLogger.Printf("failed to record LLM usage: %v", err)
+		}
+		return chatUsageRecordedMsg{day: day, today: today}
+	}
+}
+
+// saveChatSessionCmd writes the session in the background; failures are only logged.
+func saveChatSessionCmd(s ChatSession) tea.Cmd {
+	return func() tea.Msg {
+		if err := saveChatSession(s); err != nil && appLogger != nil {
+			appLogger.Printf("failed to save chat session %s: %v", s.ID, err)
+		}
+		return nil
+	}
+}
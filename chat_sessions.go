@@ -0,0 +1,203 @@
+// chat_sessions.go - Persistent chat sessions stored as JSON in ~/.passgo/chats
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const chatSessionsDirName = "chats"
+
+// ChatSession is a saved conversation, including tool calls and tool results.
+type ChatSession struct {
+	ID       string             `json:"id"`
+	Title    string             `json:"title"`
+	Model    string             `json:"model"`
+	Endpoint string             `json:"endpoint"`
+	Created  time.Time          `json:"created"`
+	Updated  time.Time          `json:"updated"`
+	Messages []ChatMessage      `json:"messages"`
+	Entries  []chatSessionEntry `json:"entries"`
+	Usage    usageTotals        `json:"usage"`
+}
+
+// chatSessionEntry is the persisted form of a chatEntry (the rendered chat log).
+type chatSessionEntry struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatSessionsDir returns ~/.passgo/chats.
+func chatSessionsDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".passgo", chatSessionsDirName), nil
+}
+
+// newChatSessionID returns a sortable, unique session ID.
+func newChatSessionID() string {
+	return time.Now().Format("20060102-150405") + "-" + randomString(4)
+}
+
+// chatSessionPath returns the file path for a session ID with the given extension.
+func chatSessionPath(id, ext string) (string, error) {
+	if id == "" || filepath.Base(id) != id || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("invalid session id %q", id)
+	}
+	dir, err := chatSessionsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, id+ext), nil
+}
+
+// saveChatSession writes a session to ~/.passgo/chats/<id>.json.
+func saveChatSession(s ChatSession) error {
+	path, err := chatSessionPath(s.ID, ".json")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// loadChatSession reads one session by ID.
+func loadChatSession(id string) (ChatSession, error) {
+	path, err := chatSessionPath(id, ".json")
+	if err != nil {
+		return ChatSession{}, err
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- path built from UserHomeDir and a validated ID
+	if err != nil {
+		return ChatSession{}, err
+	}
+	var s ChatSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		return ChatSession{}, fmt.Errorf("parse session %s: %w", id, err)
+	}
+	return s, nil
+}
+
+// listChatSessions returns all saved sessions, most recently updated first.
+// Unreadable files are skipped.
+func listChatSessions() ([]ChatSession, error) {
+	dir, err := chatSessionsDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var sessions []ChatSession
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		s, err := loadChatSession(strings.TrimSuffix(f.Name(), ".json"))
+		if err != nil {
+			if appLogger != nil {
+				appLogger.Printf("skipping chat session %s: %v", f.Name(), err)
+			}
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+	return sessions, nil
+}
+
+// renameChatSession changes a session's title.
+func renameChatSession(id, title string) error {
+	s, err := loadChatSession(id)
+	if err != nil {
+		return err
+	}
+	s.Title = title
+	return saveChatSession(s)
+}
+
+// deleteChatSession removes a session and any Markdown export of it.
+func deleteChatSession(id string) error {
+	path, err := chatSessionPath(id, ".json")
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if md, err := chatSessionPath(id, ".md"); err == nil {
+		_ = os.Remove(md)
+	}
+	return nil
+}
+
+// exportChatSession writes the session as Markdown next to its JSON file and
+// returns the path written.
+func exportChatSession(id string) (string, error) {
+	s, err := loadChatSession(id)
+	if err != nil {
+		return "", err
+	}
+	path, err := chatSessionPath(id, ".md")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(chatSessionMarkdown(s)), 0o600)
+}
+
+// chatSessionTitle derives a session title from the first user message.
+func chatSessionTitle(messages []ChatMessage) string {
+	for _, msg := range messages {
+		if msg.Role == "user" {
+			title := strings.Join(strings.Fields(msg.Content), " ")
+			return truncateToRunes(title, 48)
+		}
+	}
+	return "New chat"
+}
+
+// chatSessionMarkdown renders a session as Markdown, including tool calls and results.
+func chatSessionMarkdown(s ChatSession) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", s.Title)
+	fmt.Fprintf(&b, "- Model: %s\n- Endpoint: %s\n- Created: %s\n- Updated: %s\n",
+		s.Model, s.Endpoint, s.Created.Format(time.RFC3339), s.Updated.Format(time.RFC3339))
+
+	for _, msg := range s.Messages {
+		switch msg.Role {
+		case "user":
+			fmt.Fprintf(&b, "\n## You\n\n%s\n", msg.Content)
+		case "assistant":
+			b.WriteString("\n## Assistant\n")
+			if msg.Content != "" {
+				fmt.Fprintf(&b, "\n%s\n", msg.Content)
+			}
+			for _, tc := range msg.ToolCalls {
+				fmt.Fprintf(&b, "\n**Tool call** `%s`\n\n```json\n%s\n```\n", tc.Function.Name, tc.Function.Arguments)
+			}
+		case "tool":
+			fmt.Fprintf(&b, "\n**Tool result**\n\n```\n%s\n```\n", msg.Content)
+		}
+	}
+	return b.String()
+}
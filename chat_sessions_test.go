@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChatSessionLifecycle(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	older := ChatSession{ID: "20260101-000000-aaaa", Title: "old", Updated: time.Now().Add(-time.Hour)}
+	newer := ChatSession{
+		ID:       "20260101-000001-bbbb",
+		Title:    "start vm1",
+		Model:    "m1",
+		Endpoint: "http://localhost:11434/v1",
+		Updated:  time.Now(),
+		Messages: []ChatMessage{
+			{Role: "system", Content: "sys"},
+			{Role: "user", Content: "start vm1"},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "c1", Type: "function", Function: FunctionCall{Name: "start_instance", Arguments: `{"name":"vm1"}`}}}},
+			{Role: "tool", ToolCallID: "c1", Content: "Started vm1"},
+			{Role: "assistant", Content: "Done."},
+		},
+	}
+	for _, s := range []ChatSession{older, newer} {
+		if err := saveChatSession(s); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+
+	sessions, err := listChatSessions()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != newer.ID {
+		t.Fatalf("expected newest first, got %+v", sessions)
+	}
+	if got := sessions[0].Messages[3]; got.Role != "tool" || got.ToolCallID != "c1" {
+		t.Fatalf("tool message not preserved: %+v", got)
+	}
+
+	if err := renameChatSession(newer.ID, "renamed"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	path, err := exportChatSession(newer.ID)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	md, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	for _, want := range []string{"# renamed", "- Model: m1", "**Tool call** `start_instance`", "Started vm1", "## Assistant\n\nDone."} {
+		if !strings.Contains(string(md), want) {
+			t.Fatalf("export missing %q:\n%s", want, md)
+		}
+	}
+
+	if err := deleteChatSession(newer.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected Markdown export to be removed with the session")
+	}
+	sessions, _ = listChatSessions()
+	if len(sessions) != 1 || sessions[0].ID != older.ID {
+		t.Fatalf("unexpected sessions after delete: %+v", sessions)
+	}
+}
+
+func TestChatSessionPathRejectsTraversal(t *testing.T) {
+	for _, id := range []string{"", "../x", "a/b", ".hidden"} {
+		if _, err := chatSessionPath(id, ".json"); err == nil {
+			t.Fatalf("expected %q to be rejected", id)
+		}
+	}
+}
+
+func TestChatModelResumeSession(t *testing.T) {
+	m := newChatModel()
+	m.resumeSession(ChatSession{
+		ID:       "s1",
+		Title:    "t",
+		Messages: []ChatMessage{{Role: "user", Content: "hi"}},
+		Entries:  []chatSessionEntry{{Role: "user", Content: "hi"}},
+	})
+	if m.sessionID != "s1" || m.messages[0].Role != "system" || len(m.messages) != 2 {
+		t.Fatalf("unexpected resumed state: id=%q messages=%+v", m.sessionID, m.messages)
+	}
+	snap := m.sessionSnapshot()
+	if snap.Title != "t" || len(snap.Entries) != 2 {
+		t.Fatalf("unexpected snapshot: %+v", snap)
+	}
+}
+
+func TestPickerRenameAndDeleteFollowActiveSession(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	active := ChatSession{ID: "20260101-000000-aaaa", Title: "old", Messages: []ChatMessage{{Role: "user", Content: "hi"}}}
+	if err := saveChatSession(active); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	m := rootModel{currentView: viewChatSessions, table: newTableModel(), chat: newChatModel()}
+	m.chat.resumeSession(active)
+
+	model, _ := m.Update(renameChatSessionCmd(active.ID, "renamed")())
+	m = model.(rootModel)
+	if snap := m.chat.sessionSnapshot(); snap.ID != active.ID || snap.Title != "renamed" {
+		t.Fatalf("autosave would write %q/%q after the rename", snap.ID, snap.Title)
+	}
+
+	model, _ = m.Update(deleteChatSessionCmd(active.ID)())
+	m = model.(rootModel)
+	if m.chat.sessionID == active.ID || m.chat.sessionID == "" {
+		t.Fatalf("autosave would recreate the deleted session (id %q)", m.chat.sessionID)
+	}
+	if len(m.chat.messages) != 2 {
+		t.Fatalf("the conversation should stay open, got %+v", m.chat.messages)
+	}
+	if sessions, _ := listChatSessions(); len(sessions) != 0 {
+		t.Fatalf("deleted session still listed: %+v", sessions)
+	}
+}
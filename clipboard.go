@@ -0,0 +1,24 @@
+// clipboard.go - Copying text to the system clipboard, with an OSC 52 fallback for remote terminals
+package main
+
+import (
+	"os"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copyToClipboardCmd copies text to the clipboard. Without a local clipboard
+// tool (e.g. over SSH) it asks the terminal to do it with OSC 52.
+func copyToClipboardCmd(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := clipboard.WriteAll(text); err == nil {
+			return chatCopiedMsg{what: what}
+		}
+		if _, err := osc52.New(text).WriteTo(os.Stderr); err != nil {
+			return chatCopiedMsg{what: what, err: err}
+		}
+		return chatCopiedMsg{what: what, via: " (via the terminal, OSC 52)"}
+	}
+}
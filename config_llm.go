@@ -4,27 +4,181 @@ package main
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-// LLMConfig holds the LLM integration settings.
+// LLMConfig holds the LLM integration settings. Provider, BaseURL, APIKey and
+// Model are the effective values of the active profile.
 type LLMConfig struct {
+	Provider  string // wire format: providerOpenAI or providerAnthropic
 	BaseURL   string
 	APIKey    string
 	Model     string
 	MCPBinary string
+	Stream    bool   // stream responses over SSE; disable for endpoints that mishandle it
+	Tools     string // tool backend: "auto" (MCP, falling back to built-in), "mcp", or "native"
+
+	// Alternatives to a plaintext api-key (see llm_apikey.go)
+	APIKeyEnv     string // environment variable holding the key
+	APIKeyCommand string // shell command printing the key
+	APIKeyFile    string // passphrase-encrypted key file
+	keyPassphrase string // unlocks APIKeyFile for this session; never saved
+
+	ContextTokens    int  // context window override in tokens; 0 picks one from the model name
+	SummarizeHistory bool // summarize trimmed history with the same model
+	ParallelTools    int  // tool calls from one turn run at once; 1 = one after another
+
+	MaxRetries     int      // retries per model on 429/5xx/timeouts; 0 disables
+	FallbackModels []string // models tried in order when the active one keeps failing
+
+	Profile  string       // name of the active profile
+	Profiles []LLMProfile // named provider profiles, switchable from the chat panel
+
+	Prices     map[string]ModelPrice // USD per million tokens by model name, from [prices]
+	SpendLimit float64               // daily USD soft limit; 0 disables the warning
+
+	MCPServers []MCPServerConfig // extra MCP servers from [mcp NAME] sections
+}
+
+// MCPServerConfig is an extra MCP server used next to multipass-mcp, from an
+// [mcp NAME] section. Its tools are offered to the model as NAME__tool. A
+// server with a URL is already running and is reached over HTTP; otherwise
+// Command is started.
+type MCPServerConfig struct {
+	Name    string
+	Command string
+	Args    []string // one per arg= line
+	Env     []string // KEY=VALUE, one per env= line, added to PassGo's environment
+
+	URL      string   // Streamable HTTP endpoint, e.g. http://localhost:8000/mcp
+	Headers  []string // "Name: value", one per header= line
+	Token    string   // sent as a bearer token
+	TokenEnv string   // environment variable holding the token, used when Token is empty
+}
+
+// LLMProfile is a named endpoint/model combination stored as a [profile NAME] section.
+type LLMProfile struct {
+	Name          string
+	Provider      string
+	BaseURL       string
+	APIKey        string
+	APIKeyEnv     string
+	APIKeyCommand string
+	APIKeyFile    string
+	Model         string
+}
+
+// Wire formats spoken by LLMClient.
+const (
+	providerOpenAI    = "openai"    // OpenAI chat completions (OpenRouter, Ollama, LiteLLM, …)
+	providerAnthropic = "anthropic" // Anthropic Messages API
+)
+
+// defaultProfileName names the profile built from top-level keys in a config
+// file written before profiles existed.
+const defaultProfileName = "default"
+
+// presetLLMProfiles are always offered, unless the config file defines a
+// profile with the same name.
+func presetLLMProfiles() []LLMProfile {
+	return []LLMProfile{
+		{Name: "openrouter", Provider: providerOpenAI, BaseURL: DefaultLLMBaseURL, Model: DefaultLLMModel},
+		{Name: "ollama", Provider: providerOpenAI, BaseURL: "http://localhost:11434/v1", Model: "llama3.1"},
+		{Name: "openai", Provider: providerOpenAI, BaseURL: "https://api.openai.com/v1", Model: "gpt-4o-mini"},
+		{Name: "anthropic", Provider: providerAnthropic, BaseURL: "https://api.anthropic.com/v1", Model: "claude-sonnet-4-5"},
+	}
+}
+
+// profileIndex returns the index of the named profile, or -1.
+func (c LLMConfig) profileIndex(name string) int {
+	for i, p := range c.Profiles {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// setActiveProfile makes the named profile active and copies its values into the
+// effective settings. Returns false if no such profile exists.
+func (c *LLMConfig) setActiveProfile(name string) bool {
+	i := c.profileIndex(name)
+	if i < 0 {
+		return false
+	}
+	p := c.Profiles[i]
+	c.Profile = p.Name
+	c.Provider = p.Provider
+	c.BaseURL = p.BaseURL
+	c.APIKey = p.APIKey
+	c.APIKeyEnv = p.APIKeyEnv
+	c.APIKeyCommand = p.APIKeyCommand
+	c.APIKeyFile = p.APIKeyFile
+	c.Model = p.Model
+	return true
 }
 
+// nextProfile returns the name of the profile after the active one, wrapping around.
+func (c LLMConfig) nextProfile() string {
+	if len(c.Profiles) == 0 {
+		return c.Profile
+	}
+	i := c.profileIndex(c.Profile)
+	return c.Profiles[(i+1)%len(c.Profiles)].Name
+}
+
+// syncActiveProfile writes the effective settings back into the active profile,
+// e.g. after they were edited in the settings form.
+func (c *LLMConfig) syncActiveProfile() {
+	p := LLMProfile{Name: c.Profile, Provider: c.Provider, BaseURL: c.BaseURL, APIKey: c.APIKey,
+		APIKeyEnv: c.APIKeyEnv, APIKeyCommand: c.APIKeyCommand, APIKeyFile: c.APIKeyFile, Model: c.Model}
+	// Copy first: configs are passed by value and must not share profile storage
+	c.Profiles = append([]LLMProfile(nil), c.Profiles...)
+	if i := c.profileIndex(c.Profile); i >= 0 {
+		c.Profiles[i] = p
+		return
+	}
+	c.Profiles = append(c.Profiles, p)
+}
+
+// addPresetProfiles appends presets whose names are not already taken.
+func (c *LLMConfig) addPresetProfiles() {
+	for _, p := range presetLLMProfiles() {
+		if c.profileIndex(p.Name) < 0 {
+			c.Profiles = append(c.Profiles, p)
+		}
+	}
+}
+
+func validProvider(p string) bool {
+	return p == providerOpenAI || p == providerAnthropic
+}
+
+// Tool backends selectable with the `tools` key.
+const (
+	toolBackendAuto   = "auto"
+	toolBackendMCP    = "mcp"
+	toolBackendNative = "native"
+)
+
 const llmConfigFile = "llm.conf"
 
 // defaultLLMConfig returns the default configuration.
 func defaultLLMConfig() LLMConfig {
-	return LLMConfig{
-		BaseURL: DefaultLLMBaseURL,
-		Model:   DefaultLLMModel,
+	cfg := LLMConfig{
+		Stream:        true,
+		Tools:         toolBackendAuto,
+		ParallelTools: DefaultParallelTools,
+		MaxRetries:    DefaultLLMMaxRetries,
 	}
+	cfg.addPresetProfiles()
+	cfg.setActiveProfile("openrouter")
+	return cfg
 }
 
 // llmConfigPath returns the full path to the LLM config file.
@@ -39,28 +193,84 @@ func llmConfigPath() (string, error) {
 // loadLLMConfig reads the LLM config from ~/.passgo/llm.conf.
 // Returns default config if the file doesn't exist.
 func loadLLMConfig() (LLMConfig, error) {
-	cfg := defaultLLMConfig()
-
 	path, err := llmConfigPath()
 	if err != nil {
-		return cfg, err
+		return defaultLLMConfig(), err
 	}
 
 	f, err := os.Open(path) // #nosec G304 -- path from UserHomeDir
 	if err != nil {
 		if os.IsNotExist(err) {
-			return cfg, nil
+			return defaultLLMConfig(), nil
 		}
-		return cfg, err
+		return defaultLLMConfig(), err
 	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
+	return parseLLMConfig(f)
+}
+
+// checkMCPServerName rejects server names that would make tool names ambiguous:
+// ones containing the separator, and ones whose tool prefix matches an earlier
+// server's (e.g. "my.docs" and "my_docs" both become my_docs__).
+func checkMCPServerName(name string, prefixes map[string]string) error {
+	if strings.Contains(name, mcpToolSeparator) {
+		return fmt.Errorf("server names may not contain %q", mcpToolSeparator)
+	}
+	if other, ok := prefixes[mcpToolPrefix(name)]; ok {
+		return fmt.Errorf("tool names would clash with [mcp %s]", other)
+	}
+	return nil
+}
+
+// parseLLMConfig reads key=value lines. Keys before any section are global;
+// keys after a "[profile NAME]" line belong to that profile.
+func parseLLMConfig(r io.Reader) (LLMConfig, error) {
+	cfg := defaultLLMConfig()
+	cfg.Profiles = nil
+	cfg.Profile = ""
+
+	var profile *LLMProfile
+	var profiles []LLMProfile
+	var server *MCPServerConfig
+	serverPrefixes := map[string]string{} // tool prefix → server name
+	inPrices := false
+	flush := func() {
+		if profile != nil && profile.Name != "" {
+			profiles = append(profiles, *profile)
+		}
+		if server != nil && server.Name != "" && (server.Command != "" || server.URL != "") {
+			if err := checkMCPServerName(server.Name, serverPrefixes); err != nil {
+				if appLogger != nil {
+					appLogger.Printf("llm.conf: skipping [mcp %s]: %v", server.Name, err)
+				}
+				return
+			}
+			serverPrefixes[mcpToolPrefix(server.Name)] = server.Name
+			cfg.MCPServers = append(cfg.MCPServers, *server)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			flush()
+			section := strings.TrimSpace(line[1 : len(line)-1])
+			name, ok := strings.CutPrefix(section, "profile ")
+			profile, server = nil, nil
+			inPrices = section == "prices"
+			if ok {
+				profile = &LLMProfile{Name: strings.TrimSpace(name), Provider: providerOpenAI}
+			}
+			if name, ok := strings.CutPrefix(section, "mcp "); ok {
+				server = &MCPServerConfig{Name: strings.TrimSpace(name)}
+			}
+			continue
+		}
 		key, val, ok := strings.Cut(line, "=")
 		if !ok {
 			continue
@@ -68,21 +278,138 @@ func loadLLMConfig() (LLMConfig, error) {
 		key = strings.TrimSpace(key)
 		val = strings.TrimSpace(val)
 
+		if inPrices {
+			// model = input,output (USD per million tokens)
+			in, out, ok := strings.Cut(val, ",")
+			inPrice, errIn := strconv.ParseFloat(strings.TrimSpace(in), 64)
+			outPrice, errOut := strconv.ParseFloat(strings.TrimSpace(out), 64)
+			if ok && key != "" && errIn == nil && errOut == nil && inPrice >= 0 && outPrice >= 0 {
+				if cfg.Prices == nil {
+					cfg.Prices = map[string]ModelPrice{}
+				}
+				cfg.Prices[key] = ModelPrice{Input: inPrice, Output: outPrice}
+			}
+			continue
+		}
+
+		if server != nil {
+			switch key {
+			case "command":
+				server.Command = val
+			case "arg":
+				server.Args = append(server.Args, val)
+			case "env":
+				if strings.Contains(val, "=") {
+					server.Env = append(server.Env, val)
+				}
+			case "url":
+				server.URL = val
+			case "header":
+				if name, _, ok := strings.Cut(val, ":"); ok && strings.TrimSpace(name) != "" {
+					server.Headers = append(server.Headers, val)
+				}
+			case "token":
+				server.Token = val
+			case "token-env":
+				server.TokenEnv = val
+			}
+			continue
+		}
+
+		if profile != nil {
+			switch key {
+			case "provider":
+				if validProvider(val) {
+					profile.Provider = val
+				}
+			case "base-url":
+				profile.BaseURL = val
+			case "api-key":
+				profile.APIKey = val
+			case "api-key-env":
+				profile.APIKeyEnv = val
+			case "api-key-command":
+				profile.APIKeyCommand = val
+			case "api-key-file":
+				profile.APIKeyFile = val
+			case "model":
+				profile.Model = val
+			}
+			continue
+		}
+
 		switch key {
+		case "profile":
+			cfg.Profile = val
+		case "provider":
+			if validProvider(val) {
+				cfg.Provider = val
+			}
 		case "base-url":
 			if val != "" {
 				cfg.BaseURL = val
 			}
 		case "api-key":
 			cfg.APIKey = val
+		case "api-key-env":
+			cfg.APIKeyEnv = val
+		case "api-key-command":
+			cfg.APIKeyCommand = val
+		case "api-key-file":
+			cfg.APIKeyFile = val
 		case "model":
 			if val != "" {
 				cfg.Model = val
 			}
 		case "mcp-binary":
 			cfg.MCPBinary = val
+		case "tools":
+			switch val {
+			case toolBackendAuto, toolBackendMCP, toolBackendNative:
+				cfg.Tools = val
+			}
+		case "context-tokens":
+			if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+				cfg.ContextTokens = n
+			}
+		case "parallel-tools":
+			if n, err := strconv.Atoi(val); err == nil && n >= 1 {
+				cfg.ParallelTools = n
+			}
+		case "max-retries":
+			if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+				cfg.MaxRetries = n
+			}
+		case "fallback-models":
+			cfg.FallbackModels = nil
+			for _, model := range strings.Split(val, ",") {
+				if model = strings.TrimSpace(model); model != "" {
+					cfg.FallbackModels = append(cfg.FallbackModels, model)
+				}
+			}
+		case "summarize-history":
+			if b, err := strconv.ParseBool(val); err == nil {
+				cfg.SummarizeHistory = b
+			}
+		case "stream":
+			if b, err := strconv.ParseBool(val); err == nil {
+				cfg.Stream = b
+			}
+		case "spend-limit":
+			if f, err := strconv.ParseFloat(val, 64); err == nil && f >= 0 {
+				cfg.SpendLimit = f
+			}
 		}
 	}
+	flush()
+
+	cfg.Profiles = profiles
+	cfg.addPresetProfiles()
+	if cfg.Profile == "" || !cfg.setActiveProfile(cfg.Profile) {
+		// Older files (or an unknown profile name): the top-level keys are the profile
+		cfg.Profile = defaultProfileName
+		cfg.syncActiveProfile()
+	}
 
 	return cfg, scanner.Err()
 }
@@ -98,13 +425,84 @@ func saveLLMConfig(cfg LLMConfig) error {
 		return err
 	}
 
-	content := fmt.Sprintf(`# PassGo LLM Configuration
-# Any OpenAI-compatible endpoint works (OpenRouter, Ollama, OpenAI, LiteLLM, etc.)
+	cfg.syncActiveProfile()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `# PassGo LLM Configuration
+# Any OpenAI-compatible endpoint works (OpenRouter, Ollama, OpenAI, LiteLLM, etc.);
+# provider=anthropic speaks the Anthropic Messages API.
+# The top-level connection keys mirror the active profile.
+# Instead of api-key, the key can come from api-key-env (a variable name),
+# api-key-command (e.g. pass show openrouter) or api-key-file (encrypted; set
+# it up in the settings view, unlock with the passphrase or PASSGO_KEY_PASSPHRASE).
+profile=%s
+provider=%s
 base-url=%s
 api-key=%s
+api-key-env=%s
+api-key-command=%s
+api-key-file=%s
 model=%s
 mcp-binary=%s
-`, cfg.BaseURL, cfg.APIKey, cfg.Model, cfg.MCPBinary)
+stream=%t
+# Tools: auto (multipass-mcp, else built-in), mcp, or native (built-in only, works offline)
+tools=%s
+# History: context window in tokens (0 = guess from model), summarize trimmed messages
+context-tokens=%d
+summarize-history=%t
+# Tool calls from one model turn run concurrently, up to this many (1 = in order)
+parallel-tools=%d
+# Warn before sending once today's priced spend reaches this many USD (0 = off)
+spend-limit=%s
+# Retry rate limits, 5xx and timeouts this many times per model, then try the
+# comma-separated fallback models in order
+max-retries=%d
+fallback-models=%s
+`, cfg.Profile, cfg.Provider, cfg.BaseURL, cfg.APIKey, cfg.APIKeyEnv, cfg.APIKeyCommand, cfg.APIKeyFile, cfg.Model, cfg.MCPBinary, cfg.Stream, cfg.Tools,
+		cfg.ContextTokens, cfg.SummarizeHistory, cfg.ParallelTools, strconv.FormatFloat(cfg.SpendLimit, 'f', -1, 64),
+		cfg.MaxRetries, strings.Join(cfg.FallbackModels, ","))
+
+	// Per-model prices in USD per million tokens: model = input,output
+	b.WriteString("\n[prices]\n")
+	models := make([]string, 0, len(cfg.Prices))
+	for model := range cfg.Prices {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		p := cfg.Prices[model]
+		fmt.Fprintf(&b, "%s=%s,%s\n", model,
+			strconv.FormatFloat(p.Input, 'f', -1, 64), strconv.FormatFloat(p.Output, 'f', -1, 64))
+	}
+
+	for _, p := range cfg.Profiles {
+		fmt.Fprintf(&b, "\n[profile %s]\nprovider=%s\nbase-url=%s\napi-key=%s\nmodel=%s\n",
+			p.Name, p.Provider, p.BaseURL, p.APIKey, p.Model)
+		for _, kv := range [][2]string{{"api-key-env", p.APIKeyEnv}, {"api-key-command", p.APIKeyCommand}, {"api-key-file", p.APIKeyFile}} {
+			if kv[1] != "" {
+				fmt.Fprintf(&b, "%s=%s\n", kv[0], kv[1])
+			}
+		}
+	}
+
+	// Extra MCP servers: one arg=, env= and header= line per argument, variable and header
+	for _, srv := range cfg.MCPServers {
+		fmt.Fprintf(&b, "\n[mcp %s]\n", srv.Name)
+		for _, kv := range [][2]string{{"command", srv.Command}, {"url", srv.URL}, {"token", srv.Token}, {"token-env", srv.TokenEnv}} {
+			if kv[1] != "" {
+				fmt.Fprintf(&b, "%s=%s\n", kv[0], kv[1])
+			}
+		}
+		for _, arg := range srv.Args {
+			fmt.Fprintf(&b, "arg=%s\n", arg)
+		}
+		for _, env := range srv.Env {
+			fmt.Fprintf(&b, "env=%s\n", env)
+		}
+		for _, h := range srv.Headers {
+			fmt.Fprintf(&b, "header=%s\n", h)
+		}
+	}
 
-	return os.WriteFile(path, []byte(content), 0o600)
+	return os.WriteFile(path, []byte(b.String()), 0o600)
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLLMConfigLegacy(t *testing.T) {
+	cfg, err := parseLLMConfig(strings.NewReader("base-url=http://example/v1\napi-key=k\nmodel=m\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.Profile != defaultProfileName || cfg.BaseURL != "http://example/v1" || cfg.Model != "m" || cfg.Provider != providerOpenAI {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if i := cfg.profileIndex(defaultProfileName); i < 0 || cfg.Profiles[i].APIKey != "k" {
+		t.Fatalf("top-level keys should become the default profile: %+v", cfg.Profiles)
+	}
+	if cfg.profileIndex("anthropic") < 0 {
+		t.Fatalf("presets should be offered alongside the default profile")
+	}
+}
+
+func TestParseLLMConfigProfiles(t *testing.T) {
+	conf := `profile=work
+model=ignored
+stream=false
+
+[profile work]
+provider=anthropic
+base-url=https://proxy/v1
+api-key=secret
+model=claude-x
+
+[profile ollama]
+base-url=http://gpu:11434/v1
+model=qwen3
+`
+	cfg, err := parseLLMConfig(strings.NewReader(conf))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.Profile != "work" || cfg.Provider != providerAnthropic || cfg.Model != "claude-x" || cfg.APIKey != "secret" {
+		t.Fatalf("active profile not applied: %+v", cfg)
+	}
+	if cfg.Stream {
+		t.Fatalf("global keys should still apply")
+	}
+	if i := cfg.profileIndex("ollama"); i < 0 || cfg.Profiles[i].BaseURL != "http://gpu:11434/v1" {
+		t.Fatalf("file profile should override the preset: %+v", cfg.Profiles)
+	}
+	if n := strings.Count(profileNames(cfg), "ollama"); n != 1 {
+		t.Fatalf("expected one ollama profile, got %d", n)
+	}
+}
+
+func TestSaveLoadLLMConfigProfiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg := defaultLLMConfig()
+	if !cfg.setActiveProfile("ollama") {
+		t.Fatalf("ollama preset missing")
+	}
+	cfg.Model = "mistral"
+	cfg.MaxRetries = 5
+	cfg.FallbackModels = []string{"llama3", "qwen3"}
+	if err := saveLLMConfig(cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	got, err := loadLLMConfig()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got.Profile != "ollama" || got.Model != "mistral" {
+		t.Fatalf("active profile not persisted: %+v", got)
+	}
+	if got.MaxRetries != 5 || strings.Join(got.FallbackModels, ",") != "llama3,qwen3" {
+		t.Fatalf("retry settings not persisted: %d %v", got.MaxRetries, got.FallbackModels)
+	}
+	if got.nextProfile() == "ollama" {
+		t.Fatalf("nextProfile should move to another profile")
+	}
+}
+
+func profileNames(cfg LLMConfig) string {
+	var names []string
+	for _, p := range cfg.Profiles {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,191 @@
+// config_mounts.go - Persistent mount profiles stored in ~/.passgo/mounts.json
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// MountProfile is a mount that should exist on a VM, reapplied after the VM is recreated.
+type MountProfile struct {
+	Source  string   `json:"source"`
+	Target  string   `json:"target"`
+	UIDMaps []string `json:"uid_maps,omitempty"`
+	GIDMaps []string `json:"gid_maps,omitempty"`
+	Type    string   `json:"type,omitempty"` // "classic" or "native"; empty uses the multipass default
+}
+
+// MountProfiles holds the desired mounts for every VM, keyed by VM name.
+type MountProfiles struct {
+	// AutoReconcile mounts missing profile entries when a VM reaches Running.
+	AutoReconcile bool                      `json:"auto_reconcile"`
+	VMs           map[string][]MountProfile `json:"vms"`
+}
+
+const mountProfilesFile = "mounts.json"
+
+// mountProfilesMu serializes read-modify-write cycles from concurrent tea.Cmds.
+var mountProfilesMu sync.Mutex
+
+// defaultMountProfiles returns an empty profile set with auto-reconcile enabled.
+func defaultMountProfiles() MountProfiles {
+	return MountProfiles{AutoReconcile: true, VMs: map[string][]MountProfile{}}
+}
+
+// mountProfilesPath returns the full path to the mount profiles file.
+func mountProfilesPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".passgo", mountProfilesFile), nil
+}
+
+// loadMountProfiles reads ~/.passgo/mounts.json.
+// Returns an empty profile set if the file doesn't exist.
+func loadMountProfiles() (MountProfiles, error) {
+	profiles := defaultMountProfiles()
+
+	path, err := mountProfilesPath()
+	if err != nil {
+		return profiles, err
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- path from UserHomeDir
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return profiles, err
+	}
+
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return defaultMountProfiles(), err
+	}
+	if profiles.VMs == nil {
+		profiles.VMs = map[string][]MountProfile{}
+	}
+	return profiles, nil
+}
+
+// saveMountProfiles writes the profile set to ~/.passgo/mounts.json.
+func saveMountProfiles(profiles MountProfiles) error {
+	path, err := mountProfilesPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// updateMountProfiles loads the profile set, applies fn, and saves the result.
+func updateMountProfiles(fn func(*MountProfiles)) error {
+	mountProfilesMu.Lock()
+	defer mountProfilesMu.Unlock()
+
+	profiles, err := loadMountProfiles()
+	if err != nil {
+		return err
+	}
+	fn(&profiles)
+	return saveMountProfiles(profiles)
+}
+
+// rememberMount records (or replaces, by target path) a mount in the VM's profile.
+func rememberMount(vmName string, mount MountProfile) error {
+	return updateMountProfiles(func(p *MountProfiles) {
+		p.VMs[vmName] = upsertMountProfile(p.VMs[vmName], mount)
+	})
+}
+
+// forgetMount removes the mount with the given target path from the VM's profile.
+func forgetMount(vmName, target string) error {
+	return updateMountProfiles(func(p *MountProfiles) {
+		remaining := removeMountProfile(p.VMs[vmName], target)
+		if len(remaining) == 0 {
+			delete(p.VMs, vmName)
+			return
+		}
+		p.VMs[vmName] = remaining
+	})
+}
+
+// mountProfilesFor returns the saved mounts for a VM (nil if none or on error).
+func mountProfilesFor(vmName string) []MountProfile {
+	mountProfilesMu.Lock()
+	defer mountProfilesMu.Unlock()
+
+	profiles, err := loadMountProfiles()
+	if err != nil {
+		if appLogger != nil {
+			appLogger.Printf("failed to load mount profiles: %v", err)
+		}
+		return nil
+	}
+	return profiles.VMs[vmName]
+}
+
+// sharedMountWarning returns a warning when source is mounted into more than one
+// VM according to the saved profiles, or "" otherwise.
+func sharedMountWarning(source string) string {
+	mountProfilesMu.Lock()
+	profiles, err := loadMountProfiles()
+	mountProfilesMu.Unlock()
+	if err != nil {
+		return ""
+	}
+	vms := vmsSharingSource(profiles, source)
+	if len(vms) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("⚠ %s is mounted read-write into %d VMs (%s)", source, len(vms), strings.Join(vms, ", "))
+}
+
+// profileForMount builds a profile from a live mount. getVMMounts does not
+// report the mount type, so it is taken from the saved profile when present.
+func profileForMount(vmName string, mount MountInfo) MountProfile {
+	profile := MountProfile{
+		Source:  mount.SourcePath,
+		Target:  mount.TargetPath,
+		UIDMaps: mount.UIDMaps,
+		GIDMaps: mount.GIDMaps,
+	}
+	for _, saved := range mountProfilesFor(vmName) {
+		if saved.Target == mount.TargetPath {
+			profile.Type = saved.Type
+		}
+	}
+	return profile
+}
+
+func upsertMountProfile(list []MountProfile, mount MountProfile) []MountProfile {
+	for i, existing := range list {
+		if existing.Target == mount.Target {
+			list[i] = mount
+			return list
+		}
+	}
+	return append(list, mount)
+}
+
+func removeMountProfile(list []MountProfile, target string) []MountProfile {
+	var out []MountProfile
+	for _, existing := range list {
+		if existing.Target != target {
+			out = append(out, existing)
+		}
+	}
+	return out
+}
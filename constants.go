@@ -40,6 +40,12 @@ const (
 	VMNameRandomLength = 4
 )
 
+// Mount Defaults
+const (
+	// DefaultMountInstanceID is the uid/gid inside the VM that host IDs map to (the default ubuntu user)
+	DefaultMountInstanceID = 1000
+)
+
 // LLM Configuration Defaults
 const (
 	// DefaultLLMBaseURL is the default API endpoint
@@ -50,6 +56,12 @@ const (
 
 	// MaxAgentIterations limits the agent loop to prevent infinite tool calls
 	MaxAgentIterations = 20
+
+	// DefaultParallelTools is how many tool calls from one model turn run at once
+	DefaultParallelTools = 4
+
+	// DefaultLLMMaxRetries is how often a rate-limited or failing LLM request is retried per model
+	DefaultLLMMaxRetries = 3
 )
 
 // LLMSystemPrompt is the base system prompt sent to the LLM.
@@ -63,6 +75,7 @@ CRITICAL RULES:
 - NEVER create, launch, start, stop, or delete VMs unless the user explicitly asks.
 - For informational questions (e.g. "how many VMs?", "what's running?"), answer from the VM STATE below. Do NOT call any tools.
 - Only use tools for actions that change state (launch, start, stop, delete, exec_command, etc.).
+- Tool calls that change or delete VMs are shown to the user for approval first. If a call is denied, do not retry it.
 - When you do perform operations, confirm what you did in your final response.
 - Keep responses concise.`
 
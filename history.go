@@ -0,0 +1,282 @@
+// history.go - Token-aware conversation trimming that keeps tool-call pairs intact
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// defaultContextTokens is the context window assumed for models not in modelContextWindows.
+const defaultContextTokens = 32000
+
+// modelContextWindows maps model name fragments to their context window in tokens.
+// The first matching fragment wins, so more specific names come first.
+var modelContextWindows = []struct {
+	match  string
+	tokens int
+}{
+	{"gpt-4o-mini", 128000},
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1000000},
+	{"gpt-3.5", 16000},
+	{"o1", 128000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"deepseek", 64000},
+	{"gemini", 1000000},
+	{"llama3.1", 128000},
+	{"llama3.2", 128000},
+	{"llama3", 8000},
+	{"qwen2.5", 32000},
+	{"qwen3", 40000},
+	{"mistral", 32000},
+}
+
+// contextWindowFor returns the context window for a model, or the override when positive.
+func contextWindowFor(model string, override int) int {
+	if override > 0 {
+		return override
+	}
+	m := strings.ToLower(model)
+	for _, w := range modelContextWindows {
+		if strings.Contains(m, w.match) {
+			return w.tokens
+		}
+	}
+	return defaultContextTokens
+}
+
+// historyBudget is the token budget for messages: the context window minus room
+// for the reply (a quarter of the window) and the tool definitions.
+func historyBudget(window int, tools []ToolDef) int {
+	budget := window - window/4
+	if len(tools) > 0 {
+		if b, err := json.Marshal(tools); err == nil {
+			budget -= estimateTextTokens(string(b))
+		}
+	}
+	return max(budget, 1)
+}
+
+// estimateTextTokens approximates tokens as one per four bytes, which is close
+// enough for English text and JSON across common tokenizers.
+func estimateTextTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// estimateMessageTokens approximates the tokens a message costs, including
+// per-message framing and any tool-call arguments.
+func estimateMessageTokens(msg ChatMessage) int {
+	n := 4 + estimateTextTokens(msg.Content)
+	for _, tc := range msg.ToolCalls {
+		n += 4 + estimateTextTokens(tc.Function.Name) + estimateTextTokens(tc.Function.Arguments)
+	}
+	return n
+}
+
+// historyUnits groups messages so that an assistant message with tool calls and
+// the tool results that follow it form one unit that is kept or dropped whole.
+// Tool messages with no preceding assistant call are dropped.
+func historyUnits(messages []ChatMessage) [][]ChatMessage {
+	var units [][]ChatMessage
+	for i := 0; i < len(messages); i++ {
+		msg := messages[i]
+		if msg.Role == "tool" {
+			continue // orphan
+		}
+		unit := []ChatMessage{msg}
+		if msg.Role == "assistant" && len(msg.ToolCalls) > 0 {
+			for i+1 < len(messages) && messages[i+1].Role == "tool" {
+				i++
+				unit = append(unit, messages[i])
+			}
+		}
+		units = append(units, unit)
+	}
+	return units
+}
+
+// trimHistory keeps the system prompt (first message) and the most recent whole
+// units that fit in budget tokens. The newest unit is always kept, and the kept
+// units start with a user turn, as the Anthropic API requires. It returns the
+// messages to send and the ones dropped from the middle.
+func trimHistory(messages []ChatMessage, budget int) (kept, dropped []ChatMessage) {
+	if len(messages) == 0 {
+		return messages, nil
+	}
+	system := messages[0]
+	units := historyUnits(messages[1:])
+
+	used := estimateMessageTokens(system)
+	first := len(units)
+	for first > 0 {
+		cost := 0
+		for _, msg := range units[first-1] {
+			cost += estimateMessageTokens(msg)
+		}
+		if used+cost > budget && first < len(units) {
+			break
+		}
+		used += cost
+		first--
+	}
+	first = userTurnStart(units, first)
+
+	kept = append(kept, system)
+	for _, u := range units[:first] {
+		dropped = append(dropped, u...)
+	}
+	for _, u := range units[first:] {
+		kept = append(kept, u...)
+	}
+	return kept, dropped
+}
+
+// userTurnStart moves first to the nearest unit that opens with a user message:
+// forward when one is left in the kept range, otherwise back to the user turn
+// the newest units answer, even if that goes over budget.
+func userTurnStart(units [][]ChatMessage, first int) int {
+	for i := first; i < len(units); i++ {
+		if units[i][0].Role == "user" {
+			return i
+		}
+	}
+	for i := first - 1; i >= 0; i-- {
+		if units[i][0].Role == "user" {
+			return i
+		}
+	}
+	return first
+}
+
+// historySummaryPrompt asks the model to condense the dropped part of a conversation.
+const historySummaryPrompt = `Summarize the following earlier part of a conversation between a user and an assistant that manages Multipass VMs. Keep facts that later messages may rely on: VM names, actions taken and their results, user preferences. Reply with the summary only, under 200 words.`
+
+// historySummaryTokens is the room kept for the summary in the system prompt.
+// The prompt asks for under 200 words; a longer reply is cut to fit.
+const historySummaryTokens = 400
+
+// historySummaryNote introduces the summary at the end of the system prompt.
+const historySummaryNote = "\n\nSummary of the earlier conversation:\n"
+
+// historySummary caches the summary of a conversation's dropped messages. The
+// chat keeps one across agent runs, so a long conversation is summarized again
+// only when more of it is dropped, not on every turn.
+type historySummary struct {
+	mu   sync.Mutex
+	key  string // fingerprint of the summarized messages
+	text string
+}
+
+type historySummaryKey struct{}
+
+// withHistorySummary makes RunAgent reuse summary across runs.
+func withHistorySummary(ctx context.Context, summary *historySummary) context.Context {
+	return context.WithValue(ctx, historySummaryKey{}, summary)
+}
+
+// historySummaryFrom returns the summary cache of ctx, nil when there is none.
+func historySummaryFrom(ctx context.Context) *historySummary {
+	summary, _ := ctx.Value(historySummaryKey{}).(*historySummary)
+	return summary
+}
+
+// historyFingerprint identifies a list of messages for the summary cache.
+func historyFingerprint(messages []ChatMessage) string {
+	data, _ := json.Marshal(messages) // #nosec G104 -- plain structs always marshal
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// historyTrimmer trims the conversation for each agent iteration and, when
+// enabled, replaces the dropped middle with an LLM-written summary. The summary
+// is cached until the dropped messages change.
+type historyTrimmer struct {
+	client    *LLMClient
+	budget    int
+	summarize bool
+	cache     *historySummary
+}
+
+// newHistoryTrimmer returns a trimmer for one run. cache may be nil, in which
+// case summaries last only for the run.
+func newHistoryTrimmer(client *LLMClient, tools []ToolDef, cache *historySummary) *historyTrimmer {
+	if cache == nil {
+		cache = &historySummary{}
+	}
+	return &historyTrimmer{
+		client:    client,
+		budget:    historyBudget(contextWindowFor(client.Model, client.ContextTokens), tools),
+		summarize: client.SummarizeHistory,
+		cache:     cache,
+	}
+}
+
+// trim returns the messages to send for this iteration.
+func (h *historyTrimmer) trim(ctx context.Context, messages []ChatMessage) []ChatMessage {
+	kept, dropped := trimHistory(messages, h.budget)
+	if len(dropped) == 0 || !h.summarize {
+		return kept
+	}
+
+	// The summary joins the system prompt, since some APIs only take system
+	// text at the top. Trim again with its room reserved, so the summary
+	// covers exactly the messages this trim drops.
+	kept, dropped = trimHistory(messages, h.budget-historySummaryTokens)
+	key := historyFingerprint(dropped)
+
+	h.cache.mu.Lock()
+	summary, ok := h.cache.text, h.cache.key == key
+	h.cache.mu.Unlock()
+	if !ok {
+		var err error
+		summary, err = h.summarizeMessages(ctx, dropped)
+		if err != nil {
+			if appLogger != nil {
+				appLogger.Printf("history summary failed: %v", err)
+			}
+			return kept
+		}
+		h.cache.mu.Lock()
+		h.cache.key, h.cache.text = key, summary
+		h.cache.mu.Unlock()
+	}
+
+	out := append([]ChatMessage(nil), kept...)
+	out[0].Content += historySummaryNote + truncateToRunes(summary, historySummaryTokens*4-len(historySummaryNote))
+	return out
+}
+
+func (h *historyTrimmer) summarizeMessages(ctx context.Context, dropped []ChatMessage) (string, error) {
+	var b strings.Builder
+	for _, msg := range dropped {
+		switch msg.Role {
+		case "tool":
+			fmt.Fprintf(&b, "tool result: %s\n", truncate(msg.Content, 500))
+		default:
+			if msg.Content != "" {
+				fmt.Fprintf(&b, "%s: %s\n", msg.Role, msg.Content)
+			}
+			for _, tc := range msg.ToolCalls {
+				fmt.Fprintf(&b, "%s called %s(%s)\n", msg.Role, tc.Function.Name, tc.Function.Arguments)
+			}
+		}
+	}
+
+	// The transcript itself must fit in the context window
+	transcript := truncate(b.String(), h.budget*3)
+	resp, err := h.client.Chat(ctx, []ChatMessage{
+		{Role: "system", Content: historySummaryPrompt},
+		{Role: "user", Content: transcript},
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
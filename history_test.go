@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func toolCallMsg(id string) ChatMessage {
+	return ChatMessage{Role: "assistant", ToolCalls: []ToolCall{{ID: id, Type: "function", Function: FunctionCall{Name: "list_instances"}}}}
+}
+
+func TestTrimHistoryKeepsToolPairs(t *testing.T) {
+	big := strings.Repeat("x", 4000) // ~1000 tokens
+	messages := []ChatMessage{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: big},
+		toolCallMsg("c1"),
+		{Role: "tool", ToolCallID: "c1", Content: big},
+		{Role: "user", Content: "again"},
+		toolCallMsg("c2"),
+		{Role: "tool", ToolCallID: "c2", Content: "small"},
+		{Role: "tool", ToolCallID: "c2", Content: "small"},
+	}
+
+	kept, dropped := trimHistory(messages, 200)
+	if kept[0].Role != "system" {
+		t.Fatalf("system prompt must be kept first")
+	}
+	if len(dropped) != 3 {
+		t.Fatalf("expected the first user turn and its tool pair to be dropped, got %d", len(dropped))
+	}
+	for i, msg := range kept {
+		if msg.Role == "tool" && (i == 0 || (kept[i-1].Role != "tool" && len(kept[i-1].ToolCalls) == 0)) {
+			t.Fatalf("orphaned tool message at %d: %+v", i, kept)
+		}
+	}
+	if len(kept) != 5 {
+		t.Fatalf("expected system + last turn with both tool results, got %+v", kept)
+	}
+}
+
+func TestTrimHistoryAlwaysKeepsNewestUnit(t *testing.T) {
+	messages := []ChatMessage{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: strings.Repeat("x", 10000)},
+	}
+	kept, dropped := trimHistory(messages, 10)
+	if len(kept) != 2 || len(dropped) != 0 {
+		t.Fatalf("newest message must survive even over budget: kept=%d dropped=%d", len(kept), len(dropped))
+	}
+}
+
+func TestTrimHistoryStartsWithUserTurn(t *testing.T) {
+	big := strings.Repeat("x", 4000) // ~1000 tokens
+	tests := []struct {
+		name     string
+		messages []ChatMessage
+		wantUser string
+	}{
+		{
+			// The budget ends inside a finished turn: drop its leftover replies
+			name: "forward to the next user turn",
+			messages: []ChatMessage{
+				{Role: "system", Content: "sys"},
+				{Role: "user", Content: big},
+				toolCallMsg("c1"),
+				{Role: "tool", ToolCallID: "c1", Content: "small"},
+				{Role: "assistant", Content: "done"},
+				{Role: "user", Content: "latest"},
+			},
+			wantUser: "latest",
+		},
+		{
+			// Several agent iterations since the last user message: keep it
+			name: "back to the current user turn",
+			messages: []ChatMessage{
+				{Role: "system", Content: "sys"},
+				{Role: "user", Content: "start vm1"},
+				toolCallMsg("c1"),
+				{Role: "tool", ToolCallID: "c1", Content: big},
+				toolCallMsg("c2"),
+				{Role: "tool", ToolCallID: "c2", Content: "small"},
+			},
+			wantUser: "start vm1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, _ := trimHistory(tt.messages, 200)
+			if len(kept) < 2 || kept[1].Role != "user" || kept[1].Content != tt.wantUser {
+				t.Fatalf("expected %q first after the system prompt, got %+v", tt.wantUser, kept)
+			}
+			req := toAnthropicRequest("claude", kept, nil)
+			if len(req.Messages) == 0 || req.Messages[0].Role != "user" || req.System != "sys" {
+				t.Fatalf("trimmed history is not a valid Anthropic request: %+v", req)
+			}
+		})
+	}
+}
+
+func TestHistoryUnitsDropsOrphanTools(t *testing.T) {
+	units := historyUnits([]ChatMessage{
+		{Role: "tool", ToolCallID: "gone", Content: "orphan"},
+		{Role: "user", Content: "hi"},
+	})
+	if len(units) != 1 || units[0][0].Role != "user" {
+		t.Fatalf("unexpected units: %+v", units)
+	}
+}
+
+func TestContextWindowFor(t *testing.T) {
+	tests := []struct {
+		model    string
+		override int
+		want     int
+	}{
+		{"deepseek/deepseek-v3.2", 0, 64000},
+		{"openai/gpt-4o-mini", 0, 128000},
+		{"llama3.1:8b", 0, 128000},
+		{"llama3:8b", 0, 8000},
+		{"some-unknown-model", 0, defaultContextTokens},
+		{"deepseek/deepseek-v3.2", 4096, 4096},
+	}
+	for _, tt := range tests {
+		if got := contextWindowFor(tt.model, tt.override); got != tt.want {
+			t.Errorf("contextWindowFor(%q, %d) = %d, want %d", tt.model, tt.override, got, tt.want)
+		}
+	}
+}
+
+func TestHistoryTrimmerSummarizes(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req chatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Messages[0].Content != historySummaryPrompt {
+			t.Errorf("expected summary prompt, got %q", req.Messages[0].Content)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"user asked about vm1"}}]}`))
+	}))
+	defer srv.Close()
+
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m", ContextTokens: 400, SummarizeHistory: true})
+	h := newHistoryTrimmer(client, nil, nil)
+	messages := []ChatMessage{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: strings.Repeat("x", 2000)},
+		{Role: "assistant", Content: "ok"},
+		{Role: "user", Content: "latest"},
+	}
+
+	for i := 0; i < 2; i++ {
+		out := h.trim(context.Background(), messages)
+		if len(out) < 2 || !strings.HasPrefix(out[0].Content, "sys") || !strings.Contains(out[0].Content, "user asked about vm1") {
+			t.Fatalf("expected summary in the system prompt, got %+v", out)
+		}
+		for _, msg := range out[1:] {
+			if msg.Role == "system" {
+				t.Fatalf("summary must not be a second system message: %+v", out)
+			}
+		}
+		if req := toAnthropicRequest("claude", out, nil); req.Messages[0].Role != "user" || !strings.Contains(req.System, "user asked about vm1") {
+			t.Fatalf("summarized history is not a valid Anthropic request: %+v", req)
+		}
+		if out[len(out)-1].Content != "latest" {
+			t.Fatalf("expected latest message last, got %+v", out)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected summary to be cached, got %d LLM calls", calls)
+	}
+}
+
+func TestHistorySummaryLastsAcrossRuns(t *testing.T) {
+	var transcripts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		transcripts = append(transcripts, req.Messages[1].Content)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"earlier talk"}}]}`))
+	}))
+	defer srv.Close()
+
+	// Without room for the summary only "first" is dropped; with it, "second" goes too
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m", ContextTokens: 1200, SummarizeHistory: true})
+	messages := []ChatMessage{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "first " + strings.Repeat("a", 2000)},
+		{Role: "assistant", Content: "ok"},
+		{Role: "user", Content: "second " + strings.Repeat("b", 1200)},
+		{Role: "assistant", Content: "ok"},
+		{Role: "user", Content: "latest " + strings.Repeat("c", 1200)},
+	}
+	if kept, _ := trimHistory(messages, newHistoryTrimmer(client, nil, nil).budget); kept[1].Content[:6] != "second" {
+		t.Fatalf("test setup: expected only the first turn over budget, kept %d messages", len(kept))
+	}
+
+	cache := &historySummary{}
+	for run := 0; run < 2; run++ {
+		out := newHistoryTrimmer(client, nil, cache).trim(context.Background(), messages)
+		if out[1].Content[:6] != "latest" || !strings.Contains(out[0].Content, "earlier talk") {
+			t.Fatalf("run %d: unexpected history %+v", run, out)
+		}
+	}
+	if len(transcripts) != 1 {
+		t.Fatalf("expected one summary across runs, got %d", len(transcripts))
+	}
+	if !strings.Contains(transcripts[0], "first") || !strings.Contains(transcripts[0], "second") {
+		t.Fatalf("summary must cover every dropped message, got %q", transcripts[0])
+	}
+}
@@ -9,12 +9,19 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // maxLLMResponseBytes limits the LLM response body to prevent OOM from malicious responses.
 const maxLLMResponseBytes = 10 * 1024 * 1024 // 10MB
 
+// llmResponseHeaderTimeout bounds the wait for a response to start. It is not
+// a limit on the whole request, so a long SSE stream is not cut off; the
+// caller's context cancels a request that should stop.
+const llmResponseHeaderTimeout = 120 * time.Second
+
 // ChatMessage represents a message in the conversation.
 type ChatMessage struct {
 	Role       string     `json:"role"`
@@ -51,22 +58,115 @@ type ToolDefFunction struct {
 
 // LLMClient is an OpenAI-compatible chat completions client.
 type LLMClient struct {
+	Provider   string // wire format; providerAnthropic uses the Messages API adapter
 	BaseURL    string
-	APIKey     string
+	APIKey     string // resolved on first use when keySource is set
 	Model      string
+	Stream     bool // request SSE streaming from ChatStream
 	HTTPClient *http.Client
+
+	// History management for RunAgent (see history.go)
+	ContextTokens    int  // context window override; 0 uses modelContextWindows
+	SummarizeHistory bool // summarize messages trimmed from the middle
+	ParallelTools    int  // max tool calls run at once per turn; 1 runs them in order
+
+	// Retries (see llm_retry.go)
+	MaxRetries     int           // retries per model for transient failures
+	FallbackModels []string      // tried in order once Model keeps failing
+	retryBaseDelay time.Duration // first backoff; 0 uses llmRetryBaseDelay
+
+	// keySource resolves an API key not stored in llm.conf (environment,
+	// command or encrypted file); keyMu guards the lazy resolution
+	keySource func() (string, error)
+	keyMu     sync.Mutex
+
+	// streamUnsupported is set once the endpoint rejects a streaming request,
+	// so later calls go straight to the non-streaming path.
+	streamUnsupported atomic.Bool
+
+	// usage accumulates token usage of successful requests by model until
+	// TakeUsage; lastModel is the model that answered most recently
+	usageMu   sync.Mutex
+	usage     modelUsage
+	lastModel string
+}
+
+// recordUsage adds the usage of one completed request to model.
+func (c *LLMClient) recordUsage(model string, u Usage) {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	if c.usage == nil {
+		c.usage = modelUsage{}
+	}
+	c.usage.add(model, u)
+	c.lastModel = model
+}
+
+// TakeUsage returns the usage recorded since the last call, by model, and resets it.
+func (c *LLMClient) TakeUsage() modelUsage {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	u := c.usage
+	c.usage = nil
+	return u
+}
+
+// LastModel returns the model that answered the latest request, which differs
+// from Model after a fallback.
+func (c *LLMClient) LastModel() string {
+	c.usageMu.Lock()
+	defer c.usageMu.Unlock()
+	if c.lastModel == "" {
+		return c.Model
+	}
+	return c.lastModel
+}
+
+// newLLMHTTPClient returns an HTTP client that times out waiting for response
+// headers but lets a started response run as long as it keeps going.
+func newLLMHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = llmResponseHeaderTimeout
+	return &http.Client{Transport: transport}
 }
 
 // NewLLMClient creates a new LLM client from config.
 func NewLLMClient(cfg LLMConfig) *LLMClient {
-	return &LLMClient{
-		BaseURL: strings.TrimRight(cfg.BaseURL, "/"),
-		APIKey:  cfg.APIKey,
-		Model:   cfg.Model,
-		HTTPClient: &http.Client{
-			Timeout: 120 * time.Second,
-		},
+	c := &LLMClient{
+		Provider:         cfg.Provider,
+		BaseURL:          strings.TrimRight(cfg.BaseURL, "/"),
+		APIKey:           cfg.APIKey,
+		Model:            cfg.Model,
+		Stream:           cfg.Stream,
+		HTTPClient:       newLLMHTTPClient(),
+		ContextTokens:    cfg.ContextTokens,
+		SummarizeHistory: cfg.SummarizeHistory,
+		ParallelTools:    cfg.ParallelTools,
+		MaxRetries:       cfg.MaxRetries,
+		FallbackModels:   cfg.FallbackModels,
+	}
+	// Keys from other sources are looked up on the first request, off the UI goroutine
+	if cfg.keySource() != keySourcePlain {
+		c.APIKey = ""
+		c.keySource = func() (string, error) { return resolveAPIKey(cfg) }
+	}
+	return c
+}
+
+// apiKey returns the key to send, resolving it from its source once.
+// Failed lookups are retried on the next request.
+func (c *LLMClient) apiKey() (string, error) {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	if c.APIKey != "" || c.keySource == nil {
+		return c.APIKey, nil
+	}
+	key, err := c.keySource()
+	if err != nil {
+		return "", fmt.Errorf("API key: %w", err)
 	}
+	c.APIKey = key
+	return key, nil
 }
 
 // chatRequest is the request body for the chat completions endpoint.
@@ -74,6 +174,13 @@ type chatRequest struct {
 	Model    string        `json:"model"`
 	Messages []ChatMessage `json:"messages"`
 	Tools    []ToolDef     `json:"tools,omitempty"`
+	Stream   bool          `json:"stream,omitempty"`
+	// StreamOptions asks for a final chunk with token usage when streaming
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
 }
 
 // chatResponse is the response from the chat completions endpoint.
@@ -82,70 +189,124 @@ type chatResponse struct {
 		Message      ChatMessage `json:"message"`
 		FinishReason string      `json:"finish_reason"`
 	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
 }
 
 // Chat sends a chat completions request and returns the assistant's response.
+// Transient failures are retried, then the fallback models tried (llm_retry.go).
 func (c *LLMClient) Chat(ctx context.Context, messages []ChatMessage, tools []ToolDef) (ChatMessage, error) {
-	reqBody := chatRequest{
-		Model:    c.Model,
-		Messages: messages,
+	return c.withRetry(ctx, func(model string) (ChatMessage, error) {
+		return c.chatOnce(ctx, model, messages, tools)
+	}, nil)
+}
+
+// chatOnce is a single non-streaming request to model.
+func (c *LLMClient) chatOnce(ctx context.Context, model string, messages []ChatMessage, tools []ToolDef) (ChatMessage, error) {
+	if c.Provider == providerAnthropic {
+		return c.anthropicChat(ctx, model, messages, tools)
+	}
+
+	resp, err := c.post(ctx, chatRequest{Model: model, Messages: messages, Tools: tools})
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	defer resp.Body.Close()
+
+	// Limit response body size to prevent OOM
+	limitedReader := io.LimitReader(resp.Body, maxLLMResponseBytes)
+	respBody, err := io.ReadAll(limitedReader)
+	if err != nil {
+		return ChatMessage{}, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return ChatMessage{}, newLLMHTTPError(resp, respBody)
+	}
+
+	msg, usage, err := parseChatResponse(respBody)
+	if err == nil {
+		c.recordUsage(model, usage)
 	}
-	if len(tools) > 0 {
-		reqBody.Tools = tools
+	return msg, err
+}
+
+// post sends a chat completions request. The caller must close the response body.
+func (c *LLMClient) post(ctx context.Context, reqBody chatRequest) (*http.Response, error) {
+	if len(reqBody.Tools) == 0 {
+		reqBody.Tools = nil
 	}
 
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return ChatMessage{}, fmt.Errorf("marshal request: %w", err)
+		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
 	url := c.BaseURL + "/chat/completions"
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return ChatMessage{}, fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if c.APIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	if reqBody.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if err := c.authorize(req); err != nil {
+		return nil, err
 	}
 
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return ChatMessage{}, fmt.Errorf("LLM request failed: %w", err)
+		return nil, fmt.Errorf("LLM request failed: %w", err)
 	}
-	defer resp.Body.Close()
+	return resp, nil
+}
 
-	// Limit response body size to prevent OOM
-	limitedReader := io.LimitReader(resp.Body, maxLLMResponseBytes)
-	respBody, err := io.ReadAll(limitedReader)
+// authorize adds the API key to req in the provider's header.
+func (c *LLMClient) authorize(req *http.Request) error {
+	key, err := c.apiKey()
 	if err != nil {
-		return ChatMessage{}, fmt.Errorf("read response: %w", err)
+		return err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return ChatMessage{}, fmt.Errorf("LLM API error (HTTP %d): %s", resp.StatusCode, truncate(string(respBody), 200))
+	if c.Provider == providerAnthropic {
+		req.Header.Set("anthropic-version", anthropicVersion)
+		if key != "" {
+			req.Header.Set("x-api-key", key)
+		}
+		return nil
 	}
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	return nil
+}
 
+// parseChatResponse decodes a non-streaming chat completions response body,
+// returning the token usage if the server reported it.
+func parseChatResponse(respBody []byte) (ChatMessage, Usage, error) {
 	var chatResp chatResponse
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return ChatMessage{}, fmt.Errorf("parse response: %w", err)
+		return ChatMessage{}, Usage{}, fmt.Errorf("parse response: %w", err)
 	}
 
 	if chatResp.Error != nil {
-		return ChatMessage{}, fmt.Errorf("LLM error: %s", chatResp.Error.Message)
+		return ChatMessage{}, Usage{}, fmt.Errorf("LLM error: %s", chatResp.Error.Message)
 	}
 
 	if len(chatResp.Choices) == 0 {
-		return ChatMessage{}, fmt.Errorf("LLM returned no choices")
+		return ChatMessage{}, Usage{}, fmt.Errorf("LLM returned no choices")
 	}
 
 	msg := chatResp.Choices[0].Message
 	msg.Role = "assistant"
-	return msg, nil
+	var usage Usage
+	if chatResp.Usage != nil {
+		usage = *chatResp.Usage
+	}
+	return msg, usage, nil
 }
 
 // truncate shortens a string to max length.
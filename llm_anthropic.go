@@ -0,0 +1,313 @@
+// llm_anthropic.go - Anthropic Messages API adapter for LLMClient
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// anthropicVersion is the Messages API version header value.
+const anthropicVersion = "2023-06-01"
+
+// anthropicMaxTokens caps the reply length; the Messages API requires a value.
+const anthropicMaxTokens = 4096
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	MaxTokens int                `json:"max_tokens"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+// anthropicBlock is a content block: text, tool_use or tool_result.
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema interface{} `json:"input_schema"`
+}
+
+// anthropicUsage is the Messages API token count.
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Content []anthropicBlock `json:"content"`
+	Usage   anthropicUsage   `json:"usage"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// anthropicStreamEvent is the data payload of one Messages API SSE event.
+type anthropicStreamEvent struct {
+	Type         string         `json:"type"`
+	Index        int            `json:"index"`
+	ContentBlock anthropicBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"` // message_start
+	Usage anthropicUsage `json:"usage"` // message_delta
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// toAnthropicRequest converts OpenAI-style messages and tools. System messages
+// become the system prompt, tool results become user tool_result blocks, and
+// consecutive messages with the same role are merged as the API requires.
+func toAnthropicRequest(model string, messages []ChatMessage, tools []ToolDef) anthropicRequest {
+	req := anthropicRequest{Model: model, MaxTokens: anthropicMaxTokens}
+
+	var system []string
+	appendBlocks := func(role string, blocks ...anthropicBlock) {
+		if n := len(req.Messages); n > 0 && req.Messages[n-1].Role == role {
+			req.Messages[n-1].Content = append(req.Messages[n-1].Content, blocks...)
+			return
+		}
+		req.Messages = append(req.Messages, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			system = append(system, msg.Content)
+		case "tool":
+			appendBlocks("user", anthropicBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: msg.Content})
+		case "assistant":
+			var blocks []anthropicBlock
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				input := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, anthropicBlock{Type: "tool_use", ID: tc.ID, Name: tc.Function.Name, Input: input})
+			}
+			if len(blocks) > 0 {
+				appendBlocks("assistant", blocks...)
+			}
+		default:
+			appendBlocks("user", anthropicBlock{Type: "text", Text: msg.Content})
+		}
+	}
+	req.System = strings.Join(system, "\n\n")
+
+	for _, t := range tools {
+		schema := t.Function.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		req.Tools = append(req.Tools, anthropicTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: schema,
+		})
+	}
+	return req
+}
+
+// fromAnthropicBlocks converts response content blocks into an assistant message.
+func fromAnthropicBlocks(blocks []anthropicBlock) ChatMessage {
+	msg := ChatMessage{Role: "assistant"}
+	var text strings.Builder
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			text.WriteString(b.Text)
+		case "tool_use":
+			args := string(b.Input)
+			if args == "" {
+				args = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+				ID:       b.ID,
+				Type:     "function",
+				Function: FunctionCall{Name: b.Name, Arguments: args},
+			})
+		}
+	}
+	msg.Content = text.String()
+	return msg
+}
+
+// anthropicPost sends a Messages API request. The caller must close the response body.
+func (c *LLMClient) anthropicPost(ctx context.Context, reqBody anthropicRequest) (*http.Response, error) {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("LLM request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// anthropicChat is the non-streaming Messages API call.
+func (c *LLMClient) anthropicChat(ctx context.Context, model string, messages []ChatMessage, tools []ToolDef) (ChatMessage, error) {
+	resp, err := c.anthropicPost(ctx, toAnthropicRequest(model, messages, tools))
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxLLMResponseBytes))
+	if err != nil {
+		return ChatMessage{}, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ChatMessage{}, newLLMHTTPError(resp, respBody)
+	}
+
+	var out anthropicResponse
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return ChatMessage{}, fmt.Errorf("parse response: %w", err)
+	}
+	if out.Error != nil {
+		return ChatMessage{}, fmt.Errorf("LLM error: %s", out.Error.Message)
+	}
+	c.recordUsage(model, Usage{PromptTokens: out.Usage.InputTokens, CompletionTokens: out.Usage.OutputTokens})
+	return fromAnthropicBlocks(out.Content), nil
+}
+
+// anthropicStream is the streaming Messages API call.
+func (c *LLMClient) anthropicStream(ctx context.Context, model string, messages []ChatMessage, tools []ToolDef, onDelta func(string)) (ChatMessage, error) {
+	reqBody := toAnthropicRequest(model, messages, tools)
+	reqBody.Stream = true
+	resp, err := c.anthropicPost(ctx, reqBody)
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	defer resp.Body.Close()
+
+	body := io.LimitReader(resp.Body, maxLLMResponseBytes)
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(body)
+		return ChatMessage{}, newLLMHTTPError(resp, respBody)
+	}
+	msg, usage, err := readAnthropicStream(body, onDelta)
+	if err == nil {
+		c.recordUsage(model, usage)
+	}
+	return msg, err
+}
+
+// readAnthropicStream assembles content blocks from Messages API SSE events.
+func readAnthropicStream(r io.Reader, onDelta func(string)) (ChatMessage, Usage, error) {
+	var blocks []anthropicBlock
+	var inputs []*strings.Builder
+	var usage Usage
+	gotEvent := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSSELineBytes)
+	for scanner.Scan() {
+		payload, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // event: lines, comments and blank separators
+		}
+		var ev anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(payload)), &ev); err != nil {
+			return ChatMessage{}, Usage{}, fmt.Errorf("parse stream event: %w", err)
+		}
+		gotEvent = true
+
+		switch ev.Type {
+		case "error":
+			if ev.Error != nil {
+				return ChatMessage{}, Usage{}, fmt.Errorf("LLM error: %s", ev.Error.Message)
+			}
+			return ChatMessage{}, Usage{}, fmt.Errorf("LLM error")
+		case "message_start":
+			usage.PromptTokens = ev.Message.Usage.InputTokens
+		case "message_delta":
+			// Output tokens are cumulative
+			usage.CompletionTokens = ev.Usage.OutputTokens
+		case "content_block_start":
+			// Same bound as OpenAI tool-call indexes, so a bad index cannot
+			// panic or make the slices grow without limit
+			if ev.Index < 0 || ev.Index >= maxStreamToolCalls {
+				return ChatMessage{}, Usage{}, fmt.Errorf("stream content block index %d out of range", ev.Index)
+			}
+			for len(blocks) <= ev.Index {
+				blocks = append(blocks, anthropicBlock{})
+				inputs = append(inputs, &strings.Builder{})
+			}
+			blocks[ev.Index] = ev.ContentBlock
+			blocks[ev.Index].Input = nil
+		case "content_block_delta":
+			if ev.Index < 0 || ev.Index >= len(blocks) {
+				continue
+			}
+			switch ev.Delta.Type {
+			case "text_delta":
+				blocks[ev.Index].Text += ev.Delta.Text
+				if onDelta != nil && ev.Delta.Text != "" {
+					onDelta(ev.Delta.Text)
+				}
+			case "input_json_delta":
+				inputs[ev.Index].WriteString(ev.Delta.PartialJSON)
+			}
+		case "message_stop":
+			return finishAnthropicStream(blocks, inputs), usage, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ChatMessage{}, Usage{}, fmt.Errorf("read stream: %w", err)
+	}
+	if !gotEvent {
+		return ChatMessage{}, Usage{}, fmt.Errorf("LLM returned an empty stream")
+	}
+	return finishAnthropicStream(blocks, inputs), usage, nil
+}
+
+func finishAnthropicStream(blocks []anthropicBlock, inputs []*strings.Builder) ChatMessage {
+	for i := range blocks {
+		if blocks[i].Type == "tool_use" && inputs[i].Len() > 0 {
+			blocks[i].Input = json.RawMessage(inputs[i].String())
+		}
+	}
+	return fromAnthropicBlocks(blocks)
+}
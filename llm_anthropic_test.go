@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestToAnthropicRequest(t *testing.T) {
+	messages := []ChatMessage{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "start vm1"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "t1", Type: "function", Function: FunctionCall{Name: "start_instance", Arguments: `{"name":"vm1"}`}},
+			{ID: "t2", Type: "function", Function: FunctionCall{Name: "list_instances", Arguments: ``}},
+		}},
+		{Role: "tool", ToolCallID: "t1", Content: "started"},
+		{Role: "tool", ToolCallID: "t2", Content: "[]"},
+		{Role: "assistant", Content: "Done."},
+	}
+	tools := []ToolDef{{Type: "function", Function: ToolDefFunction{Name: "list_instances", Description: "List VMs"}}}
+
+	req := toAnthropicRequest("claude", messages, tools)
+	if req.System != "be brief" {
+		t.Fatalf("system = %q", req.System)
+	}
+	if len(req.Messages) != 4 {
+		t.Fatalf("expected 4 messages (user, assistant, user, assistant), got %d", len(req.Messages))
+	}
+	roles := []string{"user", "assistant", "user", "assistant"}
+	for i, m := range req.Messages {
+		if m.Role != roles[i] {
+			t.Fatalf("message %d role = %q, want %q", i, m.Role, roles[i])
+		}
+	}
+	calls := req.Messages[1].Content
+	if len(calls) != 2 || calls[0].Type != "tool_use" || string(calls[0].Input) != `{"name":"vm1"}` || string(calls[1].Input) != `{}` {
+		t.Fatalf("unexpected tool_use blocks: %+v", calls)
+	}
+	results := req.Messages[2].Content
+	if len(results) != 2 || results[0].ToolUseID != "t1" || results[1].ToolUseID != "t2" {
+		t.Fatalf("tool results should merge into one user message: %+v", results)
+	}
+	if len(req.Tools) != 1 || req.Tools[0].InputSchema == nil {
+		t.Fatalf("tools not converted: %+v", req.Tools)
+	}
+}
+
+func TestAnthropicChat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" || r.Header.Get("x-api-key") != "k" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("unexpected request %s key=%q", r.URL.Path, r.Header.Get("x-api-key"))
+		}
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.MaxTokens == 0 {
+			t.Errorf("max_tokens is required")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"Starting."},{"type":"tool_use","id":"t1","name":"start_instance","input":{"name":"vm1"}}]}`)
+	}))
+	defer srv.Close()
+
+	client := NewLLMClient(LLMConfig{Provider: providerAnthropic, BaseURL: srv.URL, APIKey: "k", Model: "claude"})
+	msg, err := client.Chat(context.Background(), []ChatMessage{{Role: "user", Content: "start vm1"}}, nil)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if msg.Content != "Starting." || len(msg.ToolCalls) != 1 {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+	if tc := msg.ToolCalls[0]; tc.ID != "t1" || tc.Function.Name != "start_instance" || tc.Function.Arguments != `{"name":"vm1"}` {
+		t.Fatalf("unexpected tool call %+v", tc)
+	}
+}
+
+func TestAnthropicChatStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}`,
+			`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"t1","name":"stop_instance","input":{}}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"name\":"}}`,
+			`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"vm1\"}"}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			fmt.Fprintf(w, "event: x\ndata: %s\n\n", e)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewLLMClient(LLMConfig{Provider: providerAnthropic, BaseURL: srv.URL, Model: "claude", Stream: true})
+	var got strings.Builder
+	msg, err := client.ChatStream(context.Background(), nil, nil, func(d string) { got.WriteString(d) })
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if msg.Content != "Hello" || got.String() != "Hello" {
+		t.Fatalf("content=%q deltas=%q", msg.Content, got.String())
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Arguments != `{"name":"vm1"}` {
+		t.Fatalf("unexpected tool calls %+v", msg.ToolCalls)
+	}
+}
+
+func TestReadAnthropicStreamError(t *testing.T) {
+	stream := "data: {\"type\":\"error\",\"error\":{\"message\":\"overloaded\"}}\n\n"
+	if _, _, err := readAnthropicStream(strings.NewReader(stream), nil); err == nil || !strings.Contains(err.Error(), "overloaded") {
+		t.Fatalf("expected overloaded error, got %v", err)
+	}
+}
+
+func TestReadAnthropicStreamRejectsBadIndex(t *testing.T) {
+	for _, index := range []int{-1, maxStreamToolCalls, 1 << 30} {
+		stream := fmt.Sprintf("data: {\"type\":\"content_block_start\",\"index\":%d,\"content_block\":{\"type\":\"text\"}}\n\n", index)
+		if _, _, err := readAnthropicStream(strings.NewReader(stream), nil); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Fatalf("index %d: expected an out of range error, got %v", index, err)
+		}
+	}
+	// A delta for a negative index is ignored like any unknown block
+	stream := "data: {\"type\":\"content_block_delta\",\"index\":-1,\"delta\":{\"type\":\"text_delta\",\"text\":\"x\"}}\n\n"
+	if msg, _, err := readAnthropicStream(strings.NewReader(stream), nil); err != nil || msg.Content != "" {
+		t.Fatalf("unexpected %+v, %v", msg, err)
+	}
+}
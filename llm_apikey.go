@@ -0,0 +1,226 @@
+// llm_apikey.go - API key sources: llm.conf, environment variable, command, or a passphrase-encrypted file
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Where the API key of a profile comes from.
+const (
+	keySourcePlain     = "plain"     // api-key in llm.conf
+	keySourceEncrypted = "encrypted" // api-key-file, encrypted with a passphrase
+	keySourceEnv       = "env"       // api-key-env names an environment variable
+	keySourceCommand   = "command"   // api-key-command prints the key
+)
+
+// keyPassphraseEnv supplies the passphrase of an encrypted key file, so PassGo
+// can start without asking for it.
+const keyPassphraseEnv = "PASSGO_KEY_PASSPHRASE"
+
+// apiKeyCommandTimeout bounds api-key-command, which may wait on e.g. gpg-agent.
+const apiKeyCommandTimeout = 30 * time.Second
+
+// pbkdf2Iterations is the key-derivation cost for encrypted key files.
+const pbkdf2Iterations = 600_000
+
+var errKeyPassphraseNeeded = errors.New("API key file is encrypted: enter the passphrase in settings (L) or set " + keyPassphraseEnv)
+
+// keySource reports which source the active profile uses.
+func (c LLMConfig) keySource() string {
+	switch {
+	case c.APIKeyCommand != "":
+		return keySourceCommand
+	case c.APIKeyEnv != "":
+		return keySourceEnv
+	case c.APIKeyFile != "":
+		return keySourceEncrypted
+	}
+	return keySourcePlain
+}
+
+// hasAPIKey reports whether any key source is configured (it may still fail to resolve).
+func (c LLMConfig) hasAPIKey() bool {
+	return c.APIKey != "" || c.keySource() != keySourcePlain
+}
+
+// keySourceLabel describes the key's source for the settings view.
+func (c LLMConfig) keySourceLabel() string {
+	switch c.keySource() {
+	case keySourceCommand:
+		return "command: " + c.APIKeyCommand
+	case keySourceEnv:
+		return "environment: $" + c.APIKeyEnv
+	case keySourceEncrypted:
+		return "encrypted file: " + c.APIKeyFile
+	}
+	return "llm.conf"
+}
+
+// resolveAPIKey returns the key from the configured source.
+func resolveAPIKey(c LLMConfig) (string, error) {
+	switch c.keySource() {
+	case keySourceCommand:
+		return runAPIKeyCommand(c.APIKeyCommand)
+	case keySourceEnv:
+		key := strings.TrimSpace(os.Getenv(c.APIKeyEnv))
+		if key == "" {
+			return "", fmt.Errorf("environment variable %s is not set", c.APIKeyEnv)
+		}
+		return key, nil
+	case keySourceEncrypted:
+		passphrase := c.keyPassphrase
+		if passphrase == "" {
+			passphrase = os.Getenv(keyPassphraseEnv)
+		}
+		if passphrase == "" {
+			return "", errKeyPassphraseNeeded
+		}
+		return readEncryptedAPIKey(c.APIKeyFile, passphrase)
+	}
+	return c.APIKey, nil
+}
+
+// runAPIKeyCommand runs the command through the shell and returns the first
+// line it prints, as `pass show NAME` puts the secret there.
+func runAPIKeyCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), apiKeyCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command) // #nosec G204 -- command from the user's own llm.conf
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204 -- command from the user's own llm.conf
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("api-key-command failed: %s", truncate(strings.TrimSpace(string(exitErr.Stderr)), 200))
+		}
+		return "", fmt.Errorf("api-key-command failed: %w", err)
+	}
+	key := strings.TrimSpace(firstLine(string(out)))
+	if key == "" {
+		return "", fmt.Errorf("api-key-command printed nothing")
+	}
+	return key, nil
+}
+
+// maskAPIKey shows only the last four characters of a key.
+func maskAPIKey(key string) string {
+	if key == "" {
+		return "(none)"
+	}
+	if len(key) <= 8 {
+		return strings.Repeat("•", 8)
+	}
+	return strings.Repeat("•", 8) + key[len(key)-4:]
+}
+
+// encryptedKeyFile is the on-disk format of an encrypted API key:
+// AES-256-GCM with a key derived from the passphrase by PBKDF2-SHA256.
+type encryptedKeyFile struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// apiKeyFilePath is where the encrypted key of a profile is stored.
+func apiKeyFilePath(profile string) (string, error) {
+	dir, err := llmConfigPath()
+	if err != nil {
+		return "", err
+	}
+	name := strings.Map(func(r rune) rune {
+		if isMentionChar(r) {
+			return r
+		}
+		return '_'
+	}, profile)
+	return filepath.Join(filepath.Dir(dir), "api-key-"+name+".enc"), nil
+}
+
+func keyFileAEAD(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeEncryptedAPIKey encrypts key with passphrase and writes it to path.
+func writeEncryptedAPIKey(path, key, passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("a passphrase is required")
+	}
+	f := encryptedKeyFile{Version: 1, Iterations: pbkdf2Iterations, Salt: make([]byte, 16)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	aead, err := keyFileAEAD(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Ciphertext = aead.Seal(nil, f.Nonce, []byte(key), nil)
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// readEncryptedAPIKey decrypts the key file at path.
+func readEncryptedAPIKey(path, passphrase string) (string, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path from the user's own llm.conf
+	if err != nil {
+		return "", fmt.Errorf("read API key file: %w", err)
+	}
+	var f encryptedKeyFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return "", fmt.Errorf("parse API key file: %w", err)
+	}
+	if f.Version != 1 || f.Iterations <= 0 {
+		return "", fmt.Errorf("unsupported API key file %s", path)
+	}
+	aead, err := keyFileAEAD(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return "", err
+	}
+	if len(f.Nonce) != aead.NonceSize() {
+		return "", fmt.Errorf("corrupt API key file %s", path)
+	}
+	plain, err := aead.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("wrong passphrase for %s", path)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEncryptedAPIKeyRoundTrip(t *testing.T) {
+	path := t.TempDir() + "/key.enc"
+	if err := writeEncryptedAPIKey(path, "sk-secret-1234", "hunter2"); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if strings.Contains(string(data), "sk-secret") {
+		t.Fatalf("key stored in plaintext")
+	}
+	if got, err := readEncryptedAPIKey(path, "hunter2"); err != nil || got != "sk-secret-1234" {
+		t.Fatalf("decrypt = %q, %v", got, err)
+	}
+	if _, err := readEncryptedAPIKey(path, "wrong"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Fatalf("expected wrong passphrase error, got %v", err)
+	}
+}
+
+func TestResolveAPIKeySources(t *testing.T) {
+	t.Setenv("PASSGO_TEST_KEY", " from-env \n")
+	if got, err := resolveAPIKey(LLMConfig{APIKey: "plain", APIKeyEnv: "PASSGO_TEST_KEY"}); err != nil || got != "from-env" {
+		t.Fatalf("env source = %q, %v", got, err)
+	}
+	if _, err := resolveAPIKey(LLMConfig{APIKeyEnv: "PASSGO_TEST_UNSET"}); err == nil {
+		t.Fatalf("expected an error for an unset variable")
+	}
+	if got, _ := resolveAPIKey(LLMConfig{APIKey: "plain"}); got != "plain" {
+		t.Fatalf("plain source = %q", got)
+	}
+
+	t.Setenv(keyPassphraseEnv, "")
+	if _, err := resolveAPIKey(LLMConfig{APIKeyFile: "/nonexistent"}); err != errKeyPassphraseNeeded {
+		t.Fatalf("expected passphrase prompt, got %v", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if got, err := resolveAPIKey(LLMConfig{APIKeyCommand: "printf 'from-cmd\\nmetadata\\n'"}); err != nil || got != "from-cmd" {
+		t.Fatalf("command source = %q, %v", got, err)
+	}
+	if _, err := resolveAPIKey(LLMConfig{APIKeyCommand: "echo nope >&2; exit 3"}); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Fatalf("expected command stderr in error, got %v", err)
+	}
+}
+
+func TestMaskAPIKey(t *testing.T) {
+	if got := maskAPIKey("sk-or-v1-abcdef1234"); got != "••••••••1234" {
+		t.Fatalf("unexpected mask %q", got)
+	}
+	if got := maskAPIKey("short"); strings.Contains(got, "short") {
+		t.Fatalf("short key leaked: %q", got)
+	}
+}
+
+func TestLLMClientResolvesKeyOnFirstRequest(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("PASSGO_TEST_KEY", "env-key")
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m", APIKey: "stale", APIKeyEnv: "PASSGO_TEST_KEY"})
+	if client.APIKey != "" {
+		t.Fatalf("key must not be resolved before the first request")
+	}
+	if _, err := client.Chat(context.Background(), []ChatMessage{{Role: "user", Content: "hi"}}, nil); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if auth != "Bearer env-key" {
+		t.Fatalf("unexpected Authorization %q", auth)
+	}
+}
+
+func TestLLMSettingsKeySources(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := defaultLLMConfig()
+	cfg.APIKey = "sk-plain-5678"
+
+	m := newLLMSettingsModel(cfg, 100, 40)
+	m, _ = m.Update(llmSettingsKeyMsg{key: cfg.APIKey})
+	if strings.Contains(m.View(), "sk-plain") || !strings.Contains(m.keyStatus, "5678 (llm.conf)") {
+		t.Fatalf("expected only a masked key, status %q", m.keyStatus)
+	}
+
+	// Environment variable: a typed key is refused, the name is required
+	m.setKeySource(2)
+	m.fields[settingsFieldKey].input.SetValue("typed")
+	if _, err := m.save(); err == nil {
+		t.Fatalf("expected a typed key to be refused for the env source")
+	}
+	m.fields[settingsFieldKey].input.SetValue("")
+	m.fields[settingsFieldKeyDetail].input.SetValue("MY_KEY")
+	cmd, err := m.save()
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	saved, ok := cmd().(llmSettingsSavedMsg)
+	if !ok || saved.config.APIKeyEnv != "MY_KEY" || saved.config.APIKey != "" {
+		t.Fatalf("unexpected env config %+v", saved.config)
+	}
+
+	// Encrypted file: the existing plaintext key is moved into the file
+	m.setKeySource(1)
+	m.fields[settingsFieldKeyDetail].input.SetValue("pass phrase")
+	cmd, err = m.save()
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	saved, ok = cmd().(llmSettingsSavedMsg)
+	if !ok || saved.config.APIKey != "" || saved.config.APIKeyFile == "" {
+		t.Fatalf("unexpected encrypted config %+v", saved.config)
+	}
+	if got, err := resolveAPIKey(saved.config); err != nil || got != "sk-plain-5678" {
+		t.Fatalf("resolve encrypted key = %q, %v", got, err)
+	}
+	loaded, err := loadLLMConfig()
+	if err != nil || loaded.APIKeyFile != saved.config.APIKeyFile || loaded.APIKey != "" {
+		t.Fatalf("encrypted source not persisted: %+v, %v", loaded, err)
+	}
+
+	// Unlocking with the wrong passphrase reports an error instead of saving
+	m = newLLMSettingsModel(loaded, 100, 40)
+	m.fields[settingsFieldKeyDetail].input.SetValue("nope")
+	cmd, err = m.save()
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	var msg tea.Msg = cmd()
+	if _, ok := msg.(llmSettingsErrMsg); !ok {
+		t.Fatalf("expected a passphrase error, got %#v", msg)
+	}
+}
@@ -0,0 +1,161 @@
+// llm_models.go - Model discovery (/models, Ollama /api/tags) and the settings connection test
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxModelListBytes limits a model list response; OpenRouter's is a few hundred KB.
+const maxModelListBytes = 8 * 1024 * 1024
+
+// ListModels returns the model IDs the endpoint offers, sorted. Local
+// endpoints are asked through Ollama's /api/tags first, which lists the
+// pulled models even on versions without the OpenAI-compatible /models.
+func (c *LLMClient) ListModels(ctx context.Context) ([]string, error) {
+	if isLocalEndpoint(c.BaseURL) {
+		if models, err := c.ollamaTags(ctx); err == nil {
+			return models, nil
+		}
+	}
+
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := c.getJSON(ctx, c.BaseURL+"/models", true, &list); err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(list.Data))
+	for _, m := range list.Data {
+		if m.ID != "" {
+			models = append(models, m.ID)
+		}
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// ollamaTags lists the models pulled into a local Ollama server.
+func (c *LLMClient) ollamaTags(ctx context.Context) ([]string, error) {
+	root := strings.TrimSuffix(c.BaseURL, "/v1")
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := c.getJSON(ctx, root+"/api/tags", false, &tags); err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		models = append(models, m.Name)
+	}
+	sort.Strings(models)
+	return models, nil
+}
+
+// getJSON fetches url, with the API key when auth is set, and decodes the body into out.
+func (c *LLMClient) getJSON(ctx context.Context, url string, auth bool, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if auth {
+		if err := c.authorize(req); err != nil {
+			return err
+		}
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("LLM request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxModelListBytes))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return newLLMHTTPError(resp, body)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+	return nil
+}
+
+// connectionTestTool is offered in the connection test to check tool calling.
+var connectionTestTool = ToolDef{
+	Type: "function",
+	Function: ToolDefFunction{
+		Name:        "ping",
+		Description: "Answers pong. Call it when asked to ping.",
+		Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+	},
+}
+
+// connectionResult is the outcome of TestConnection.
+type connectionResult struct {
+	latency time.Duration
+	tools   bool // the model called the test tool
+	err     error
+}
+
+// String summarizes the result for the settings view.
+func (r connectionResult) String() string {
+	var httpErr *llmHTTPError
+	if errors.As(r.err, &httpErr) {
+		switch {
+		case httpErr.status == http.StatusUnauthorized || httpErr.status == http.StatusForbidden:
+			return fmt.Sprintf("Authentication failed (HTTP %d): check the API key", httpErr.status)
+		case httpErr.status == http.StatusNotFound:
+			return "Not found (HTTP 404): check the base URL and model name"
+		case toolsUnsupported(httpErr):
+			return fmt.Sprintf("Reachable in %s, but the model does not support tool calling", r.latency.Round(time.Millisecond))
+		}
+	}
+	if r.err != nil {
+		return "Failed: " + r.err.Error()
+	}
+	if !r.tools {
+		return fmt.Sprintf("OK in %s, but the model answered without calling the test tool; tool calling may be unsupported", r.latency.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("OK in %s, tool calling works", r.latency.Round(time.Millisecond))
+}
+
+// toolsUnsupported reports whether a request error says the model cannot take
+// tools. Other errors that merely mention tools, such as a malformed tool
+// schema or a server fault, are shown with the server's own message.
+func toolsUnsupported(httpErr *llmHTTPError) bool {
+	if httpErr.status < 400 || httpErr.status >= 500 {
+		return false
+	}
+	body := strings.ToLower(httpErr.body)
+	if !strings.Contains(body, "tool") {
+		return false
+	}
+	for _, phrase := range []string{"not support", "unsupported", "doesn't support", "not supported", "not available", "not enabled"} {
+		if strings.Contains(body, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestConnection makes one small request that asks the model to call a test
+// tool, without retries or fallbacks, and reports how it went.
+func (c *LLMClient) TestConnection(ctx context.Context) connectionResult {
+	messages := []ChatMessage{{Role: "user", Content: "Call the ping tool once. Do not answer in text."}}
+	started := time.Now()
+	resp, err := c.chatOnce(ctx, c.Model, messages, []ToolDef{connectionTestTool})
+	return connectionResult{latency: time.Since(started), tools: len(resp.ToolCalls) > 0, err: err}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestListModels(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			auth = r.Header.Get("Authorization")
+			_, _ = w.Write([]byte(`{"data":[{"id":"zeta"},{"id":"alpha"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// A local endpoint without Ollama's /api/tags falls back to /models
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL + "/v1", APIKey: "k"})
+	models, err := client.ListModels(context.Background())
+	if err != nil || strings.Join(models, ",") != "alpha,zeta" {
+		t.Fatalf("ListModels = %v, %v", models, err)
+	}
+	if auth != "Bearer k" {
+		t.Fatalf("unexpected Authorization %q", auth)
+	}
+}
+
+func TestListModelsOllamaTags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"models":[{"name":"qwen3:8b"},{"name":"llama3.1:8b"}]}`))
+	}))
+	defer srv.Close()
+
+	models, err := NewLLMClient(LLMConfig{BaseURL: srv.URL + "/v1"}).ListModels(context.Background())
+	if err != nil || strings.Join(models, ",") != "llama3.1:8b,qwen3:8b" {
+		t.Fatalf("ListModels = %v, %v", models, err)
+	}
+}
+
+func TestTestConnection(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"tools", 200, `{"choices":[{"message":{"role":"assistant","tool_calls":[{"id":"1","type":"function","function":{"name":"ping","arguments":"{}"}}]}}]}`, "tool calling works"},
+		{"no tools", 200, `{"choices":[{"message":{"role":"assistant","content":"pong"}}]}`, "tool calling may be unsupported"},
+		{"auth", 401, `{"error":{"message":"bad key"}}`, "Authentication failed (HTTP 401)"},
+		{"unsupported", 400, `{"error":{"message":"model does not support tools"}}`, "does not support tool calling"},
+		{"unsupported 422", 422, `{"error":{"message":"tool_choice is not supported by this model"}}`, "does not support tool calling"},
+		{"bad tool schema", 400, `{"error":{"message":"invalid schema for tool ping"}}`, "Failed: LLM API error (HTTP 400): {\"error\":{\"message\":\"invalid schema for tool ping\"}}"},
+		{"server fault", 500, `{"error":{"message":"tool runner crashed, not supported yet"}}`, "Failed: LLM API error (HTTP 500)"},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			_, _ = w.Write([]byte(tt.body))
+		}))
+		got := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m"}).TestConnection(context.Background()).String()
+		srv.Close()
+		if !strings.Contains(got, tt.want) {
+			t.Fatalf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLLMSettingsModelPicker(t *testing.T) {
+	m := newLLMSettingsModel(defaultLLMConfig(), 100, 40)
+	for !m.fields[m.cursor].isPicker {
+		m.move(1)
+	}
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.picker == nil || cmd == nil {
+		t.Fatalf("expected the picker to open and load models")
+	}
+
+	m, _ = m.Update(llmModelsMsg{models: []string{"anthropic/claude-sonnet", "deepseek/deepseek-v3.2", "openai/gpt-5"}})
+	for _, r := range "deep v3" {
+		m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if len(m.picker.matches) != 1 {
+		t.Fatalf("expected one match, got %v", m.picker.matches)
+	}
+	m, cmd = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m, _ = m.Update(cmd())
+	if m.picker != nil || m.fields[settingsFieldModel].input.Value() != "deepseek/deepseek-v3.2" {
+		t.Fatalf("expected the model to be picked, got %q", m.fields[settingsFieldModel].input.Value())
+	}
+}
@@ -0,0 +1,197 @@
+// llm_retry.go - Retries with exponential backoff and fallback models for LLM requests
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// llmRetryBaseDelay is the backoff before the first retry; it doubles each time.
+	llmRetryBaseDelay = time.Second
+	// llmRetryMaxDelay caps the computed backoff.
+	llmRetryMaxDelay = 30 * time.Second
+	// llmRetryAfterMax is the longest Retry-After we wait for; beyond it the
+	// model counts as failing and the next fallback is tried.
+	llmRetryAfterMax = 2 * time.Minute
+)
+
+// llmHTTPError is a non-200 response from the LLM API.
+type llmHTTPError struct {
+	status     int
+	body       string
+	retryAfter time.Duration // from the Retry-After header; 0 when absent
+}
+
+func (e *llmHTTPError) Error() string {
+	return fmt.Sprintf("LLM API error (HTTP %d): %s", e.status, truncate(e.body, 200))
+}
+
+func newLLMHTTPError(resp *http.Response, body []byte) error {
+	return &llmHTTPError{
+		status:     resp.StatusCode,
+		body:       string(body),
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(max(0, secs)) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// retryableLLMError reports whether a failed request is worth sending again:
+// rate limits, gateway and overload errors, timeouts and dropped connections.
+func retryableLLMError(err error) bool {
+	var httpErr *llmHTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.status {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+			529: // Anthropic "overloaded"
+			return true
+		}
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// modelUnavailableLLMError reports whether a failed request was refused
+// because of the model itself (unknown, retired, not served here): a 404, or a
+// 400 whose body names the model. Another model may still work, so the next
+// fallback is tried; other client errors such as 401/403 are not model-specific.
+func modelUnavailableLLMError(err error, model string) bool {
+	var httpErr *llmHTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	switch httpErr.status {
+	case http.StatusNotFound:
+		return true
+	case http.StatusBadRequest:
+		return model != "" && strings.Contains(strings.ToLower(httpErr.body), strings.ToLower(model))
+	}
+	return false
+}
+
+// llmErrorSummary is a short description of a failure for the chat panel.
+func llmErrorSummary(err error) string {
+	var httpErr *llmHTTPError
+	if errors.As(err, &httpErr) {
+		return fmt.Sprintf("HTTP %d", httpErr.status)
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.Timeout() {
+		return "timeout"
+	}
+	return truncate(err.Error(), 80)
+}
+
+// llmBackoff returns the wait before retry number attempt (0-based): the
+// server's Retry-After when given, otherwise exponential backoff from base
+// with jitter in [d/2, d).
+func llmBackoff(base time.Duration, attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	d := base << attempt
+	if d <= 0 || d > llmRetryMaxDelay {
+		d = llmRetryMaxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int64N(int64(half))) // #nosec G404 -- jitter, not security
+}
+
+type llmRetryReporterKey struct{}
+
+// withLLMRetryReporter attaches a callback that is told about each retry and fallback.
+func withLLMRetryReporter(ctx context.Context, report func(string)) context.Context {
+	return context.WithValue(ctx, llmRetryReporterKey{}, report)
+}
+
+func reportLLMRetry(ctx context.Context, format string, args ...interface{}) {
+	text := fmt.Sprintf(format, args...)
+	if appLogger != nil {
+		appLogger.Printf("LLM: %s", text)
+	}
+	if report, ok := ctx.Value(llmRetryReporterKey{}).(func(string)); ok {
+		report(text)
+	}
+}
+
+// withRetry runs call against the primary model, retrying failures that look
+// transient, then against each fallback model in turn. A model-specific
+// client error moves on to the next fallback without retries; other errors
+// that are not transient are returned at once, and so is any error once
+// partial reports that output already reached the user.
+func (c *LLMClient) withRetry(ctx context.Context, call func(model string) (ChatMessage, error), partial func() bool) (ChatMessage, error) {
+	base := c.retryBaseDelay
+	if base <= 0 {
+		base = llmRetryBaseDelay
+	}
+	models := append([]string{c.Model}, c.FallbackModels...)
+
+	var err error
+	for i, model := range models {
+		if i > 0 {
+			reportLLMRetry(ctx, "%s keeps failing (%s); falling back to %s", models[i-1], llmErrorSummary(err), model)
+		}
+		for attempt := 0; ; attempt++ {
+			var msg ChatMessage
+			msg, err = call(model)
+			if err == nil || ctx.Err() != nil || (partial != nil && partial()) {
+				return msg, err
+			}
+			if !retryableLLMError(err) {
+				if modelUnavailableLLMError(err, model) && i < len(models)-1 {
+					break
+				}
+				return msg, err
+			}
+			if attempt >= c.MaxRetries {
+				break
+			}
+			var retryAfter time.Duration
+			var httpErr *llmHTTPError
+			if errors.As(err, &httpErr) {
+				retryAfter = httpErr.retryAfter
+			}
+			if retryAfter > llmRetryAfterMax {
+				break
+			}
+			wait := llmBackoff(base, attempt, retryAfter)
+			reportLLMRetry(ctx, "%s failed (%s); retry %d/%d in %s",
+				model, llmErrorSummary(err), attempt+1, c.MaxRetries, wait.Round(100*time.Millisecond))
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ChatMessage{}, ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return ChatMessage{}, err
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"-1", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"soon", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Fatalf("parseRetryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLLMBackoff(t *testing.T) {
+	if got := llmBackoff(time.Second, 0, 5*time.Second); got != 5*time.Second {
+		t.Fatalf("expected Retry-After to win, got %v", got)
+	}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		for i := 0; i < 20; i++ {
+			got := llmBackoff(time.Second, attempt, 0)
+			if got < want/2 || got >= want {
+				t.Fatalf("attempt %d: backoff %v outside [%v, %v)", attempt, got, want/2, want)
+			}
+		}
+	}
+	if got := llmBackoff(time.Second, 40, 0); got > llmRetryMaxDelay {
+		t.Fatalf("backoff %v above cap", got)
+	}
+}
+
+func TestRetryableLLMError(t *testing.T) {
+	for status, want := range map[int]bool{429: true, 502: true, 503: true, 529: true, 400: false, 401: false, 404: false} {
+		if got := retryableLLMError(&llmHTTPError{status: status}); got != want {
+			t.Fatalf("status %d: retryable = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestModelUnavailableLLMError(t *testing.T) {
+	tests := []struct {
+		err  *llmHTTPError
+		want bool
+	}{
+		{&llmHTTPError{status: 404, body: "not found"}, true},
+		{&llmHTTPError{status: 400, body: `{"error":{"message":"gpt-x is not a valid model ID"}}`}, true},
+		{&llmHTTPError{status: 400, body: `{"error":{"message":"messages: field required"}}`}, false},
+		{&llmHTTPError{status: 401, body: "invalid key for GPT-X"}, false},
+		{&llmHTTPError{status: 403, body: "forbidden"}, false},
+	}
+	for _, tt := range tests {
+		if got := modelUnavailableLLMError(tt.err, "gpt-x"); got != tt.want {
+			t.Fatalf("status %d %q: model unavailable = %v, want %v", tt.err.status, tt.err.body, got, tt.want)
+		}
+	}
+}
+
+// retryServer answers with the given statuses in order (then 200), recording
+// the model of each request.
+func retryServer(t *testing.T, statuses map[string][]int) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mu sync.Mutex
+	var models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		models = append(models, req.Model)
+		queue := statuses[req.Model]
+		status := http.StatusOK
+		if len(queue) > 0 {
+			status, statuses[req.Model] = queue[0], queue[1:]
+		}
+		mu.Unlock()
+		if status != http.StatusOK {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"error":{"message":"busy"}}`, status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"answer from ` + req.Model + `"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &models
+}
+
+func TestChatRetriesThenFallsBack(t *testing.T) {
+	srv, models := retryServer(t, map[string][]int{
+		"primary": {429, 502, 503},
+	})
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "primary", MaxRetries: 2, FallbackModels: []string{"backup"}})
+	client.retryBaseDelay = time.Millisecond
+
+	var reports []string
+	ctx := withLLMRetryReporter(context.Background(), func(s string) { reports = append(reports, s) })
+	msg, err := client.Chat(ctx, []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if msg.Content != "answer from backup" {
+		t.Fatalf("unexpected answer %q", msg.Content)
+	}
+	if got := strings.Join(*models, ","); got != "primary,primary,primary,backup" {
+		t.Fatalf("unexpected request order %s", got)
+	}
+	if len(reports) != 3 || !strings.Contains(reports[0], "HTTP 429") || !strings.Contains(reports[2], "falling back to backup") {
+		t.Fatalf("unexpected retry reports %q", reports)
+	}
+}
+
+func TestChatDoesNotRetryClientErrors(t *testing.T) {
+	srv, models := retryServer(t, map[string][]int{"primary": {401}})
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "primary", MaxRetries: 3, FallbackModels: []string{"backup"}})
+	client.retryBaseDelay = time.Millisecond
+
+	_, err := client.Chat(context.Background(), []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Fatalf("expected the 401 to be returned, got %v", err)
+	}
+	if len(*models) != 1 {
+		t.Fatalf("expected a single request, got %v", *models)
+	}
+}
+
+func TestChatFallsBackOnUnknownModel(t *testing.T) {
+	srv, models := retryServer(t, map[string][]int{"primary": {404}})
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "primary", MaxRetries: 3, FallbackModels: []string{"backup"}})
+	client.retryBaseDelay = time.Millisecond
+
+	msg, err := client.Chat(context.Background(), []ChatMessage{{Role: "user", Content: "hi"}}, nil)
+	if err != nil || msg.Content != "answer from backup" {
+		t.Fatalf("expected the fallback to answer, got %q, %v", msg.Content, err)
+	}
+	if got := strings.Join(*models, ","); got != "primary,backup" {
+		t.Fatalf("a 404 must not be retried on the same model, requests %s", got)
+	}
+}
+
+func TestChatStreamRetriesBeforeOutput(t *testing.T) {
+	srv, models := retryServer(t, map[string][]int{"primary": {503}})
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "primary", Stream: true, MaxRetries: 1})
+	client.retryBaseDelay = time.Millisecond
+
+	var deltas []string
+	msg, err := client.ChatStream(context.Background(), []ChatMessage{{Role: "user", Content: "hi"}}, nil,
+		func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if msg.Content != "answer from primary" || strings.Join(deltas, "") != msg.Content || len(*models) != 2 {
+		t.Fatalf("unexpected result %q, deltas %v, requests %v", msg.Content, deltas, *models)
+	}
+}
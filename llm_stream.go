@@ -0,0 +1,272 @@
+// llm_stream.go - Server-Sent Events streaming for the chat completions client
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxSSELineBytes bounds a single SSE line (one JSON chunk).
+const maxSSELineBytes = 1024 * 1024 // 1MB
+
+// maxStreamToolCalls bounds the tool-call index a stream may use, so a bad
+// index cannot make the calls slice grow without limit.
+const maxStreamToolCalls = 128
+
+// chatStreamChunk is one `data:` payload of a streamed chat completion.
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string          `json:"content"`
+			ToolCalls []toolCallDelta `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// toolCallDelta is a fragment of a tool call. The first fragment for an index
+// carries the ID and function name; later ones append to the arguments.
+type toolCallDelta struct {
+	Index    *int   `json:"index"` // nil when the provider leaves it out
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// ChatStream is like Chat but requests an SSE stream and calls onDelta with each
+// content fragment as it arrives. The assembled message, including any tool calls,
+// is returned once the stream ends. Falls back to Chat when streaming is disabled
+// or the endpoint does not support it. Failures are retried like Chat's, unless
+// part of the reply was already delivered.
+func (c *LLMClient) ChatStream(ctx context.Context, messages []ChatMessage, tools []ToolDef, onDelta func(string)) (ChatMessage, error) {
+	if !c.Stream || c.streamUnsupported.Load() {
+		return c.Chat(ctx, messages, tools)
+	}
+	delivered := false
+	forward := func(s string) {
+		delivered = true
+		if onDelta != nil {
+			onDelta(s)
+		}
+	}
+	return c.withRetry(ctx, func(model string) (ChatMessage, error) {
+		return c.streamOnce(ctx, model, messages, tools, forward)
+	}, func() bool { return delivered })
+}
+
+// streamOnce is a single streaming request to model.
+func (c *LLMClient) streamOnce(ctx context.Context, model string, messages []ChatMessage, tools []ToolDef, onDelta func(string)) (ChatMessage, error) {
+	if c.Provider == providerAnthropic {
+		return c.anthropicStream(ctx, model, messages, tools, onDelta)
+	}
+
+	resp, err := c.post(ctx, chatRequest{
+		Model:         model,
+		Messages:      messages,
+		Tools:         tools,
+		Stream:        true,
+		StreamOptions: &streamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	defer resp.Body.Close()
+
+	body := io.LimitReader(resp.Body, maxLLMResponseBytes)
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(body)
+		if streamRejected(resp.StatusCode, string(respBody)) {
+			c.streamUnsupported.Store(true)
+			return c.chatOnce(ctx, model, messages, tools)
+		}
+		return ChatMessage{}, newLLMHTTPError(resp, respBody)
+	}
+
+	// Some servers ignore `stream` and answer with a regular JSON body
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		respBody, err := io.ReadAll(body)
+		if err != nil {
+			return ChatMessage{}, fmt.Errorf("read response: %w", err)
+		}
+		msg, usage, err := parseChatResponse(respBody)
+		if err != nil {
+			return ChatMessage{}, err
+		}
+		c.recordUsage(model, usage)
+		if msg.Content != "" {
+			onDelta(msg.Content)
+		}
+		return msg, nil
+	}
+
+	msg, usage, err := readChatStream(body, onDelta)
+	if err == nil {
+		c.recordUsage(model, usage)
+	}
+	return msg, err
+}
+
+// streamRejected reports whether an error response looks like the endpoint
+// refusing the `stream` parameter rather than failing for another reason.
+func streamRejected(status int, body string) bool {
+	switch status {
+	case http.StatusBadRequest, http.StatusNotImplemented, http.StatusUnprocessableEntity:
+		return strings.Contains(strings.ToLower(body), "stream")
+	}
+	return false
+}
+
+// readChatStream parses an SSE chat completions stream, calling onDelta for each
+// content fragment and assembling tool-call fragments by index. Usage comes from
+// the final chunk when the server honors stream_options.include_usage.
+func readChatStream(r io.Reader, onDelta func(string)) (ChatMessage, Usage, error) {
+	msg := ChatMessage{Role: "assistant"}
+	var content strings.Builder
+	var calls []ToolCall
+	var usage Usage
+	gotChunk := false
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSSELineBytes)
+
+	var data strings.Builder
+	flush := func() (bool, error) {
+		payload := strings.TrimSpace(data.String())
+		data.Reset()
+		if payload == "" {
+			return false, nil
+		}
+		if payload == "[DONE]" {
+			return true, nil
+		}
+
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			return false, fmt.Errorf("parse stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return false, fmt.Errorf("LLM error: %s", chunk.Error.Message)
+		}
+		gotChunk = true
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				if onDelta != nil {
+					onDelta(choice.Delta.Content)
+				}
+			}
+			for _, d := range choice.Delta.ToolCalls {
+				var err error
+				if calls, err = mergeToolCallDelta(calls, d); err != nil {
+					return false, err
+				}
+			}
+		}
+		return false, nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// Blank line terminates an event
+			done, err := flush()
+			if err != nil {
+				return ChatMessage{}, Usage{}, err
+			}
+			if done {
+				msg, err := finishStream(msg, content.String(), calls, gotChunk)
+				return msg, usage, err
+			}
+		case strings.HasPrefix(line, ":"):
+			// Comment / keep-alive
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return ChatMessage{}, Usage{}, fmt.Errorf("read stream: %w", err)
+	}
+
+	// Stream closed without a trailing blank line or [DONE]
+	if _, err := flush(); err != nil {
+		return ChatMessage{}, Usage{}, err
+	}
+	msg, err := finishStream(msg, content.String(), calls, gotChunk)
+	return msg, usage, err
+}
+
+// mergeToolCallDelta folds one tool-call fragment into the calls assembled so far.
+// An index below 0 or at maxStreamToolCalls and above is an error. Without an
+// index, a fragment with a new ID starts a call and any other continues the
+// latest one.
+func mergeToolCallDelta(calls []ToolCall, d toolCallDelta) ([]ToolCall, error) {
+	var idx int
+	switch {
+	case d.Index != nil:
+		idx = *d.Index
+		if idx < 0 || idx >= maxStreamToolCalls {
+			return calls, fmt.Errorf("stream tool call index %d out of range", idx)
+		}
+	case len(calls) > 0:
+		idx = len(calls) - 1
+	}
+	// Some providers send every call at the same index, each with its own ID
+	if idx < len(calls) && d.ID != "" && calls[idx].ID != "" && calls[idx].ID != d.ID {
+		idx = len(calls)
+		if idx >= maxStreamToolCalls {
+			return calls, fmt.Errorf("stream sent more than %d tool calls", maxStreamToolCalls)
+		}
+	}
+	for len(calls) <= idx {
+		calls = append(calls, ToolCall{Type: "function"})
+	}
+
+	tc := &calls[idx]
+	if d.ID != "" {
+		tc.ID = d.ID
+	}
+	if d.Type != "" {
+		tc.Type = d.Type
+	}
+	if d.Function.Name != "" && tc.Function.Name == "" {
+		tc.Function.Name = d.Function.Name
+	}
+	tc.Function.Arguments += d.Function.Arguments
+	return calls, nil
+}
+
+// finishStream assembles the streamed message. Calls that never got a function
+// name, such as the gaps left by skipped indexes, are dropped rather than
+// passed on to be rejected as an unknown tool.
+func finishStream(msg ChatMessage, content string, calls []ToolCall, gotChunk bool) (ChatMessage, error) {
+	if !gotChunk {
+		return ChatMessage{}, fmt.Errorf("LLM returned an empty stream")
+	}
+	msg.Content = content
+	for _, tc := range calls {
+		if tc.Function.Name != "" {
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+		}
+	}
+	return msg, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadChatStreamContent(t *testing.T) {
+	stream := strings.Join([]string{
+		`: keep-alive`,
+		`data: {"choices":[{"delta":{"role":"assistant","content":"Hel"}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"content":"lo"}}]}`,
+		``,
+		`data: {"choices":[{"delta":{},"finish_reason":"stop"}]}`,
+		``,
+		`data: [DONE]`,
+		``,
+	}, "\n")
+
+	var deltas []string
+	msg, _, err := readChatStream(strings.NewReader(stream), func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("readChatStream: %v", err)
+	}
+	if msg.Content != "Hello" || msg.Role != "assistant" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+	if strings.Join(deltas, "|") != "Hel|lo" {
+		t.Fatalf("unexpected deltas: %v", deltas)
+	}
+}
+
+func TestReadChatStreamToolCalls(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"start_instance","arguments":""}}]}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"name\":"}}]}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"list_instances","arguments":"{}"}}]}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"vm1\"}"}}]}}]}`,
+		``,
+		`data: [DONE]`,
+		``,
+	}, "\n")
+
+	msg, _, err := readChatStream(strings.NewReader(stream), nil)
+	if err != nil {
+		t.Fatalf("readChatStream: %v", err)
+	}
+	if len(msg.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %+v", msg.ToolCalls)
+	}
+	first := msg.ToolCalls[0]
+	if first.ID != "call_1" || first.Function.Name != "start_instance" || first.Function.Arguments != `{"name":"vm1"}` {
+		t.Fatalf("unexpected first call: %+v", first)
+	}
+	if msg.ToolCalls[1].Function.Name != "list_instances" {
+		t.Fatalf("unexpected second call: %+v", msg.ToolCalls[1])
+	}
+}
+
+func TestReadChatStreamToolCallsWithoutIndex(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"choices":[{"delta":{"tool_calls":[{"id":"call_1","type":"function","function":{"name":"start_instance","arguments":"{\"name\":"}}]}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"tool_calls":[{"function":{"arguments":"\"vm1\"}"}}]}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"tool_calls":[{"id":"call_2","type":"function","function":{"name":"stop_instance","arguments":"{\"name\":"}}]}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"tool_calls":[{"function":{"arguments":"\"vm2\"}"}}]}}]}`,
+		``,
+		`data: [DONE]`,
+		``,
+	}, "\n")
+
+	msg, _, err := readChatStream(strings.NewReader(stream), nil)
+	if err != nil {
+		t.Fatalf("readChatStream: %v", err)
+	}
+	if len(msg.ToolCalls) != 2 || msg.ToolCalls[0].Function.Arguments != `{"name":"vm1"}` || msg.ToolCalls[1].Function.Arguments != `{"name":"vm2"}` {
+		t.Fatalf("fragments merged into the wrong calls: %+v", msg.ToolCalls)
+	}
+}
+
+func TestReadChatStreamDropsNamelessCalls(t *testing.T) {
+	// Index 1 is skipped, and index 3 never gets a name
+	stream := strings.Join([]string{
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"a","function":{"name":"list_instances","arguments":"{}"}}]}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":2,"id":"b","function":{"name":"list_instances","arguments":"{}"}}]}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":3,"function":{"arguments":"{}"}}]}}]}`,
+		``,
+		`data: [DONE]`,
+		``,
+	}, "\n")
+
+	msg, _, err := readChatStream(strings.NewReader(stream), nil)
+	if err != nil {
+		t.Fatalf("readChatStream: %v", err)
+	}
+	if len(msg.ToolCalls) != 2 || msg.ToolCalls[0].ID != "a" || msg.ToolCalls[1].ID != "b" {
+		t.Fatalf("expected only the named calls, got %+v", msg.ToolCalls)
+	}
+}
+
+func TestReadChatStreamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+	}{
+		{"empty", "data: [DONE]\n\n"},
+		{"error chunk", `data: {"error":{"message":"rate limited"}}` + "\n\n"},
+		{"bad json", "data: {nope\n\n"},
+		{"negative tool call index", `data: {"choices":[{"delta":{"tool_calls":[{"index":-1,"function":{"name":"x"}}]}}]}` + "\n\n"},
+		{"huge tool call index", `data: {"choices":[{"delta":{"tool_calls":[{"index":1000000000,"function":{"name":"x"}}]}}]}` + "\n\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := readChatStream(strings.NewReader(tt.stream), nil); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
+
+func TestChatStreamFallback(t *testing.T) {
+	var streamRequests, plainRequests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Stream {
+			streamRequests++
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"stream is not supported"}}`)
+			return
+		}
+		plainRequests++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`)
+	}))
+	defer srv.Close()
+
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m", Stream: true})
+	for i := 0; i < 2; i++ {
+		msg, err := client.ChatStream(context.Background(), []ChatMessage{{Role: "user", Content: "hey"}}, nil, nil)
+		if err != nil {
+			t.Fatalf("ChatStream: %v", err)
+		}
+		if msg.Content != "hi" {
+			t.Fatalf("unexpected content %q", msg.Content)
+		}
+	}
+	if streamRequests != 1 || plainRequests != 2 {
+		t.Fatalf("expected one rejected stream then plain requests, got stream=%d plain=%d", streamRequests, plainRequests)
+	}
+}
+
+func TestChatStreamOutlivesHeaderTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, d := range []string{"a", "b", "c"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", d)
+			w.(http.Flusher).Flush()
+			time.Sleep(60 * time.Millisecond)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	// The stream takes longer than the header timeout; only the wait for headers is bounded
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m", Stream: true, MaxRetries: 0})
+	if client.HTTPClient.Timeout != 0 {
+		t.Fatalf("the whole request must not be time limited, got %s", client.HTTPClient.Timeout)
+	}
+	client.HTTPClient.Transport.(*http.Transport).ResponseHeaderTimeout = 50 * time.Millisecond
+	msg, err := client.ChatStream(context.Background(), nil, nil, nil)
+	if err != nil || msg.Content != "abc" {
+		t.Fatalf("stream cut off: %q, %v", msg.Content, err)
+	}
+}
+
+func TestChatStreamSSE(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"a\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"b\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m", Stream: true})
+	var got strings.Builder
+	msg, err := client.ChatStream(context.Background(), nil, nil, func(d string) { got.WriteString(d) })
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if msg.Content != "ab" || got.String() != "ab" {
+		t.Fatalf("content=%q deltas=%q", msg.Content, got.String())
+	}
+}
@@ -45,6 +45,8 @@ const (
 	viewMountAdd
 	viewMountModify
 	viewLLMSettings
+	viewChatSessions
+	viewAuditLog
 )
 
 // ─── Root Model ────────────────────────────────────────────────────────────────
@@ -55,20 +57,22 @@ type rootModel struct {
 	height      int
 
 	// Child models
-	table       tableModel
-	help        helpModel
-	version     versionModel
-	info        infoModel
-	loading     loadingModel
-	errModal    errorModel
-	confirm     confirmModel
-	advCreate   advCreateModel
-	snapCreate  snapCreateModel
-	snapManage  snapManageModel
-	mountManage mountManageModel
-	mountAdd    mountAddModel
-	mountModify mountModifyModel
-	llmSettings llmSettingsModel
+	table        tableModel
+	help         helpModel
+	version      versionModel
+	info         infoModel
+	loading      loadingModel
+	errModal     errorModel
+	confirm      confirmModel
+	advCreate    advCreateModel
+	snapCreate   snapCreateModel
+	snapManage   snapManageModel
+	mountManage  mountManageModel
+	mountAdd     mountAddModel
+	mountModify  mountModifyModel
+	llmSettings  llmSettingsModel
+	chatSessions chatSessionsModel
+	auditLog     auditLogModel
 
 	// Chat panel
 	chat             chatModel
@@ -124,6 +128,10 @@ func (m *rootModel) setChildSizes() {
 	m.mountModify.height = m.height
 	m.llmSettings.width = m.width
 	m.llmSettings.height = m.height
+	m.chatSessions.width = m.width
+	m.chatSessions.height = m.height
+	m.auditLog.width = m.width
+	m.auditLog.height = m.height
 
 	// Chat panel gets dynamic width when open
 	if m.chatOpen {
@@ -146,6 +154,16 @@ func initialModel() rootModel {
 	chat.config = cfg
 	chat.llmClient = NewLLMClient(cfg)
 
+	// Today's LLM usage so far, for the chat title and spend limit
+	chat.usageDay = usageDay(time.Now())
+	if today, err := loadDailyUsage(chat.usageDay); err != nil {
+		if appLogger != nil {
+			appLogger.Printf("failed to load LLM usage: %v", err)
+		}
+	} else {
+		chat.todayUsage = today
+	}
+
 	return rootModel{
 		currentView:      viewLoading,
 		table:            newTableModel(),
@@ -340,12 +358,19 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.currentView = viewError
 			}
 		} else {
+			var reconcileCmd tea.Cmd
+			if !m.table.lastRefresh.IsZero() { // not the initial load
+				reconcileCmd = autoReconcileMountsCmd(runningTransitions(m.table.vms, msg.vms))
+			}
 			m.table.setVMs(msg.vms)
 			m.table.lastRefresh = time.Now()
 			m.chat.currentVMs = msg.vms // keep chat VM state in sync
 			if !msg.background {
 				m.currentView = viewTable
 			}
+			if reconcileCmd != nil {
+				return m, tea.Batch(reconcileCmd, m.dequeuePendingVMListFetch())
+			}
 		}
 		return m, m.dequeuePendingVMListFetch()
 
@@ -392,6 +417,9 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Build toast message
 		toastMsg := operationToastMessage(msg.vmName, msg.operation, elapsed)
 		toastCmd := m.table.addToast(toastMsg, "success")
+		if msg.warning != "" {
+			toastCmd = tea.Batch(toastCmd, m.table.addToast(msg.warning, "info"))
+		}
 
 		// Inline operations: stay on table, refresh in background
 		if msg.inline {
@@ -402,7 +430,7 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Return to mount/snap manager if that's where we came from
-		if m.lastMountVM != "" && (msg.operation == "mount" || msg.operation == "umount") {
+		if m.lastMountVM != "" && (msg.operation == "mount" || msg.operation == "umount" || msg.operation == "remount") {
 			vmName := m.lastMountVM
 			m.loading = newLoadingModel("Refreshing mounts…")
 			m.setChildSizes()
@@ -445,9 +473,71 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.mountManage = newMountManageModel(msg.vmName, m.width, m.height)
 			m.mountManage.mounts = msg.mounts
 			m.currentView = viewMountManage
+			if len(msg.mounts) > 0 {
+				return m, checkMountHealthCmd(msg.vmName, msg.mounts)
+			}
 		}
 		return m, nil
 
+	case mountHealthResultMsg:
+		// Drop results for a manager that has since been closed or reopened for another VM
+		if m.currentView == viewMountManage && m.mountManage.vmName == msg.vmName {
+			m.mountManage.health = msg.health
+		}
+		return m, nil
+
+	case mountReconcileResultMsg:
+		if msg.auto {
+			// Background reconcile after a VM reached Running: toast only when something happened
+			if msg.err != nil {
+				return m, m.table.addToast(fmt.Sprintf("✗ mount reconcile failed for %s: %s", msg.vmName, msg.err.Error()), "error")
+			}
+			if len(msg.mounted) > 0 {
+				return m, m.table.addToast(mountReconcileToastMessage(msg.vmName, msg.mounted), "success")
+			}
+			return m, nil
+		}
+		var toastCmd tea.Cmd
+		if msg.err != nil {
+			toastCmd = m.table.addToast(fmt.Sprintf("✗ mount reconcile failed: %s", msg.err.Error()), "error")
+			m.errModal = newErrorModel("Mount Reconcile Error", msg.err.Error())
+			m.setChildSizes()
+			m.currentView = viewError
+			return m, toastCmd
+		}
+		toastCmd = m.table.addToast(mountReconcileToastMessage(msg.vmName, msg.mounted), "success")
+		if m.lastMountVM != "" {
+			m.loading = newLoadingModel("Refreshing mounts…")
+			m.setChildSizes()
+			m.currentView = viewLoading
+			return m, tea.Batch(m.loading.Init(), fetchMountsCmd(m.lastMountVM), toastCmd)
+		}
+		return m, toastCmd
+
+	case bulkMountResultMsg:
+		failed := bulkMountFailed(msg.results)
+		style := "success"
+		if failed {
+			style = "error"
+		}
+		toastCmds := []tea.Cmd{m.table.addToast(bulkMountToastMessage(msg.results), style)}
+		if msg.warning != "" {
+			toastCmds = append(toastCmds, m.table.addToast(msg.warning, "info"))
+		}
+		if failed {
+			m.errModal = newErrorModel("Bulk Mount", bulkMountReport(msg.mount, msg.results))
+			m.setChildSizes()
+			m.currentView = viewError
+			return m, tea.Batch(toastCmds...)
+		}
+		if m.lastMountVM != "" {
+			m.loading = newLoadingModel("Refreshing mounts…")
+			m.setChildSizes()
+			m.currentView = viewLoading
+			toastCmds = append(toastCmds, m.loading.Init(), fetchMountsCmd(m.lastMountVM))
+		}
+		return m, tea.Batch(toastCmds...)
+
 	case shellFinishedMsg:
 		m.loading = newLoadingModel("Refreshing…")
 		m.setChildSizes()
@@ -497,6 +587,7 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case mountAddRequestMsg:
 		m.mountAdd = newMountAddModel(msg.vmName, m.width, m.height)
+		m.mountAdd.targetGroups = buildMountTargetGroups(msg.vmName, m.table)
 		m.currentView = viewMountAdd
 		return m, nil
 
@@ -509,7 +600,12 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Update chat model with new config
 		m.chat.config = msg.config
 		m.chat.llmClient = NewLLMClient(msg.config)
-		// Reset MCP state so it re-initializes with new config
+		// Reset tool state so it re-initializes with new config
+		if m.chat.router != nil {
+			m.chat.router.Close()
+			m.chat.router = nil
+		}
+		m.chat.toolExec = nil
 		m.chat.mcpReady = false
 		m.chat.mcpInitFailed = false
 		m.chat.entries = append(m.chat.entries, chatEntry{
@@ -520,19 +616,99 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.currentView = viewTable
 		return m, nil
 
+	case auditLogLoadedMsg:
+		if msg.err != nil {
+			m.errModal = newErrorModel("Audit Log Error", msg.err.Error())
+			m.setChildSizes()
+			m.currentView = viewError
+			return m, nil
+		}
+		if m.currentView == viewAuditLog {
+			m.auditLog.setEntries(msg.entries)
+			return m, nil
+		}
+		m.auditLog = newAuditLogModel(msg.entries, m.width, m.height)
+		m.currentView = viewAuditLog
+		return m, nil
+
+	case chatSessionsLoadedMsg:
+		if msg.err != nil {
+			m.errModal = newErrorModel("Chat Sessions Error", msg.err.Error())
+			m.setChildSizes()
+			m.currentView = viewError
+			return m, nil
+		}
+		// Keep the open chat from saving the old title or recreating a deleted session
+		if msg.renamedID != "" {
+			m.chat.sessionRenamed(msg.renamedID, msg.title)
+		}
+		if msg.deletedID != "" {
+			m.chat.sessionDeleted(msg.deletedID)
+		}
+		if m.currentView == viewChatSessions {
+			m.chatSessions.setSessions(msg.sessions)
+			return m, nil
+		}
+		m.chatSessions = newChatSessionsModel(msg.sessions, m.width, m.height)
+		m.currentView = viewChatSessions
+		return m, nil
+
+	case chatSessionLoadedMsg:
+		if msg.err != nil {
+			m.errModal = newErrorModel("Chat Sessions Error", msg.err.Error())
+			m.setChildSizes()
+			m.currentView = viewError
+			return m, nil
+		}
+		if m.chat.thinking {
+			return m, m.table.addToast("✗ Wait for the current reply before switching chats", "error")
+		}
+		m.chat.resumeSession(msg.session)
+		m.openChat()
+		return m, nil
+
+	case chatNewSessionMsg:
+		if m.chat.thinking {
+			return m, m.table.addToast("✗ Wait for the current reply before switching chats", "error")
+		}
+		m.chat.newSession()
+		m.openChat()
+		return m, nil
+
+	case chatSelectVMMsg:
+		if m.table.selectVM(msg.name) {
+			m.chatFocus = false
+			m.chat.Blur()
+		}
+		return m, nil
+
+	case chatSessionExportedMsg:
+		if msg.err != nil {
+			return m, m.table.addToast(fmt.Sprintf("✗ export failed: %s", msg.err.Error()), "error")
+		}
+		return m, m.table.addToast("✓ Exported to "+msg.path, "success")
+
 	case mountModifySubmitMsg:
 		m.loading = newLoadingModel("Updating mount…")
 		m.setChildSizes()
 		m.currentView = viewLoading
 		return m, tea.Batch(m.loading.Init(), func() tea.Msg {
-			err := runMountModifyOperation(runMultipassCommand, msg.vmName, msg.oldTarget, msg.newSource, msg.newTarget)
+			err := runMountModifyOperation(runMultipassCommand, msg.vmName, msg.oldTarget, msg.newMount)
+			if err == nil {
+				logMountProfileError(forgetMount(msg.vmName, msg.oldTarget))
+				logMountProfileError(rememberMount(msg.vmName, msg.newMount))
+			}
 			return vmOperationResultMsg{vmName: msg.vmName, operation: "mount", err: err}
 		})
 	}
 
 	// ── Chat messages (always route to chat model) ──
+	if _, ok := msg.(chatApprovalRequestMsg); ok && m.currentView == viewTable {
+		// The agent is blocked on this answer, so bring the chat forward with focus
+		m.openChat()
+	}
 	switch msg.(type) {
-	case chatToolStartMsg, chatToolDoneMsg, chatAgentResultMsg, chatMCPReadyMsg, chatMCPInitDoneMsg, chatMCPDownloadProgressMsg:
+	case chatApprovalRequestMsg, chatStreamDeltaMsg, chatUsageRecordedMsg, chatToolStartMsg, chatToolDoneMsg, chatAgentResultMsg, chatMCPReadyMsg, chatMCPInitDoneMsg, chatMCPDownMsg, chatMCPDownloadProgressMsg, chatMentionsResolvedMsg, chatLLMRetryMsg, chatCopiedMsg, chatToolPlannedMsg, chatPlanDoneMsg:
 		var cmd tea.Cmd
 		m.chat, cmd = m.chat.Update(msg)
 		return m, cmd
@@ -594,6 +770,14 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.llmSettings, cmd = m.llmSettings.Update(msg)
 		return m, cmd
+	case viewChatSessions:
+		var cmd tea.Cmd
+		m.chatSessions, cmd = m.chatSessions.Update(msg)
+		return m, cmd
+	case viewAuditLog:
+		var cmd tea.Cmd
+		m.auditLog, cmd = m.auditLog.Update(msg)
+		return m, cmd
 	}
 
 	return m, nil
@@ -625,7 +809,9 @@ func (m rootModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	// Tab switches focus between table and chat when chat is open
-	if msg.String() == "tab" && m.chatOpen && m.currentView == viewTable && !m.table.filterFocused {
+	// (unless the chat input is a slash command, where Tab completes it)
+	if msg.String() == "tab" && m.chatOpen && m.currentView == viewTable && !m.table.filterFocused &&
+		!(m.chatFocus && m.chat.wantsTab()) {
 		m.chatFocus = !m.chatFocus
 		if m.chatFocus {
 			m.chat.Focus()
@@ -661,8 +847,8 @@ func (m rootModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "q", "ctrl+c":
 			// Cleanup MCP on quit
-			if m.chat.mcpClient != nil {
-				m.chat.mcpClient.Close()
+			if m.chat.router != nil {
+				m.chat.router.Close()
 			}
 			return m, tea.Quit
 		case "esc":
@@ -816,6 +1002,10 @@ func (m rootModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				m.currentView = viewLoading
 				return m, tea.Batch(m.loading.Init(), fetchSnapshotsCmd(vm.Name))
 			}
+		case "S":
+			return m, listChatSessionsCmd()
+		case "A":
+			return m, loadAuditLogCmd()
 		case "L":
 			m.llmSettings = newLLMSettingsModel(m.chat.config, m.width, m.height)
 			m.currentView = viewLLMSettings
@@ -895,6 +1085,14 @@ func (m rootModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		m.llmSettings, cmd = m.llmSettings.Update(msg)
 		return m, cmd
+	case viewChatSessions:
+		var cmd tea.Cmd
+		m.chatSessions, cmd = m.chatSessions.Update(msg)
+		return m, cmd
+	case viewAuditLog:
+		var cmd tea.Cmd
+		m.auditLog, cmd = m.auditLog.Update(msg)
+		return m, cmd
 	}
 
 	return m, nil
@@ -984,11 +1182,27 @@ func (m rootModel) View() string {
 		return m.mountModify.View()
 	case viewLLMSettings:
 		return m.llmSettings.View()
+	case viewChatSessions:
+		return m.chatSessions.View()
+	case viewAuditLog:
+		return m.auditLog.View()
 	default:
 		return "Unknown view"
 	}
 }
 
+// openChat shows the chat panel with focus and returns to the table view.
+func (m *rootModel) openChat() {
+	if !m.chatOpen {
+		m.chatOpen = true
+		m.chat.currentVMs = m.table.vms
+		m.chat.setSize(m.width*m.chatWidthPercent/100, m.height)
+	}
+	m.chatFocus = true
+	m.chat.Focus()
+	m.currentView = viewTable
+}
+
 // ─── Toast Helpers ──────────────────────────────────────────────────────────────
 
 func operationToastMessage(vmName, operation string, elapsed time.Duration) string {
@@ -1021,6 +1235,8 @@ func operationToastMessage(vmName, operation string, elapsed time.Duration) stri
 		return fmt.Sprintf("✓ Mount added to %s%s", vmName, timeStr)
 	case "umount":
 		return fmt.Sprintf("✓ Mount removed from %s%s", vmName, timeStr)
+	case "remount":
+		return fmt.Sprintf("✓ Mount remounted on %s%s", vmName, timeStr)
 	case "stop-all":
 		return fmt.Sprintf("✓ All VMs stopped%s", timeStr)
 	case "start-all":
@@ -1032,6 +1248,52 @@ func operationToastMessage(vmName, operation string, elapsed time.Duration) stri
 	}
 }
 
+func mountReconcileToastMessage(vmName string, mounted []string) string {
+	switch len(mounted) {
+	case 0:
+		return fmt.Sprintf("✓ Mounts for %s already up to date", vmName)
+	case 1:
+		return fmt.Sprintf("✓ Remounted %s on %s", mounted[0], vmName)
+	default:
+		return fmt.Sprintf("✓ Remounted %d paths on %s", len(mounted), vmName)
+	}
+}
+
+func bulkMountFailed(results []bulkMountResult) bool {
+	for _, r := range results {
+		if r.err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func bulkMountToastMessage(results []bulkMountResult) string {
+	ok := 0
+	for _, r := range results {
+		if r.err == nil {
+			ok++
+		}
+	}
+	if ok == len(results) {
+		return fmt.Sprintf("✓ Mounted into %d VMs", ok)
+	}
+	return fmt.Sprintf("✗ Mounted into %d of %d VMs", ok, len(results))
+}
+
+// bulkMountReport lists the per-VM outcome of a bulk mount for the error modal.
+func bulkMountReport(mount MountProfile, results []bulkMountResult) string {
+	lines := []string{fmt.Sprintf("%s → %s", mount.Source, mount.Target), ""}
+	for _, r := range results {
+		if r.err != nil {
+			lines = append(lines, fmt.Sprintf("✗ %s: %s", r.vmName, r.err.Error()))
+		} else {
+			lines = append(lines, fmt.Sprintf("✓ %s", r.vmName))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // ─── Sort (moved from old main.go) ────────────────────────────────────────────
 
 func sortVMs(vms []vmData, column int, ascending bool) {
@@ -1141,15 +1403,14 @@ func compareFloat64(a, b float64) int {
 	}
 }
 
-func runMountModifyOperation(runCmd func(args ...string) (string, error), vmName, oldTarget, newSource, newTarget string) error {
+func runMountModifyOperation(runCmd func(args ...string) (string, error), vmName, oldTarget string, newMount MountProfile) error {
 	oldMount := vmName + ":" + oldTarget
 	if _, err := runCmd("umount", oldMount); err != nil {
 		return fmt.Errorf("failed to unmount %s: %w", oldMount, err)
 	}
 
-	newMount := vmName + ":" + newTarget
-	if _, err := runCmd("mount", newSource, newMount); err != nil {
-		return fmt.Errorf("failed to mount %s to %s: %w", newSource, newMount, err)
+	if _, err := runCmd(buildMountArgs(vmName, newMount)...); err != nil {
+		return fmt.Errorf("failed to mount %s to %s:%s: %w", newMount.Source, vmName, newMount.Target, err)
 	}
 
 	return nil
@@ -1199,7 +1460,7 @@ func main() {
 	}
 
 	// Cleanup MCP subprocess
-	if model.chat.mcpClient != nil {
-		model.chat.mcpClient.Close()
+	if model.chat.router != nil {
+		model.chat.router.Close()
 	}
 }
@@ -126,7 +126,7 @@ func TestRunMountModifyOperation(t *testing.T) {
 			return "", nil
 		}
 
-		err := runMountModifyOperation(runCmd, "vm1", "/old", "/new-src", "/new")
+		err := runMountModifyOperation(runCmd, "vm1", "/old", MountProfile{Source: "/new-src", Target: "/new"})
 		if err == nil || !strings.Contains(err.Error(), "failed to unmount") {
 			t.Fatalf("expected unmount failure, got: %v", err)
 		}
@@ -145,7 +145,7 @@ func TestRunMountModifyOperation(t *testing.T) {
 			return "", nil
 		}
 
-		err := runMountModifyOperation(runCmd, "vm1", "/old", "/new-src", "/new")
+		err := runMountModifyOperation(runCmd, "vm1", "/old", MountProfile{Source: "/new-src", Target: "/new"})
 		if err == nil || !strings.Contains(err.Error(), "failed to mount") {
 			t.Fatalf("expected mount failure, got: %v", err)
 		}
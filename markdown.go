@@ -0,0 +1,347 @@
+// markdown.go - Markdown rendering of assistant replies in the chat panel, using the active theme
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mdCodeBlock is a fenced code block found in a reply.
+type mdCodeBlock struct {
+	lang string
+	code string
+}
+
+// parseFence reports whether line opens or closes a fenced code block and
+// returns the fence marker and the info string (language).
+func parseFence(line string) (string, string, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, marker) {
+			return marker, strings.TrimSpace(strings.TrimLeft(trimmed, marker[:1])), true
+		}
+	}
+	return "", "", false
+}
+
+// markdownCodeBlocks returns the fenced code blocks of src in order. An
+// unterminated block (e.g. mid-stream) runs to the end.
+func markdownCodeBlocks(src string) []mdCodeBlock {
+	var blocks []mdCodeBlock
+	var cur *mdCodeBlock
+	var lines []string
+	var marker string
+	for _, line := range strings.Split(src, "\n") {
+		if m, info, ok := parseFence(line); ok {
+			if cur == nil {
+				cur, marker, lines = &mdCodeBlock{lang: info}, m, nil
+				continue
+			}
+			if m == marker && info == "" {
+				cur.code = strings.Join(lines, "\n")
+				blocks = append(blocks, *cur)
+				cur = nil
+				continue
+			}
+		}
+		if cur != nil {
+			lines = append(lines, line)
+		}
+	}
+	if cur != nil {
+		cur.code = strings.Join(lines, "\n")
+		blocks = append(blocks, *cur)
+	}
+	return blocks
+}
+
+var (
+	mdHeadingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	mdBulletRe   = regexp.MustCompile(`^(\s*)([-*+])\s+(.*)$`)
+	mdOrderedRe  = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	mdRuleRe     = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	mdTableSepRe = regexp.MustCompile(`^\s*\|?\s*:?-{2,}:?\s*(\|\s*:?-{2,}:?\s*)*\|?\s*$`)
+	mdBoldRe     = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalicRe   = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*`)
+)
+
+// markdownRenderer renders Markdown to styled terminal lines of a fixed width.
+// Code blocks are numbered from firstBlock so /copy can refer to them.
+type markdownRenderer struct {
+	width      int
+	t          theme
+	firstBlock int
+}
+
+// renderMarkdown renders src for the chat panel. It returns the text and the
+// number of code blocks it contained.
+func renderMarkdown(src string, width int, t theme, firstBlock int) (string, int) {
+	r := markdownRenderer{width: max(10, width), t: t, firstBlock: firstBlock}
+	return r.render(src)
+}
+
+func (r markdownRenderer) render(src string) (string, int) {
+	lines := strings.Split(strings.TrimRight(src, "\n"), "\n")
+	var out []string
+	blocks := 0
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// Fenced code runs until the matching fence or the end of the reply
+		if marker, lang, ok := parseFence(line); ok {
+			var code []string
+			for i++; i < len(lines); i++ {
+				if m, info, ok := parseFence(lines[i]); ok && m == marker && info == "" {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			out = append(out, r.codeBlock(lang, code, r.firstBlock+blocks)...)
+			blocks++
+			continue
+		}
+
+		// A table is a header row followed by a separator row
+		if strings.Contains(line, "|") && i+1 < len(lines) && mdTableSepRe.MatchString(lines[i+1]) {
+			rows := [][]string{splitTableRow(line)}
+			i += 2
+			for ; i < len(lines) && strings.Contains(lines[i], "|") && strings.TrimSpace(lines[i]) != ""; i++ {
+				rows = append(rows, splitTableRow(lines[i]))
+			}
+			i--
+			out = append(out, r.table(rows)...)
+			continue
+		}
+
+		out = append(out, r.block(line)...)
+	}
+	return strings.Join(out, "\n"), blocks
+}
+
+// block renders one non-code, non-table line.
+func (r markdownRenderer) block(line string) []string {
+	t := r.t
+	text := lipgloss.NewStyle().Foreground(t.Text)
+	wrap := lipgloss.NewStyle().Width(r.width)
+
+	if strings.TrimSpace(line) == "" {
+		return []string{""}
+	}
+	if mdRuleRe.MatchString(line) {
+		return []string{lipgloss.NewStyle().Foreground(t.Dimmed).Render(strings.Repeat("─", r.width))}
+	}
+	if m := mdHeadingRe.FindStringSubmatch(line); m != nil {
+		style := lipgloss.NewStyle().Foreground(t.Accent).Bold(true)
+		if len(m[1]) == 1 {
+			style = style.Underline(true)
+		} else if len(m[1]) > 2 {
+			style = style.Foreground(t.AccentLight)
+		}
+		return []string{wrap.Render(r.inline(m[2], style))}
+	}
+	if quote, ok := strings.CutPrefix(strings.TrimLeft(line, " "), ">"); ok {
+		bar := lipgloss.NewStyle().Foreground(t.Dimmed).Render("▌ ")
+		body := lipgloss.NewStyle().Width(r.width - 2).Render(
+			r.inline(strings.TrimSpace(quote), lipgloss.NewStyle().Foreground(t.TextMuted).Italic(true)))
+		return []string{lipgloss.JoinHorizontal(lipgloss.Top, bar, body)}
+	}
+	if m := mdBulletRe.FindStringSubmatch(line); m != nil {
+		return []string{r.listItem(len(m[1]), "•", m[3])}
+	}
+	if m := mdOrderedRe.FindStringSubmatch(line); m != nil {
+		return []string{r.listItem(len(m[1]), m[2], m[3])}
+	}
+	return []string{wrap.Render(r.inline(line, text))}
+}
+
+// listItem renders a list entry with a hanging indent, nested by leading spaces.
+func (r markdownRenderer) listItem(indent int, marker, body string) string {
+	depth := min(indent/2, 4)
+	pad := strings.Repeat("  ", depth)
+	markerText := lipgloss.NewStyle().Foreground(r.t.Accent).Render(marker) + " "
+	prefixW := lipgloss.Width(pad + markerText)
+	text := lipgloss.NewStyle().Width(max(5, r.width-prefixW)).Render(r.inline(body, lipgloss.NewStyle().Foreground(r.t.Text)))
+	return lipgloss.JoinHorizontal(lipgloss.Top, pad+markerText, text)
+}
+
+// codeBlock renders fenced code in a frame, hard-wrapping long lines so output
+// keeps its columns. n is the block's number for /copy.
+func (r markdownRenderer) codeBlock(lang string, code []string, n int) []string {
+	t := r.t
+	frame := lipgloss.NewStyle().Foreground(t.Dimmed)
+	codeStyle := lipgloss.NewStyle().Foreground(t.Highlight)
+
+	label := fmt.Sprintf(" [%d] /copy %d ", n, n)
+	if lang != "" {
+		label = " " + lang + " ·" + label
+	}
+	header := frame.Render("╭─") + lipgloss.NewStyle().Foreground(t.AccentLight).Render(label)
+	if rest := r.width - lipgloss.Width(header); rest > 0 {
+		header += frame.Render(strings.Repeat("─", rest))
+	}
+
+	out := []string{header}
+	bodyW := max(4, r.width-2)
+	for _, line := range code {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		for _, part := range hardWrap(line, bodyW) {
+			out = append(out, frame.Render("│ ")+codeStyle.Render(part))
+		}
+	}
+	out = append(out, frame.Render("╰"+strings.Repeat("─", max(0, r.width-1))))
+	return out
+}
+
+// hardWrap splits s into pieces of at most width cells.
+func hardWrap(s string, width int) []string {
+	if lipgloss.Width(s) <= width {
+		return []string{s}
+	}
+	var parts []string
+	var cur strings.Builder
+	curW := 0
+	for _, rn := range s {
+		w := lipgloss.Width(string(rn))
+		if curW+w > width {
+			parts = append(parts, cur.String())
+			cur.Reset()
+			curW = 0
+		}
+		cur.WriteRune(rn)
+		curW += w
+	}
+	return append(parts, cur.String())
+}
+
+// splitTableRow splits "| a | b |" into trimmed cells.
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// table renders rows (the first is the header) with box-drawing borders,
+// shrinking the widest columns until the table fits.
+func (r markdownRenderer) table(rows [][]string) []string {
+	t := r.t
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	widths := make([]int, cols)
+	for _, row := range rows {
+		for c, cell := range row {
+			widths[c] = max(widths[c], lipgloss.Width(r.inline(cell, lipgloss.NewStyle())))
+		}
+	}
+	// Borders take 3 cells per column plus one
+	avail := r.width - (3*cols + 1)
+	for total(widths) > avail {
+		widest := 0
+		for c := range widths {
+			if widths[c] > widths[widest] {
+				widest = c
+			}
+		}
+		if widths[widest] <= 3 {
+			break
+		}
+		widths[widest]--
+	}
+
+	frame := lipgloss.NewStyle().Foreground(t.Dimmed)
+	rule := func(left, mid, right string) string {
+		parts := make([]string, cols)
+		for c, w := range widths {
+			parts[c] = strings.Repeat("─", w+2)
+		}
+		return frame.Render(left + strings.Join(parts, mid) + right)
+	}
+
+	out := []string{rule("┌", "┬", "┐")}
+	for i, row := range rows {
+		style := lipgloss.NewStyle().Foreground(t.Text)
+		if i == 0 {
+			style = lipgloss.NewStyle().Foreground(t.Accent).Bold(true)
+		}
+		line := frame.Render("│")
+		for c, w := range widths {
+			cell := ""
+			if c < len(row) {
+				cell = r.inline(row[c], style)
+			}
+			cell = lipgloss.NewStyle().MaxWidth(w).Render(cell)
+			if pad := w - lipgloss.Width(cell); pad > 0 {
+				cell += strings.Repeat(" ", pad)
+			}
+			line += " " + cell + " " + frame.Render("│")
+		}
+		out = append(out, line)
+		if i == 0 {
+			out = append(out, rule("├", "┼", "┤"))
+		}
+	}
+	return append(out, rule("└", "┴", "┘"))
+}
+
+func total(ns []int) int {
+	sum := 0
+	for _, n := range ns {
+		sum += n
+	}
+	return sum
+}
+
+// inline styles `code`, **bold** and *italic* spans on top of base. Each
+// span is rendered on its own so an inner reset never drops the base style.
+func (r markdownRenderer) inline(s string, base lipgloss.Style) string {
+	parts := strings.Split(s, "`")
+	if len(parts)%2 == 0 {
+		// Unbalanced backtick: leave the last one literal
+		parts[len(parts)-2] += "`" + parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	var b strings.Builder
+	for i, p := range parts {
+		if i%2 == 1 {
+			b.WriteString(base.Foreground(r.t.AccentLight).Render(p))
+			continue
+		}
+		r.emphasis(&b, p, base)
+	}
+	return b.String()
+}
+
+// emphasis writes s with **bold** and *italic* spans styled.
+func (r markdownRenderer) emphasis(b *strings.Builder, s string, base lipgloss.Style) {
+	italics := func(s string) {
+		last := 0
+		for _, m := range mdItalicRe.FindAllStringSubmatchIndex(s, -1) {
+			// m[2:4] is the character before the opening *, m[4:6] the text
+			b.WriteString(base.Render(s[last:m[3]]))
+			b.WriteString(base.Italic(true).Render(s[m[4]:m[5]]))
+			last = m[1]
+		}
+		b.WriteString(base.Render(s[last:]))
+	}
+	last := 0
+	for _, m := range mdBoldRe.FindAllStringSubmatchIndex(s, -1) {
+		italics(s[last:m[0]])
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[4], m[5]
+		}
+		b.WriteString(base.Foreground(r.t.Highlight).Bold(true).Render(s[start:end]))
+		last = m[1]
+	}
+	italics(s[last:])
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestMarkdownCodeBlocks(t *testing.T) {
+	src := "Intro\n```yaml\nusers:\n  - name: dev\n```\ntext\n~~~\nls -l\n~~~\n```sh\necho unterminated"
+	blocks := markdownCodeBlocks(src)
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %+v", blocks)
+	}
+	if blocks[0].lang != "yaml" || blocks[0].code != "users:\n  - name: dev" {
+		t.Fatalf("unexpected first block %+v", blocks[0])
+	}
+	if blocks[1].code != "ls -l" || blocks[2].code != "echo unterminated" {
+		t.Fatalf("unexpected blocks %+v", blocks[1:])
+	}
+}
+
+func TestRenderMarkdownFitsWidth(t *testing.T) {
+	src := strings.Join([]string{
+		"# Status",
+		"Some **bold** and `code` and *italic* text that is long enough to need wrapping in a narrow pane.",
+		"- first item with a fairly long description that wraps",
+		"  - nested",
+		"1. ordered",
+		"> quoted",
+		"---",
+		"| VM | State | IP |",
+		"|----|-------|----|",
+		"| dev | Running | 10.0.0.12 |",
+		"| a-very-long-instance-name | Stopped | -- |",
+		"```",
+		"a-very-long-line-of-command-output-that-must-be-hard-wrapped-to-fit",
+		"```",
+	}, "\n")
+	const width = 30
+	out, blocks := renderMarkdown(src, width, themes[0], 4)
+	if blocks != 1 {
+		t.Fatalf("expected one code block, got %d", blocks)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if w := lipgloss.Width(line); w > width {
+			t.Fatalf("line wider than %d (%d): %q", width, w, line)
+		}
+	}
+	for _, want := range []string{"Status", "• first item", "• nested", "1. ordered", "▌ quoted", "┌", "│ dev", "[4] /copy 4"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("rendered output missing %q:\n%s", want, out)
+		}
+	}
+	for _, marker := range []string{"**", "`code`", "# Status", "|----|"} {
+		if strings.Contains(out, marker) {
+			t.Fatalf("markup %q left in output:\n%s", marker, out)
+		}
+	}
+}
+
+func TestChatCopyCommandPicksBlock(t *testing.T) {
+	m := newChatModel()
+	m.focused = true
+	m.entries = append(m.entries,
+		chatEntry{role: "assistant", content: "```\none\n```"},
+		chatEntry{role: "user", content: "```\nnot mine\n```"},
+		chatEntry{role: "assistant", content: "```\ntwo\n```\n```\nthree\n```"},
+	)
+	if blocks := m.codeBlocks(); len(blocks) != 3 || blocks[2].code != "three" {
+		t.Fatalf("unexpected blocks %+v", blocks)
+	}
+
+	m, cmd := sendChatCommand(m, "/copy 9")
+	if cmd != nil || m.entries[len(m.entries)-1].role != "error" {
+		t.Fatalf("expected an out-of-range error")
+	}
+	if _, cmd = sendChatCommand(m, "/copy 2"); cmd == nil {
+		t.Fatalf("expected a copy command")
+	}
+}
@@ -1,4 +1,4 @@
-// mcp_client.go - MCP client: spawn multipass-mcp subprocess, JSON-RPC over stdio
+// mcp_client.go - MCP client: JSON-RPC to multipass-mcp or another server over stdio (or HTTP, see mcp_http.go)
 package main
 
 import (
@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"sync"
 	"sync/atomic"
@@ -16,20 +15,94 @@ import (
 
 // MCP timeouts
 const (
-	mcpCallTimeout = 60 * time.Second  // timeout for individual tool calls
-	mcpInitTimeout = 15 * time.Second  // timeout for initialization handshake
-	mcpReadLimit   = 10 * 1024 * 1024  // 10MB max response line size
+	mcpCallTimeout = 60 * time.Second // timeout for individual tool calls
+	mcpInitTimeout = 15 * time.Second // timeout for initialization handshake
+	mcpReadLimit   = 10 * 1024 * 1024 // 10MB max response line size
 )
 
-// MCPClient manages a multipass-mcp subprocess communicating via JSON-RPC over stdio.
+// MCP protocol versions offered in the handshake. Streamable HTTP arrived in 2025-03-26.
+const (
+	mcpStdioProtocolVersion = "2024-11-05"
+	mcpHTTPProtocolVersion  = "2025-03-26"
+)
+
+// MCPClient talks JSON-RPC to an MCP server, usually a multipass-mcp
+// subprocess over stdio. Everything the server sends is routed by ID to the
+// waiting calls, so calls may overlap.
 type MCPClient struct {
-	cmd    *exec.Cmd
-	stdin  io.WriteCloser
-	reader *bufio.Reader
-	mu     sync.Mutex
-	nextID atomic.Int64
-	tools  []ToolDef
-	closed atomic.Bool
+	command *mcpCommand   // how to restart the server; nil unless it was spawned
+	stop    chan struct{} // closed by Close to end the supervisor
+	nextID  atomic.Int64
+	closed  atomic.Bool
+
+	// mu guards the fields below, which the reader goroutine shares with callers
+	mu          sync.Mutex
+	transport   mcpTransport             // replaced when the supervisor restarts the server
+	restartDone chan struct{}            // non-nil while restarting; tool calls wait for it
+	pending     map[int64]chan rpcResult // in-flight calls by request ID
+	readErr     error                    // why the reader stopped; fails new calls
+	tools       []ToolDef                // cached tools/list result
+	downErr     error                    // set when the supervisor gave up restarting the server
+	// onNotification receives server notifications on the reader goroutine, so it must not block
+	onNotification func(method string, params json.RawMessage)
+	// onDown is told when the supervisor gives up on the server; it must not block
+	onDown func(err error)
+}
+
+// mcpTransport carries JSON-RPC messages to a server. Messages coming back are
+// passed to the client's dispatch.
+type mcpTransport interface {
+	// send transmits one encoded message. id is the request ID, or 0 for
+	// notifications and replies.
+	send(data []byte, id int64) error
+	close() error
+}
+
+// stdioTransport writes messages as lines on a subprocess's stdin; readLoop
+// reads its stdout.
+type stdioTransport struct {
+	cmd   *exec.Cmd // nil when the client was not started from a binary
+	stdin io.WriteCloser
+	mu    sync.Mutex    // serializes messages written to stdin
+	done  chan struct{} // closed when readLoop has stopped
+
+	closeOnce sync.Once // Close and the supervisor may both close it
+	closeErr  error
+}
+
+func (t *stdioTransport) send(data []byte, _ int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err := fmt.Fprintf(t.stdin, "%s\n", data)
+	return err
+}
+
+// close closes stdin, which ends the server, and waits for the process to exit.
+func (t *stdioTransport) close() error {
+	t.closeOnce.Do(func() { t.closeErr = t.shutdown() })
+	return t.closeErr
+}
+
+func (t *stdioTransport) shutdown() error {
+	t.mu.Lock()
+	t.stdin.Close()
+	t.mu.Unlock()
+
+	if t.cmd == nil {
+		return nil
+	}
+
+	// Wait with timeout — kill if subprocess doesn't exit
+	done := make(chan error, 1)
+	go func() { done <- t.cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.cmd.Process.Kill() // #nosec G104 -- best-effort kill
+		return fmt.Errorf("MCP server did not exit, killed")
+	}
 }
 
 // jsonRPCRequest is a JSON-RPC 2.0 request.
@@ -40,10 +113,13 @@ type jsonRPCRequest struct {
 	Params  interface{} `json:"params,omitempty"`
 }
 
-// jsonRPCResponse is a JSON-RPC 2.0 response.
-type jsonRPCResponse struct {
+// jsonRPCMessage is anything the server sends: a response (ID with Result or
+// Error), a notification (Method, no ID) or a request of its own (both).
+type jsonRPCMessage struct {
 	JSONRPC string          `json:"jsonrpc"`
-	ID      int64           `json:"id"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
 	Result  json.RawMessage `json:"result,omitempty"`
 	Error   *jsonRPCError   `json:"error,omitempty"`
 }
@@ -54,6 +130,16 @@ type jsonRPCError struct {
 	Message string `json:"message"`
 }
 
+func (e *jsonRPCError) Error() string {
+	return fmt.Sprintf("RPC error %d: %s", e.Code, e.Message)
+}
+
+// rpcResult is the outcome of one call, delivered by the reader.
+type rpcResult struct {
+	data json.RawMessage
+	err  error
+}
+
 // mcpToolsResult is the result of tools/list.
 type mcpToolsResult struct {
 	Tools []mcpToolInfo `json:"tools"`
@@ -80,37 +166,52 @@ type mcpContent struct {
 
 // NewMCPClient spawns the multipass-mcp binary and performs the initialize handshake.
 func NewMCPClient(binaryPath string) (*MCPClient, error) {
-	cmd := exec.Command(binaryPath) // #nosec G204 -- binary path from user config or auto-download
-	cmd.Stderr = os.Stderr
+	return startMCPClient(binaryPath, nil, nil)
+}
 
-	stdin, err := cmd.StdinPipe()
+// startMCPClient spawns an MCP server command with extra KEY=VALUE environment
+// variables, performs the initialize handshake and supervises the process,
+// restarting it if it exits or stops answering pings.
+func startMCPClient(command string, args, env []string) (*MCPClient, error) {
+	mc := &mcpCommand{command: command, args: args, env: env}
+	t, stdout, err := mc.start()
 	if err != nil {
-		return nil, fmt.Errorf("stdin pipe: %w", err)
+		return nil, err
 	}
 
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		stdin.Close()
-		return nil, fmt.Errorf("stdout pipe: %w", err)
+	c := &MCPClient{
+		command:   mc,
+		stop:      make(chan struct{}),
+		transport: t,
+		pending:   make(map[int64]chan rpcResult),
 	}
-
-	if err := cmd.Start(); err != nil {
-		stdin.Close()
-		return nil, fmt.Errorf("start MCP server: %w", err)
+	go c.readLoop(t, stdout)
+	if err := c.initialize(mcpStdioProtocolVersion); err != nil {
+		c.Close()
+		return nil, err
 	}
+	go c.supervise()
+	return c, nil
+}
 
+// newMCPClient starts the reader on a connected stream. The caller performs the handshake.
+func newMCPClient(stdout io.Reader, stdin io.WriteCloser) *MCPClient {
+	t := &stdioTransport{stdin: stdin, done: make(chan struct{})}
 	c := &MCPClient{
-		cmd:    cmd,
-		stdin:  stdin,
-		reader: bufio.NewReaderSize(stdout, 64*1024), // 64KB buffer
+		transport: t,
+		pending:   make(map[int64]chan rpcResult),
 	}
+	go c.readLoop(t, stdout)
+	return c
+}
 
-	// Initialize handshake with timeout
+// initialize performs the MCP handshake.
+func (c *MCPClient) initialize(protocolVersion string) error {
 	initCtx, initCancel := context.WithTimeout(context.Background(), mcpInitTimeout)
 	defer initCancel()
 
-	_, err = c.callWithContext(initCtx, "initialize", map[string]interface{}{
-		"protocolVersion": "2024-11-05",
+	_, err := c.callWithContext(initCtx, "initialize", map[string]interface{}{
+		"protocolVersion": protocolVersion,
 		"capabilities":    map[string]interface{}{},
 		"clientInfo": map[string]string{
 			"name":    "passgo",
@@ -118,23 +219,49 @@ func NewMCPClient(binaryPath string) (*MCPClient, error) {
 		},
 	})
 	if err != nil {
-		c.Close()
-		return nil, fmt.Errorf("MCP initialize: %w", err)
+		return fmt.Errorf("MCP initialize: %w", err)
 	}
 
 	// Send initialized notification (no response expected)
 	if err := c.notify("notifications/initialized", nil); err != nil {
-		c.Close()
-		return nil, fmt.Errorf("MCP initialized notification: %w", err)
+		return fmt.Errorf("MCP initialized notification: %w", err)
 	}
+	return nil
+}
 
-	return c, nil
+// SetNotificationHandler sets the function that receives server notifications
+// such as log messages and progress. It runs on the reader goroutine and must
+// not block. Without one, notifications are written to the debug log.
+func (c *MCPClient) SetNotificationHandler(h func(method string, params json.RawMessage)) {
+	c.mu.Lock()
+	c.onNotification = h
+	c.mu.Unlock()
+}
+
+// SetDownHandler sets the function told when the server could not be
+// restarted and every call will fail. It runs on the supervisor goroutine and
+// must not block.
+func (c *MCPClient) SetDownHandler(h func(err error)) {
+	c.mu.Lock()
+	c.onDown = h
+	c.mu.Unlock()
+}
+
+// Down returns why the server is gone for good, nil while it is running or
+// being restarted.
+func (c *MCPClient) Down() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.downErr
 }
 
 // ListTools fetches and caches available tools from the MCP server.
 func (c *MCPClient) ListTools() ([]ToolDef, error) {
-	if len(c.tools) > 0 {
-		return c.tools, nil
+	c.mu.Lock()
+	cached := c.tools
+	c.mu.Unlock()
+	if len(cached) > 0 {
+		return cached, nil
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), mcpCallTimeout)
@@ -162,7 +289,9 @@ func (c *MCPClient) ListTools() ([]ToolDef, error) {
 		}
 	}
 
+	c.mu.Lock()
 	c.tools = tools
+	c.mu.Unlock()
 	return tools, nil
 }
 
@@ -214,133 +343,241 @@ func (c *MCPClient) CallTool(ctx context.Context, name string, arguments map[str
 	return resultText, nil
 }
 
-// Close terminates the MCP subprocess.
+// Close terminates the MCP subprocess or ends the HTTP session. Calls still
+// waiting fail once the server's output is closed.
 func (c *MCPClient) Close() error {
 	if c.closed.Swap(true) {
 		return nil // already closed
 	}
-	c.mu.Lock()
-	c.stdin.Close()
-	c.mu.Unlock()
-
-	// Wait with timeout — kill if subprocess doesn't exit
-	done := make(chan error, 1)
-	go func() { done <- c.cmd.Wait() }()
-
-	select {
-	case err := <-done:
-		return err
-	case <-time.After(5 * time.Second):
-		c.cmd.Process.Kill() // #nosec G104 -- best-effort kill
-		return fmt.Errorf("MCP server did not exit, killed")
+	if c.stop != nil {
+		close(c.stop)
 	}
+	return c.currentTransport().close()
 }
 
-// callWithContext sends a JSON-RPC request and reads the response, respecting context cancellation.
+// currentTransport returns the transport to the running server.
+func (c *MCPClient) currentTransport() mcpTransport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.transport
+}
+
+// callWithContext sends a JSON-RPC request and waits for the reader to deliver
+// its response. When ctx ends first the server is told to cancel the request;
+// a response arriving later is dropped by the reader. Tool calls made while the
+// server is being restarted wait for the restart.
 func (c *MCPClient) callWithContext(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
 	if c.closed.Load() {
 		return nil, fmt.Errorf("MCP client is closed")
 	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	if method == "tools/call" {
+		if err := c.waitRestart(ctx); err != nil {
+			return nil, fmt.Errorf("MCP call %s: %w", method, err)
+		}
+	}
 
 	id := c.nextID.Add(1)
+	ch := make(chan rpcResult, 1)
 
-	req := jsonRPCRequest{
-		JSONRPC: "2.0",
-		ID:      id,
-		Method:  method,
-		Params:  params,
-	}
-
-	data, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
+	c.mu.Lock()
+	if c.readErr != nil {
+		err := c.readErr
+		c.mu.Unlock()
+		return nil, fmt.Errorf("MCP server stopped: %w", err)
 	}
+	c.pending[id] = ch
+	c.mu.Unlock()
 
-	// Write request followed by newline
-	if _, err := fmt.Fprintf(c.stdin, "%s\n", data); err != nil {
+	if err := c.write(jsonRPCRequest{JSONRPC: "2.0", ID: id, Method: method, Params: params}, id); err != nil {
+		c.forget(id)
 		return nil, fmt.Errorf("write request: %w", err)
 	}
 
-	// Read response with context timeout
-	type readResult struct {
-		data json.RawMessage
-		err  error
-	}
-	ch := make(chan readResult, 1)
-
-	go func() {
-		for {
-			line, err := c.reader.ReadBytes('\n')
-			if err != nil {
-				ch <- readResult{err: fmt.Errorf("read response: %w", err)}
-				return
-			}
-
-			// Reject oversized responses
-			if len(line) > mcpReadLimit {
-				ch <- readResult{err: fmt.Errorf("response too large (%d bytes)", len(line))}
-				return
-			}
-
-			var resp jsonRPCResponse
-			if err := json.Unmarshal(line, &resp); err != nil {
-				// Could be a notification, skip it
-				continue
-			}
-
-			// Skip notifications (no ID)
-			if resp.ID == 0 && resp.Result == nil && resp.Error == nil {
-				continue
-			}
-
-			if resp.ID == id {
-				if resp.Error != nil {
-					ch <- readResult{err: fmt.Errorf("RPC error %d: %s", resp.Error.Code, resp.Error.Message)}
-					return
-				}
-				ch <- readResult{data: resp.Result}
-				return
-			}
-		}
-	}()
-
 	select {
 	case <-ctx.Done():
+		c.forget(id)
+		if method != "initialize" {
+			// Best-effort: the server may stop work it no longer needs to do
+			c.notify("notifications/cancelled", map[string]interface{}{ // #nosec G104 -- best-effort
+				"requestId": id,
+				"reason":    ctx.Err().Error(),
+			})
+		}
 		return nil, fmt.Errorf("MCP call %s: %w", method, ctx.Err())
 	case result := <-ch:
 		return result.data, result.err
 	}
 }
 
+// forget drops a call that is no longer waiting for its response.
+func (c *MCPClient) forget(id int64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// waitRestart blocks while the supervisor is restarting the server.
+func (c *MCPClient) waitRestart(ctx context.Context) error {
+	c.mu.Lock()
+	done := c.restartDone
+	c.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// readLoop reads the server's messages until the stream ends, then fails the
+// calls still waiting. A transport that has already been replaced by a
+// restart leaves the client alone.
+func (c *MCPClient) readLoop(t *stdioTransport, stdout io.Reader) {
+	defer close(t.done)
+	reader := bufio.NewReaderSize(stdout, 64*1024) // 64KB buffer
+	var err error
+	for {
+		var line []byte
+		line, err = reader.ReadBytes('\n')
+		if len(line) > 0 {
+			c.dispatch(line)
+		}
+		if err != nil {
+			err = fmt.Errorf("read response: %w", err)
+			break
+		}
+	}
+
+	c.mu.Lock()
+	if c.transport != mcpTransport(t) {
+		c.mu.Unlock()
+		return
+	}
+	c.readErr = err
+	if c.command != nil && !c.closed.Load() && c.restartDone == nil {
+		// Hold tool calls until the supervisor has restarted the server
+		c.restartDone = make(chan struct{})
+	}
+	pending := c.pending
+	c.pending = make(map[int64]chan rpcResult)
+	c.mu.Unlock()
+	for _, ch := range pending {
+		ch <- rpcResult{err: err}
+	}
+}
+
+// dispatch handles one line from the server.
+func (c *MCPClient) dispatch(line []byte) {
+	// Reject oversized responses, failing only the call they answer
+	if len(line) > mcpReadLimit {
+		var head struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if json.Unmarshal(line, &head) == nil {
+			c.deliver(head.ID, rpcResult{err: fmt.Errorf("response too large (%d bytes)", len(line))})
+		}
+		return
+	}
+
+	var msg jsonRPCMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		return // not JSON-RPC (e.g. a stray log line)
+	}
+
+	switch {
+	case msg.Method != "" && len(msg.ID) == 0:
+		c.handleNotification(msg.Method, msg.Params)
+	case msg.Method != "":
+		c.answerServerRequest(msg)
+	case msg.Error != nil:
+		c.deliver(msg.ID, rpcResult{err: msg.Error})
+	default:
+		c.deliver(msg.ID, rpcResult{data: msg.Result})
+	}
+}
+
+// deliver hands a response to the call waiting on rawID, if any.
+func (c *MCPClient) deliver(rawID json.RawMessage, result rpcResult) {
+	var id int64
+	if err := json.Unmarshal(rawID, &id); err != nil {
+		return
+	}
+	if !c.complete(id, result) && appLogger != nil {
+		appLogger.Printf("MCP response for unknown or cancelled request %d dropped", id)
+	}
+}
+
+// complete hands result to the call waiting on id and reports whether there was one.
+func (c *MCPClient) complete(id int64, result rpcResult) bool {
+	c.mu.Lock()
+	ch, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if ok {
+		ch <- result
+	}
+	return ok
+}
+
+// handleNotification drops the cached tools when the server says they changed,
+// then passes the notification on.
+func (c *MCPClient) handleNotification(method string, params json.RawMessage) {
+	c.mu.Lock()
+	if method == "notifications/tools/list_changed" {
+		c.tools = nil
+	}
+	h := c.onNotification
+	c.mu.Unlock()
+
+	if h != nil {
+		h(method, params)
+	} else if appLogger != nil {
+		appLogger.Printf("MCP notification %s: %s", method, truncate(string(params), 200))
+	}
+}
+
+// answerServerRequest replies to a request the server sent us. Only ping is
+// supported; anything else gets "method not found".
+func (c *MCPClient) answerServerRequest(msg jsonRPCMessage) {
+	reply := map[string]interface{}{"jsonrpc": "2.0", "id": msg.ID}
+	if msg.Method == "ping" {
+		reply["result"] = map[string]interface{}{}
+	} else {
+		reply["error"] = jsonRPCError{Code: -32601, Message: "method not found: " + msg.Method}
+	}
+	if err := c.write(reply, 0); err != nil && appLogger != nil {
+		appLogger.Printf("MCP reply to %s failed: %v", msg.Method, err)
+	}
+}
+
 // notify sends a JSON-RPC notification (no response expected).
 func (c *MCPClient) notify(method string, params interface{}) error {
 	if c.closed.Load() {
 		return fmt.Errorf("MCP client is closed")
 	}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	type notification struct {
 		JSONRPC string      `json:"jsonrpc"`
 		Method  string      `json:"method"`
 		Params  interface{} `json:"params,omitempty"`
 	}
 
-	req := notification{
+	return c.write(notification{
 		JSONRPC: "2.0",
 		Method:  method,
 		Params:  params,
-	}
+	}, 0)
+}
 
-	data, err := json.Marshal(req)
+// write encodes one message and hands it to the transport. id is the request
+// ID, or 0 for notifications and replies.
+func (c *MCPClient) write(msg interface{}, id int64) error {
+	data, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
-
-	_, err = fmt.Fprintf(c.stdin, "%s\n", data)
-	return err
+	return c.currentTransport().send(data, id)
 }
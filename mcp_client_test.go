@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMCPServer is the server end of an MCPClient connected through pipes.
+type fakeMCPServer struct {
+	in  *bufio.Reader  // what the client writes
+	out io.WriteCloser // what the client reads
+}
+
+func newFakeMCP(t *testing.T) (*MCPClient, *fakeMCPServer) {
+	t.Helper()
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	c := newMCPClient(clientIn, clientOut)
+	t.Cleanup(func() {
+		c.Close()
+		serverOut.Close()
+	})
+	return c, &fakeMCPServer{in: bufio.NewReader(serverIn), out: serverOut}
+}
+
+// next reads the next message the client sent.
+func (s *fakeMCPServer) next(t *testing.T) jsonRPCMessage {
+	t.Helper()
+	line, err := s.in.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("server read: %v", err)
+	}
+	var msg jsonRPCMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		t.Fatalf("server decode %q: %v", line, err)
+	}
+	return msg
+}
+
+func (s *fakeMCPServer) send(t *testing.T, line string) {
+	t.Helper()
+	if _, err := fmt.Fprintln(s.out, line); err != nil {
+		t.Fatalf("server write: %v", err)
+	}
+}
+
+func toolText(id json.RawMessage, text string) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"content":[{"type":"text","text":%q}]}}`, id, text)
+}
+
+func TestMCPClientConcurrentCallsOutOfOrder(t *testing.T) {
+	c, srv := newFakeMCP(t)
+
+	type result struct {
+		out string
+		err error
+	}
+	results := make(chan result, 2)
+	for _, vm := range []string{"a", "b"} {
+		go func(vm string) {
+			out, err := c.CallTool(context.Background(), "get_instance_info", map[string]interface{}{"name": vm})
+			results <- result{out, err}
+		}(vm)
+	}
+
+	// Both requests are in flight before either is answered; reply in reverse order
+	first, second := srv.next(t), srv.next(t)
+	name := func(m jsonRPCMessage) string {
+		var p struct {
+			Arguments map[string]string `json:"arguments"`
+		}
+		_ = json.Unmarshal(m.Params, &p)
+		return p.Arguments["name"]
+	}
+	srv.send(t, toolText(second.ID, "info "+name(second)))
+	srv.send(t, toolText(first.ID, "info "+name(first)))
+
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		r := <-results
+		if r.err != nil {
+			t.Fatalf("CallTool: %v", r.err)
+		}
+		got[r.out] = true
+	}
+	if !got["info a"] || !got["info b"] {
+		t.Fatalf("responses routed to the wrong calls: %v", got)
+	}
+}
+
+func TestMCPClientCancelSendsNotification(t *testing.T) {
+	c, srv := newFakeMCP(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.CallTool(ctx, "stop_instance", nil)
+		errc <- err
+	}()
+	req := srv.next(t)
+	cancel()
+
+	note := srv.next(t)
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	_ = json.Unmarshal(note.Params, &params)
+	if note.Method != "notifications/cancelled" || string(params.RequestID) != string(req.ID) {
+		t.Fatalf("expected notifications/cancelled for %s, got %+v", req.ID, note)
+	}
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation error, got %v", err)
+	}
+
+	// The late response is dropped and does not reach the next call
+	srv.send(t, toolText(req.ID, "late"))
+	go func() {
+		next := srv.next(t)
+		srv.send(t, toolText(next.ID, "fresh"))
+	}()
+	out, err := c.CallTool(context.Background(), "list_instances", nil)
+	if err != nil || out != "fresh" {
+		t.Fatalf("next call got %q, %v", out, err)
+	}
+}
+
+func TestMCPClientNotificationsAndServerPing(t *testing.T) {
+	c, srv := newFakeMCP(t)
+	c.tools = []ToolDef{{Type: "function"}}
+
+	notes := make(chan string, 2)
+	c.SetNotificationHandler(func(method string, params json.RawMessage) {
+		notes <- method + " " + string(params)
+	})
+	srv.send(t, `{"jsonrpc":"2.0","method":"notifications/message","params":{"level":"info","data":"hello"}}`)
+	srv.send(t, `{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`)
+	if got := <-notes; !strings.Contains(got, "hello") {
+		t.Fatalf("unexpected notification %q", got)
+	}
+	<-notes
+	c.mu.Lock()
+	cached := c.tools
+	c.mu.Unlock()
+	if cached != nil {
+		t.Fatalf("tools/list_changed should drop the cached tools")
+	}
+
+	srv.send(t, `{"jsonrpc":"2.0","id":"srv-1","method":"ping"}`)
+	reply := srv.next(t)
+	if string(reply.ID) != `"srv-1"` || reply.Error != nil || string(reply.Result) != "{}" {
+		t.Fatalf("unexpected ping reply %+v", reply)
+	}
+}
+
+func TestMCPClientServerExitFailsPendingCalls(t *testing.T) {
+	c, srv := newFakeMCP(t)
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := c.CallTool(context.Background(), "get_instance_info", nil)
+		errc <- err
+	}()
+	srv.next(t)
+	srv.out.Close()
+
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Fatalf("expected the pending call to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("pending call not failed after the server exited")
+	}
+	if _, err := c.CallTool(context.Background(), "get_instance_info", nil); err == nil || !strings.Contains(err.Error(), "stopped") {
+		t.Fatalf("expected new calls to fail, got %v", err)
+	}
+}
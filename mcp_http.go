@@ -0,0 +1,287 @@
+// mcp_http.go - MCP Streamable HTTP transport: JSON-RPC POSTs answered with JSON or an SSE stream
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mcpSessionHeader carries the session ID the server assigns in its initialize response.
+const mcpSessionHeader = "Mcp-Session-Id"
+
+// httpTransport speaks the MCP Streamable HTTP transport to a server that is
+// already running. Every message is POSTed to one endpoint; a request is
+// answered either with a JSON body or with an SSE stream that may carry
+// notifications and server requests before the response. A GET stream, when
+// the server offers one, carries messages that are not tied to a request.
+type httpTransport struct {
+	url     string
+	headers http.Header // configured headers plus Authorization
+	client  *http.Client
+	ctx     context.Context // cancelled by close to end open streams
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup // request and listen goroutines
+
+	onMessage func(data []byte)              // the client's dispatch
+	onFail    func(id int64, err error) bool // fails a call that got no response
+	reinit    func() error                   // the client's initialize handshake
+
+	renewMu   sync.Mutex // one session renewal at a time
+	mu        sync.Mutex
+	sessionID string
+}
+
+// NewMCPHTTPClient connects to an MCP server at url over Streamable HTTP and
+// performs the initialize handshake. headers are sent with every request; a
+// non-empty token is sent as a bearer token.
+func NewMCPHTTPClient(url, token string, headers http.Header) (*MCPClient, error) {
+	h := headers.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	if token != "" {
+		h.Set("Authorization", "Bearer "+token)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &httpTransport{url: url, headers: h, client: &http.Client{}, ctx: ctx, cancel: cancel}
+	c := &MCPClient{transport: t, pending: make(map[int64]chan rpcResult)}
+	t.onMessage = c.dispatch
+	t.onFail = func(id int64, err error) bool { return c.complete(id, rpcResult{err: err}) }
+	t.reinit = func() error { return c.initialize(mcpHTTPProtocolVersion) }
+
+	if err := c.initialize(mcpHTTPProtocolVersion); err != nil {
+		c.Close()
+		return nil, err
+	}
+	t.wg.Add(1)
+	go t.listen()
+	return c, nil
+}
+
+// send POSTs one message. Notifications and replies are sent before returning
+// so they stay in order with what follows (notifications/initialized must
+// arrive before tools/list); requests are answered in the background.
+func (t *httpTransport) send(data []byte, id int64) error {
+	if id == 0 {
+		return t.post(data, 0)
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		err := t.post(data, id)
+		if err == nil {
+			err = errors.New("MCP server ended the response without answering")
+		}
+		t.onFail(id, err) // no-op when the response was delivered
+	}()
+	return nil
+}
+
+// post sends one message and dispatches whatever the server answers with.
+func (t *httpTransport) post(data []byte, id int64) error {
+	return t.postMessage(data, id, true)
+}
+
+// postMessage is post. A request that gets 404 for its session ID, which means
+// the server ended the session, starts a new session and is sent once more
+// when renew is set.
+func (t *httpTransport) postMessage(data []byte, id int64, renew bool) error {
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodPost, t.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	resp, err := t.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusAccepted {
+		return nil
+	}
+	if id == 0 && resp.StatusCode == http.StatusOK {
+		return nil // some servers answer notifications with an empty 200
+	}
+	if session := req.Header.Get(mcpSessionHeader); resp.StatusCode == http.StatusNotFound && session != "" && id != 0 && renew {
+		resp.Body.Close()
+		if err := t.renewSession(session); err != nil {
+			return fmt.Errorf("MCP session expired and a new one could not be started: %w", err)
+		}
+		return t.postMessage(data, id, false)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return mcpHTTPError(resp)
+	}
+	if isEventStream(resp) {
+		return readMCPEvents(resp.Body, t.onMessage)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, mcpReadLimit+1))
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		// A JSON-RPC batch
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return fmt.Errorf("parse response: %w", err)
+		}
+		for _, msg := range batch {
+			t.onMessage(msg)
+		}
+		return nil
+	}
+	t.onMessage(body)
+	return nil
+}
+
+// listen opens the optional GET stream for messages the server sends on its
+// own, such as notifications/tools/list_changed. Servers without one answer
+// 405, which is fine.
+func (t *httpTransport) listen() {
+	defer t.wg.Done()
+	req, err := http.NewRequestWithContext(t.ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := t.do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !isEventStream(resp) {
+		return
+	}
+	if err := readMCPEvents(resp.Body, t.onMessage); err != nil && t.ctx.Err() == nil && appLogger != nil {
+		appLogger.Printf("MCP event stream from %s ended: %v", t.url, err)
+	}
+}
+
+// do adds the configured headers and the session ID, sends req and remembers
+// a session ID the server assigns.
+func (t *httpTransport) do(req *http.Request) (*http.Response, error) {
+	for name, values := range t.headers {
+		for _, v := range values {
+			req.Header.Add(name, v)
+		}
+	}
+	t.mu.Lock()
+	if t.sessionID != "" {
+		req.Header.Set(mcpSessionHeader, t.sessionID)
+	}
+	t.mu.Unlock()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("MCP request failed: %w", err)
+	}
+	if id := resp.Header.Get(mcpSessionHeader); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// renewSession starts a new session in place of expired. Requests that saw the
+// same session expire share one initialize.
+func (t *httpTransport) renewSession(expired string) error {
+	t.renewMu.Lock()
+	defer t.renewMu.Unlock()
+	t.mu.Lock()
+	if t.sessionID != expired {
+		t.mu.Unlock()
+		return nil // already renewed
+	}
+	t.sessionID = ""
+	t.mu.Unlock()
+	if appLogger != nil {
+		appLogger.Printf("MCP session at %s expired, starting a new one", t.url)
+	}
+	return t.reinit()
+}
+
+// close ends the session on the server, best-effort, then aborts open
+// requests and streams; their calls fail.
+func (t *httpTransport) close() error {
+	t.mu.Lock()
+	session := t.sessionID
+	t.mu.Unlock()
+
+	if session != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil); err == nil {
+			if resp, err := t.do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+		cancel()
+	}
+
+	t.cancel()
+	t.wg.Wait()
+	return nil
+}
+
+// mcpHTTPError describes a failed HTTP response with the start of its body.
+func mcpHTTPError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		msg = http.StatusText(resp.StatusCode)
+	}
+	return fmt.Errorf("MCP server returned HTTP %d: %s", resp.StatusCode, msg)
+}
+
+func isEventStream(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+// readMCPEvents reads an SSE stream and passes each event's data, one
+// JSON-RPC message, to onMessage. Event names, IDs and comments are ignored.
+// An event larger than mcpReadLimit ends the stream with an error.
+func readMCPEvents(r io.Reader, onMessage func([]byte)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), mcpReadLimit+len("data: "))
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		switch {
+		case len(line) == 0:
+			if len(data) > 0 {
+				onMessage(data)
+				data = nil
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			line = bytes.TrimPrefix(line[len("data:"):], []byte(" "))
+			if len(data)+1+len(line) > mcpReadLimit {
+				return fmt.Errorf("read event stream: event larger than %d bytes", mcpReadLimit)
+			}
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, line...)
+		}
+	}
+	if len(data) > 0 {
+		onMessage(data)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read event stream: %w", err)
+	}
+	return nil
+}
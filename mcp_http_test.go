@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// httpMCPStandIn is a minimal Streamable HTTP MCP server: JSON answers for
+// initialize and tools/list, an SSE stream for tools/call.
+type httpMCPStandIn struct {
+	mu       sync.Mutex
+	methods  []string // POSTed methods, in order
+	sessions []string // Mcp-Session-Id sent with each POST after initialize
+	deleted  bool
+	inits    int    // initialize requests; each gets session sess-N
+	expired  string // a session the server has ended, answered with 404
+}
+
+func (s *httpMCPStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Team") != "infra" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		http.Error(w, "no event stream", http.StatusMethodNotAllowed)
+		return
+	case http.MethodDelete:
+		s.mu.Lock()
+		s.deleted = r.Header.Get(mcpSessionHeader) == "sess-1"
+		s.mu.Unlock()
+		return
+	}
+
+	var msg jsonRPCMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.methods = append(s.methods, msg.Method)
+	if msg.Method != "initialize" {
+		s.sessions = append(s.sessions, r.Header.Get(mcpSessionHeader))
+	}
+	if msg.Method == "initialize" {
+		s.inits++
+	}
+	inits, expired := s.inits, s.expired != "" && r.Header.Get(mcpSessionHeader) == s.expired
+	s.mu.Unlock()
+	if expired {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	switch msg.Method {
+	case "initialize":
+		w.Header().Set(mcpSessionHeader, fmt.Sprintf("sess-%d", inits))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"protocolVersion":"2025-03-26","capabilities":{"tools":{}}}}`, msg.ID)
+	case "tools/list":
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":{"tools":[{"name":"search","description":"Search docs","inputSchema":{"type":"object"}}]}}`, msg.ID)
+	case "tools/call":
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/message\",\"params\":{\"data\":\"searching\"}}\n\n")
+		fmt.Fprintf(w, "id: 2\ndata: %s\n\n", toolText(msg.ID, "found it"))
+	default:
+		if len(msg.ID) == 0 {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"method not found"}}`, msg.ID)
+	}
+}
+
+func TestMCPHTTPClientJSONAndSSEResponses(t *testing.T) {
+	standIn := &httpMCPStandIn{}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	c, err := NewMCPHTTPClient(srv.URL, "secret", http.Header{"X-Team": {"infra"}})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	notes := make(chan string, 1)
+	c.SetNotificationHandler(func(method string, params json.RawMessage) {
+		notes <- method + " " + string(params)
+	})
+
+	tools, err := c.ListTools()
+	if err != nil || len(tools) != 1 || tools[0].Function.Name != "search" {
+		t.Fatalf("ListTools: %+v, %v", tools, err)
+	}
+	out, err := c.CallTool(context.Background(), "search", map[string]interface{}{"q": "vm"})
+	if err != nil || out != "found it" {
+		t.Fatalf("CallTool: %q, %v", out, err)
+	}
+	if got := <-notes; !strings.Contains(got, "searching") {
+		t.Fatalf("notification from the SSE stream not passed on: %q", got)
+	}
+	c.Close()
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	if got := strings.Join(standIn.methods, ","); got != "initialize,notifications/initialized,tools/list,tools/call" {
+		t.Fatalf("unexpected requests %s", got)
+	}
+	for _, s := range standIn.sessions {
+		if s != "sess-1" {
+			t.Fatalf("session ID not sent after initialize: %q", standIn.sessions)
+		}
+	}
+	if !standIn.deleted {
+		t.Fatalf("Close should end the session")
+	}
+}
+
+func TestMCPHTTPClientRenewsExpiredSession(t *testing.T) {
+	standIn := &httpMCPStandIn{}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	c, err := NewMCPHTTPClient(srv.URL, "secret", http.Header{"X-Team": {"infra"}})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer c.Close()
+
+	standIn.mu.Lock()
+	standIn.expired = "sess-1"
+	standIn.mu.Unlock()
+	for i := 0; i < 2; i++ {
+		if out, err := c.CallTool(context.Background(), "search", nil); err != nil || out != "found it" {
+			t.Fatalf("call %d after the session expired: %q, %v", i, out, err)
+		}
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+	if standIn.inits != 2 {
+		t.Fatalf("expected one new session, got %d initialize requests", standIn.inits)
+	}
+	if last := standIn.sessions[len(standIn.sessions)-1]; last != "sess-2" {
+		t.Fatalf("calls should use the new session, got %q", standIn.sessions)
+	}
+}
+
+func TestReadMCPEventsLimitsEventSize(t *testing.T) {
+	line := "data: " + strings.Repeat("x", mcpReadLimit/2) + "\n"
+	err := readMCPEvents(strings.NewReader(line+line+line+"\n"), func([]byte) {
+		t.Fatalf("an oversized event must not be passed on")
+	})
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Fatalf("expected a size error, got %v", err)
+	}
+}
+
+func TestMCPHTTPClientRejectedToken(t *testing.T) {
+	srv := httptest.NewServer(&httpMCPStandIn{})
+	defer srv.Close()
+
+	_, err := NewMCPHTTPClient(srv.URL, "wrong", http.Header{"X-Team": {"infra"}})
+	if err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Fatalf("expected an HTTP 401 error, got %v", err)
+	}
+}
+
+func TestConnectMCPServerOverHTTP(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TEAM_MCP_TOKEN", "secret")
+	srv := httptest.NewServer(&httpMCPStandIn{})
+	defer srv.Close()
+
+	conf := fmt.Sprintf("[mcp team]\nurl=%s\nheader=X-Team: infra\nheader=no colon\ntoken-env=TEAM_MCP_TOKEN\n", srv.URL)
+	cfg, err := parseLLMConfig(strings.NewReader(conf))
+	if err != nil || len(cfg.MCPServers) != 1 {
+		t.Fatalf("parse: %+v, %v", cfg.MCPServers, err)
+	}
+	if got := cfg.MCPServers[0].Headers; len(got) != 1 {
+		t.Fatalf("expected the malformed header to be dropped, got %q", got)
+	}
+
+	servers := startMCPServers(cfg.MCPServers)
+	defer newToolRouter(nil, nil, servers).Close()
+	if servers[0].err != nil || len(servers[0].tools) != 1 || servers[0].tools[0].Function.Name != "team__search" {
+		t.Fatalf("unexpected server %+v", servers[0])
+	}
+}
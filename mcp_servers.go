@@ -0,0 +1,223 @@
+// mcp_servers.go - Extra MCP servers from llm.conf (spawned or over HTTP) and the router that namespaces their tools
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// mcpToolSeparator joins a server name and one of its tools, e.g. docs__search.
+// Tool names may only contain letters, digits, _ and -, so no "." or "/".
+const mcpToolSeparator = "__"
+
+// mcpServer is a configured extra server: its client once started, or why it failed.
+type mcpServer struct {
+	name   string
+	client *MCPClient
+	tools  []ToolDef // namespaced; the last list the server gave
+	err    error
+}
+
+// mcpToolPrefix turns a server name into a tool name prefix.
+func mcpToolPrefix(server string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, server) + mcpToolSeparator
+}
+
+// namespaceTools prefixes a server's tools with its name and labels their descriptions.
+func namespaceTools(server string, tools []ToolDef) []ToolDef {
+	prefix := mcpToolPrefix(server)
+	out := make([]ToolDef, len(tools))
+	for i, t := range tools {
+		t.Function.Name = prefix + t.Function.Name
+		t.Function.Description = fmt.Sprintf("[%s] %s", server, t.Function.Description)
+		out[i] = t
+	}
+	return out
+}
+
+// unqualifiedToolName strips the server prefix from a namespaced tool name.
+func unqualifiedToolName(name string) string {
+	if _, tool, ok := strings.Cut(name, mcpToolSeparator); ok {
+		return tool
+	}
+	return name
+}
+
+// startMCPServers starts the configured servers concurrently and lists their
+// tools. Servers that fail are returned with err set.
+func startMCPServers(cfgs []MCPServerConfig) []*mcpServer {
+	servers := make([]*mcpServer, len(cfgs))
+	var wg sync.WaitGroup
+	for i, cfg := range cfgs {
+		servers[i] = &mcpServer{name: cfg.Name}
+		wg.Add(1)
+		go func(s *mcpServer, cfg MCPServerConfig) {
+			defer wg.Done()
+			client, err := connectMCPServer(cfg)
+			if err != nil {
+				s.err = err
+				return
+			}
+			tools, err := client.ListTools()
+			if err != nil {
+				client.Close()
+				s.err = err
+				return
+			}
+			s.tools = namespaceTools(cfg.Name, tools)
+			s.client = client
+		}(servers[i], cfg)
+	}
+	wg.Wait()
+	return servers
+}
+
+// connectMCPServer connects to a running server over HTTP when cfg has a URL
+// and starts cfg.Command otherwise.
+func connectMCPServer(cfg MCPServerConfig) (*MCPClient, error) {
+	if cfg.URL == "" {
+		return startMCPClient(cfg.Command, cfg.Args, cfg.Env)
+	}
+	headers := http.Header{}
+	for _, h := range cfg.Headers {
+		name, value, _ := strings.Cut(h, ":")
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	token := cfg.Token
+	if token == "" && cfg.TokenEnv != "" {
+		token = os.Getenv(cfg.TokenEnv)
+	}
+	return NewMCPHTTPClient(cfg.URL, token, headers)
+}
+
+// ToolRouter is the chat's tool executor once tools are set up: the base tools
+// (multipass-mcp or built-in, unprefixed) plus the namespaced tools of the extra
+// MCP servers. Tool lists are read from the clients on each use, so a server
+// that was restarted or announced tools/list_changed is offered as it is now.
+type ToolRouter struct {
+	base      ToolExecutor // nil when neither multipass-mcp nor built-in tools are in use
+	baseTools []ToolDef    // the last list the base gave
+	servers   []*mcpServer
+	mu        sync.Mutex // guards baseTools and each server's tools
+}
+
+func newToolRouter(base ToolExecutor, baseTools []ToolDef, servers []*mcpServer) *ToolRouter {
+	return &ToolRouter{base: base, baseTools: baseTools, servers: servers}
+}
+
+// Definitions returns every tool offered to the model.
+func (r *ToolRouter) Definitions() []ToolDef {
+	var tools []ToolDef
+	if c, ok := r.base.(*MCPClient); ok {
+		if listed, err := c.ListTools(); err == nil {
+			r.mu.Lock()
+			r.baseTools = listed
+			r.mu.Unlock()
+		}
+	}
+	r.mu.Lock()
+	tools = append(tools, r.baseTools...)
+	r.mu.Unlock()
+	for _, s := range r.servers {
+		tools = append(tools, r.serverTools(s)...)
+	}
+	return tools
+}
+
+// serverTools returns a server's namespaced tools, listed again when the
+// client dropped its cache. The last list is kept while listing fails, e.g.
+// when a restart gave up.
+func (r *ToolRouter) serverTools(s *mcpServer) []ToolDef {
+	if s.client == nil {
+		return nil
+	}
+	if listed, err := s.client.ListTools(); err == nil {
+		r.mu.Lock()
+		s.tools = namespaceTools(s.name, listed)
+		r.mu.Unlock()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return s.tools
+}
+
+// CallTool sends a namespaced call to its server and anything else to the base
+// executor. The prefix alone picks the server (names are checked to be
+// unambiguous), so a call made while the server restarts waits for it and is
+// checked against the new tool list by the server itself. A call for a server
+// that failed to start fails here rather than reaching the base executor.
+func (r *ToolRouter) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (string, error) {
+	for _, s := range r.servers {
+		tool, ok := strings.CutPrefix(name, mcpToolPrefix(s.name))
+		if !ok {
+			continue
+		}
+		if s.client == nil {
+			return "", fmt.Errorf("MCP server %s is unavailable: %v", s.name, s.err)
+		}
+		return s.client.CallTool(ctx, tool, arguments)
+	}
+	if r.base == nil {
+		return "", fmt.Errorf("unknown tool %s", name)
+	}
+	return r.base.CallTool(ctx, name, arguments)
+}
+
+// Status describes the tool backends for the chat title, e.g. "MCP · docs ✓ · fs ✗".
+// A server that failed to start or could not be restarted is marked ✗.
+func (r *ToolRouter) Status() string {
+	var parts []string
+	switch base := r.base.(type) {
+	case *MCPClient:
+		if base.Down() != nil {
+			parts = append(parts, "MCP ✗")
+		} else {
+			parts = append(parts, "MCP")
+		}
+	case *NativeTools:
+		parts = append(parts, "built-in tools")
+	}
+	for _, s := range r.servers {
+		mark := "✓"
+		if s.client == nil || s.client.Down() != nil {
+			mark = "✗"
+		}
+		parts = append(parts, s.name+" "+mark)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// SetDownHandler tells h, with the server's name, when multipass-mcp or an
+// extra server stops for good.
+func (r *ToolRouter) SetDownHandler(h func(server string, err error)) {
+	if c, ok := r.base.(*MCPClient); ok {
+		c.SetDownHandler(func(err error) { h("multipass-mcp", err) })
+	}
+	for _, s := range r.servers {
+		if s.client != nil {
+			name := s.name
+			s.client.SetDownHandler(func(err error) { h(name, err) })
+		}
+	}
+}
+
+// Close stops multipass-mcp and the extra servers.
+func (r *ToolRouter) Close() {
+	if c, ok := r.base.(*MCPClient); ok {
+		c.Close()
+	}
+	for _, s := range r.servers {
+		if s.client != nil {
+			s.client.Close()
+		}
+	}
+}
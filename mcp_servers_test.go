@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseLLMConfigMCPServers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	conf := `[mcp docs]
+command=/usr/local/bin/docs-mcp
+arg=--root
+arg=/srv/docs with spaces
+env=DOCS_TOKEN=abc=123
+env=ignored
+
+[mcp nocommand]
+arg=x
+`
+	cfg, err := parseLLMConfig(strings.NewReader(conf))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(cfg.MCPServers) != 1 {
+		t.Fatalf("expected one server (the other has no command), got %+v", cfg.MCPServers)
+	}
+	srv := cfg.MCPServers[0]
+	if srv.Name != "docs" || srv.Command != "/usr/local/bin/docs-mcp" ||
+		strings.Join(srv.Args, "|") != "--root|/srv/docs with spaces" || strings.Join(srv.Env, "|") != "DOCS_TOKEN=abc=123" {
+		t.Fatalf("unexpected server %+v", srv)
+	}
+
+	if err := saveLLMConfig(cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	got, err := loadLLMConfig()
+	if err != nil || len(got.MCPServers) != 1 || strings.Join(got.MCPServers[0].Args, "|") != strings.Join(srv.Args, "|") {
+		t.Fatalf("servers not persisted: %+v, %v", got.MCPServers, err)
+	}
+}
+
+func TestParseLLMConfigRejectsAmbiguousServerNames(t *testing.T) {
+	conf := `[mcp my.docs]
+command=/bin/a
+
+[mcp my_docs]
+command=/bin/b
+
+[mcp team__wiki]
+command=/bin/c
+
+[mcp wiki]
+url=http://localhost:8000/mcp
+`
+	cfg, err := parseLLMConfig(strings.NewReader(conf))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var names []string
+	for _, srv := range cfg.MCPServers {
+		names = append(names, srv.Name)
+	}
+	if strings.Join(names, ",") != "my.docs,wiki" {
+		t.Fatalf("expected the clashing and separator names dropped, got %v", names)
+	}
+}
+
+func TestToolRouterNamespacesServers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	client, srv := newFakeMCP(t)
+	client.tools = []ToolDef{{Type: "function", Function: ToolDefFunction{Name: "search"}}}
+	docs := &mcpServer{name: "docs", client: client, tools: namespaceTools("docs", client.tools)}
+	failed := &mcpServer{name: "fs", err: errToolDenied}
+	base := &fakeExecutor{}
+	router := newToolRouter(base, []ToolDef{{Type: "function", Function: ToolDefFunction{Name: "list_instances"}}}, []*mcpServer{docs, failed})
+
+	if n := len(router.Definitions()); n != 2 {
+		t.Fatalf("expected base and docs tools, got %d", n)
+	}
+	if got := router.Status(); got != "docs ✓ · fs ✗" {
+		t.Fatalf("unexpected status %q", got)
+	}
+
+	go func() {
+		req := srv.next(t)
+		var p struct {
+			Name string `json:"name"`
+		}
+		_ = json.Unmarshal(req.Params, &p)
+		srv.send(t, toolText(req.ID, "called "+p.Name))
+	}()
+	out, err := router.CallTool(context.Background(), "docs__search", nil)
+	if err != nil || out != "called search" {
+		t.Fatalf("namespaced call = %q, %v", out, err)
+	}
+	if out, _ := router.CallTool(context.Background(), "list_instances", nil); out != "list_instances <nil> done" {
+		t.Fatalf("base call = %q", out)
+	}
+	// A failed server's calls must not fall through to the base executor
+	out, err = router.CallTool(context.Background(), "fs__read", nil)
+	if err == nil || out != "" || !strings.Contains(err.Error(), "MCP server fs is unavailable") {
+		t.Fatalf("call to a failed server = %q, %v", out, err)
+	}
+}
+
+func TestStartMCPServersReportsFailures(t *testing.T) {
+	servers := startMCPServers([]MCPServerConfig{{Name: "missing", Command: "/nonexistent/mcp-server"}})
+	if len(servers) != 1 || servers[0].err == nil || servers[0].client != nil {
+		t.Fatalf("expected a start failure, got %+v", servers[0])
+	}
+	if got := newToolRouter(nil, nil, servers).Status(); got != "missing ✗" {
+		t.Fatalf("unexpected status %q", got)
+	}
+}
@@ -0,0 +1,237 @@
+// mcp_supervisor.go - Keeps spawned MCP servers running: ping health checks, restarts, stderr into the log
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// MCP supervision
+const (
+	mcpPingInterval    = 30 * time.Second // health check period
+	mcpPingTimeout     = 10 * time.Second // a ping slower than this counts as a hang
+	mcpMaxRestarts     = 5                // consecutive failed restarts before giving up
+	mcpRestartMaxDelay = 30 * time.Second // backoff cap between restart attempts
+	mcpStderrLineLimit = 4096             // longer stderr lines are logged in pieces
+)
+
+// mcpRestartDelay is the wait after the first failed restart, doubled after
+// each further one. Tests shorten it.
+var mcpRestartDelay = time.Second
+
+// mcpCommand is how a spawned MCP server is started, kept for restarts.
+type mcpCommand struct {
+	command string
+	args    []string
+	env     []string // extra KEY=VALUE variables
+}
+
+// name labels the server in the log.
+func (mc *mcpCommand) name() string {
+	return filepath.Base(mc.command)
+}
+
+// start spawns the server with its stderr going to the log, so it does not
+// draw over the TUI.
+func (mc *mcpCommand) start() (*stdioTransport, io.Reader, error) {
+	cmd := exec.Command(mc.command, mc.args...) // #nosec G204 -- command from user config or auto-download
+	cmd.Stderr = &mcpStderrLog{server: mc.name()}
+	if len(mc.env) > 0 {
+		cmd.Env = append(os.Environ(), mc.env...)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("stdin pipe: %w", err)
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		stdin.Close()
+		return nil, nil, fmt.Errorf("stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		return nil, nil, fmt.Errorf("start MCP server: %w", err)
+	}
+	return &stdioTransport{cmd: cmd, stdin: stdin, done: make(chan struct{})}, stdout, nil
+}
+
+// supervise restarts the server when it exits or stops answering pings,
+// until the client is closed or a restart fails for good.
+func (c *MCPClient) supervise() {
+	ticker := time.NewTicker(mcpPingInterval)
+	defer ticker.Stop()
+
+	for {
+		t := c.currentTransport().(*stdioTransport)
+		var reason error
+		select {
+		case <-c.stop:
+			return
+		case <-t.done:
+			reason = errors.New("exited")
+		case <-ticker.C:
+			if reason = c.ping(); reason == nil {
+				continue
+			}
+			reason = fmt.Errorf("ping failed: %w", reason)
+		}
+		if c.closed.Load() {
+			return
+		}
+
+		// Reap the old process (killing it if it hung) for its exit status
+		if err := t.close(); err != nil {
+			reason = fmt.Errorf("%w: %v", reason, err)
+		}
+		if appLogger != nil {
+			appLogger.Printf("MCP server %s %v, restarting", c.command.name(), reason)
+		}
+		if !c.restart() {
+			return
+		}
+	}
+}
+
+// ping checks that the server still answers. An error reply is an answer too:
+// servers need not implement ping, and one that says "method not found" is
+// alive. Only a timeout or a broken transport fails.
+func (c *MCPClient) ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), mcpPingTimeout)
+	defer cancel()
+	_, err := c.callWithContext(ctx, "ping", nil)
+	var rpcErr *jsonRPCError
+	if errors.As(err, &rpcErr) {
+		return nil
+	}
+	return err
+}
+
+// restart starts a new server process, retrying with backoff. Tool calls wait
+// until it is done. It reports false when the client was closed or every
+// attempt failed, which leaves calls failing with the last error.
+func (c *MCPClient) restart() bool {
+	c.mu.Lock()
+	if c.restartDone == nil {
+		c.restartDone = make(chan struct{})
+	}
+	done := c.restartDone
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.restartDone = nil
+		c.mu.Unlock()
+		close(done)
+	}()
+
+	delay := mcpRestartDelay
+	var err error
+	for attempt := 1; attempt <= mcpMaxRestarts; attempt++ {
+		err = c.respawn()
+		if err == nil {
+			if appLogger != nil {
+				appLogger.Printf("MCP server %s restarted", c.command.name())
+			}
+			return true
+		}
+		if c.closed.Load() {
+			return false
+		}
+		if appLogger != nil {
+			appLogger.Printf("MCP server %s restart %d/%d failed: %v", c.command.name(), attempt, mcpMaxRestarts, err)
+		}
+		select {
+		case <-c.stop:
+			return false
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, mcpRestartMaxDelay)
+	}
+	if appLogger != nil {
+		appLogger.Printf("MCP server %s could not be restarted, giving up", c.command.name())
+	}
+	c.markDown(fmt.Errorf("stopped and could not be restarted: %w", err))
+	return false
+}
+
+// markDown records that the server is gone for good and tells the down handler.
+func (c *MCPClient) markDown(err error) {
+	c.mu.Lock()
+	c.downErr = err
+	h := c.onDown
+	c.mu.Unlock()
+	if h != nil {
+		h(err)
+	}
+}
+
+// respawn replaces the transport with a new process, then repeats the
+// initialize handshake and tools/list.
+func (c *MCPClient) respawn() error {
+	t, stdout, err := c.command.start()
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.closed.Load() {
+		c.mu.Unlock()
+		t.close() // #nosec G104 -- the client is gone
+		return fmt.Errorf("MCP client is closed")
+	}
+	c.transport = t
+	c.readErr = nil
+	c.tools = nil
+	c.mu.Unlock()
+	go c.readLoop(t, stdout)
+
+	err = c.initialize(mcpStdioProtocolVersion)
+	if err == nil {
+		_, err = c.ListTools()
+	}
+	if err != nil {
+		t.close() // #nosec G104 -- replaced by the next attempt
+		<-t.done  // readErr is set before the next attempt or giving up
+		return err
+	}
+	return nil
+}
+
+// mcpStderrLog writes a server's stderr to the log line by line.
+type mcpStderrLog struct {
+	server  string
+	partial []byte // the unfinished last line
+}
+
+func (w *mcpStderrLog) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(w.partial[:i])
+		w.partial = w.partial[i+1:]
+	}
+	if len(w.partial) > mcpStderrLineLimit {
+		w.log(w.partial)
+		w.partial = nil
+	}
+	return len(p), nil
+}
+
+func (w *mcpStderrLog) log(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) > 0 && appLogger != nil {
+		appLogger.Printf("MCP %s stderr: %s", w.server, line)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHelperMCPServer is not a real test: run as a subprocess with
+// PASSGO_FAKE_MCP=1 it is a stdio MCP server whose "crash" tool exits. It does
+// not implement ping. Its tools are echo and crash, or the comma-separated
+// names read at startup from the file in PASSGO_FAKE_MCP_TOOLS.
+func TestHelperMCPServer(t *testing.T) {
+	if os.Getenv("PASSGO_FAKE_MCP") != "1" {
+		t.Skip("helper process")
+	}
+	fmt.Fprintln(os.Stderr, "fake server starting")
+	names := []string{"echo", "crash"}
+	if path := os.Getenv("PASSGO_FAKE_MCP_TOOLS"); path != "" {
+		data, _ := os.ReadFile(path) // #nosec G304 -- test helper
+		names = strings.Split(strings.TrimSpace(string(data)), ",")
+	}
+	var tools []string
+	for _, name := range names {
+		tools = append(tools, fmt.Sprintf(`{"name":%q}`, name))
+	}
+	in := bufio.NewScanner(os.Stdin)
+	for in.Scan() {
+		var msg jsonRPCMessage
+		if json.Unmarshal(in.Bytes(), &msg) != nil || len(msg.ID) == 0 {
+			continue
+		}
+		switch msg.Method {
+		case "tools/list":
+			fmt.Printf(`{"jsonrpc":"2.0","id":%s,"result":{"tools":[%s]}}`+"\n", msg.ID, strings.Join(tools, ","))
+		case "tools/call":
+			if strings.Contains(string(msg.Params), "crash") {
+				os.Exit(3)
+			}
+			fmt.Println(toolText(msg.ID, "echo"))
+		case "ping":
+			fmt.Printf(`{"jsonrpc":"2.0","id":%s,"error":{"code":-32601,"message":"method not found"}}`+"\n", msg.ID)
+		default:
+			fmt.Printf(`{"jsonrpc":"2.0","id":%s,"result":{}}`+"\n", msg.ID)
+		}
+	}
+	os.Exit(0)
+}
+
+func TestMCPClientRestartsCrashedServer(t *testing.T) {
+	var logs bytes.Buffer
+	prev := appLogger
+	appLogger = log.New(&logs, "", 0)
+	defer func() { appLogger = prev }()
+
+	c, err := startMCPClient(os.Args[0], []string{"-test.run=^TestHelperMCPServer$"}, []string{"PASSGO_FAKE_MCP=1"})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if _, err := c.CallTool(context.Background(), "crash", nil); err == nil {
+		t.Fatalf("expected the call that crashed the server to fail")
+	}
+
+	// The next call waits for the restart instead of failing
+	out, err := c.CallTool(context.Background(), "echo", nil)
+	if err != nil || out != "echo" {
+		t.Fatalf("call after the crash got %q, %v", out, err)
+	}
+	if tools, err := c.ListTools(); err != nil || len(tools) != 2 {
+		t.Fatalf("tools not listed again after the restart: %v, %v", tools, err)
+	}
+	c.Close()
+
+	got := logs.String()
+	for _, want := range []string{"stderr: fake server starting", "exited: exit status 3, restarting", "restarted"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("log missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestMCPSupervisorGivesUpVisibly(t *testing.T) {
+	prevDelay := mcpRestartDelay
+	mcpRestartDelay = time.Millisecond
+	defer func() { mcpRestartDelay = prevDelay }()
+
+	c, err := startMCPClient(os.Args[0], []string{"-test.run=^TestHelperMCPServer$"}, []string{"PASSGO_FAKE_MCP=1"})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer c.Close()
+	router := newToolRouter(nil, nil, []*mcpServer{{name: "fake", client: c}})
+	down := make(chan string, 1)
+	router.SetDownHandler(func(server string, err error) { down <- server + ": " + err.Error() })
+
+	// Every restart fails once the binary is gone
+	c.mu.Lock()
+	c.command.command = "/nonexistent/mcp-server"
+	c.mu.Unlock()
+	if _, err := c.CallTool(context.Background(), "crash", nil); err == nil {
+		t.Fatalf("expected the call that crashed the server to fail")
+	}
+
+	select {
+	case got := <-down:
+		if !strings.HasPrefix(got, "fake: stopped and could not be restarted") {
+			t.Fatalf("unexpected down report %q", got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("supervisor did not report giving up")
+	}
+	if c.Down() == nil || router.Status() != "fake ✗" {
+		t.Fatalf("expected the server marked down, status %q", router.Status())
+	}
+}
+
+func TestChatMCPDownClearsReady(t *testing.T) {
+	m := newChatModel()
+	m.mcpReady = true
+	m, _ = m.Update(chatMCPDownMsg{server: "docs", err: errors.New("stopped and could not be restarted: boom")})
+	if m.mcpReady {
+		t.Fatalf("tools must be set up again after a server went down")
+	}
+	if last := m.entries[len(m.entries)-1]; last.role != "error" || !strings.Contains(last.content, "MCP server docs stopped") {
+		t.Fatalf("expected a chat line about the server, got %+v", last)
+	}
+}
+
+func TestMCPPingErrorReplyIsHealthy(t *testing.T) {
+	c, err := startMCPClient(os.Args[0], []string{"-test.run=^TestHelperMCPServer$"}, []string{"PASSGO_FAKE_MCP=1"})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	// The server answers ping with "method not found", which is still an answer
+	if _, err := c.callWithContext(context.Background(), "ping", nil); err == nil {
+		t.Fatalf("expected the helper to reject ping")
+	}
+	if err := c.ping(); err != nil {
+		t.Fatalf("an error reply should count as healthy, got %v", err)
+	}
+
+	// Without a connection the check fails
+	c.Close()
+	if err := c.ping(); err == nil {
+		t.Fatalf("expected ping to fail once the client is closed")
+	}
+}
+
+func TestToolRouterRelistsAfterRestart(t *testing.T) {
+	toolsFile := filepath.Join(t.TempDir(), "tools")
+	if err := os.WriteFile(toolsFile, []byte("echo,crash"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	servers := startMCPServers([]MCPServerConfig{{
+		Name:    "fake",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperMCPServer$"},
+		Env:     []string{"PASSGO_FAKE_MCP=1", "PASSGO_FAKE_MCP_TOOLS=" + toolsFile},
+	}})
+	router := newToolRouter(nil, nil, servers)
+	defer router.Close()
+	if n := len(router.Definitions()); n != 2 {
+		t.Fatalf("expected two tools at startup, got %d", n)
+	}
+
+	// The restarted server offers a different list
+	if err := os.WriteFile(toolsFile, []byte("echo,crash,search"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := router.CallTool(context.Background(), "fake__crash", nil); err == nil {
+		t.Fatalf("expected the call that crashed the server to fail")
+	}
+	if out, err := router.CallTool(context.Background(), "fake__search", nil); err != nil || out != "echo" {
+		t.Fatalf("tool added by the restarted server got %q, %v", out, err)
+	}
+	var names []string
+	for _, tool := range router.Definitions() {
+		names = append(names, tool.Function.Name)
+	}
+	if strings.Join(names, ",") != "fake__echo,fake__crash,fake__search" {
+		t.Fatalf("definitions not rebuilt after the restart: %v", names)
+	}
+}
+
+func TestMCPStderrLogSplitsLines(t *testing.T) {
+	var logs bytes.Buffer
+	prev := appLogger
+	appLogger = log.New(&logs, "", 0)
+	defer func() { appLogger = prev }()
+
+	w := &mcpStderrLog{server: "docs-mcp"}
+	fmt.Fprint(w, "first\r\nsec")
+	fmt.Fprint(w, "ond\n\n")
+	if got := logs.String(); got != "MCP docs-mcp stderr: first\nMCP docs-mcp stderr: second\n" {
+		t.Fatalf("unexpected log %q", got)
+	}
+}
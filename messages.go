@@ -2,8 +2,11 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -23,7 +26,8 @@ type vmOperationResultMsg struct {
 	vmName    string
 	operation string
 	err       error
-	inline    bool // true when the operation was inline (stay on table)
+	inline    bool   // true when the operation was inline (stay on table)
+	warning   string // optional follow-up warning toast on success
 }
 
 // vmInfoResultMsg carries raw info output for a single VM.
@@ -47,6 +51,33 @@ type mountListResultMsg struct {
 	err    error
 }
 
+// mountReconcileResultMsg carries the outcome of reapplying a VM's mount profile.
+type mountReconcileResultMsg struct {
+	vmName  string
+	mounted []string // target paths that were mounted
+	err     error
+	auto    bool // true when triggered by the VM reaching Running (stay on current view)
+}
+
+// mountHealthResultMsg carries health check results for a VM's mounts.
+type mountHealthResultMsg struct {
+	vmName string
+	health map[string]mountHealth // keyed by target path
+}
+
+// bulkMountResultMsg carries per-VM results of mounting one directory into several VMs.
+type bulkMountResultMsg struct {
+	mount   MountProfile
+	results []bulkMountResult
+	warning string // non-empty when the source is now shared read-write by several VMs
+}
+
+// auditLogLoadedMsg carries the agent audit log, newest first.
+type auditLogLoadedMsg struct {
+	entries []auditEntry
+	err     error
+}
+
 // shellFinishedMsg is sent when an interactive shell exits.
 type shellFinishedMsg struct{ err error }
 
@@ -97,6 +128,13 @@ func doFetchVMList() ([]vmData, error) {
 }
 
 // fetchVMListCmd fetches the full VM list with details.
+func loadAuditLogCmd() tea.Cmd {
+	return func() tea.Msg {
+		entries, err := loadAuditEntries()
+		return auditLogLoadedMsg{entries: entries, err: err}
+	}
+}
+
 func fetchVMListCmd() tea.Cmd {
 	return func() tea.Msg {
 		vms, err := doFetchVMList()
@@ -255,22 +293,135 @@ func fetchMountsCmd(vmName string) tea.Cmd {
 	}
 }
 
-// mountCmd mounts a local directory to a VM.
-func mountCmd(source, vmName, target string) tea.Cmd {
+// mountCmd mounts a local directory to a VM and records it in the VM's mount profile.
+func mountCmd(vmName string, mount MountProfile) tea.Cmd {
 	return func() tea.Msg {
-		_, err := runMultipassCommand("mount", source, vmName+":"+target)
-		return vmOperationResultMsg{vmName: vmName, operation: "mount", err: err}
+		_, err := runMultipassCommand(buildMountArgs(vmName, mount)...)
+		if err != nil {
+			return vmOperationResultMsg{vmName: vmName, operation: "mount", err: err}
+		}
+		logMountProfileError(rememberMount(vmName, mount))
+		return vmOperationResultMsg{vmName: vmName, operation: "mount", warning: sharedMountWarning(mount.Source)}
 	}
 }
 
-// umountCmd unmounts a directory from a VM.
+// umountCmd unmounts a directory from a VM and drops it from the VM's mount profile.
 func umountCmd(vmName, target string) tea.Cmd {
 	return func() tea.Msg {
 		_, err := runMultipassCommand("umount", vmName+":"+target)
+		if err == nil {
+			logMountProfileError(forgetMount(vmName, target))
+		}
 		return vmOperationResultMsg{vmName: vmName, operation: "umount", err: err}
 	}
 }
 
+// bulkMountCmd mounts one directory into several VMs and records each success in its profile.
+func bulkMountCmd(vmNames []string, mount MountProfile) tea.Cmd {
+	return func() tea.Msg {
+		results := runBulkMount(runMultipassCommand, vmNames, mount)
+		for _, r := range results {
+			if r.err == nil {
+				logMountProfileError(rememberMount(r.vmName, mount))
+			}
+		}
+		return bulkMountResultMsg{mount: mount, results: results, warning: sharedMountWarning(mount.Source)}
+	}
+}
+
+// remountCmd unmounts and mounts a path again, e.g. after the sshfs server in the VM died.
+// The unmount error is ignored: a stale mount may already be half gone.
+func remountCmd(vmName string, mount MountInfo) tea.Cmd {
+	return func() tea.Msg {
+		_, _ = runMultipassCommand("umount", vmName+":"+mount.TargetPath)
+		_, err := runMultipassCommand(buildMountArgs(vmName, profileForMount(vmName, mount))...)
+		return vmOperationResultMsg{vmName: vmName, operation: "remount", err: err}
+	}
+}
+
+// checkMountHealthCmd checks every mount's host source and VM target at once,
+// each multipass call bounded by mountHealthTimeout.
+func checkMountHealthCmd(vmName string, mounts []MountInfo) tea.Cmd {
+	return func() tea.Msg {
+		execInVM := func(vmName string, args ...string) (string, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), mountHealthTimeout)
+			defer cancel()
+			return runMultipassCommandContext(ctx, append([]string{"exec", vmName, "--"}, args...)...)
+		}
+
+		// An unknown state still checks the targets; the stat fails if the VM is down
+		state := "Running"
+		ctx, cancel := context.WithTimeout(context.Background(), mountHealthTimeout)
+		if out, err := runMultipassCommandContext(ctx, "info", vmName); err == nil {
+			if s := parseVMInfo(out).State; s != "" {
+				state = s
+			}
+		}
+		cancel()
+
+		health := make(map[string]mountHealth, len(mounts))
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, mount := range mounts {
+			wg.Add(1)
+			go func(mount MountInfo) {
+				defer wg.Done()
+				h := checkMountHealth(vmName, state, mount, os.Stat, execInVM)
+				mu.Lock()
+				health[mount.TargetPath] = h
+				mu.Unlock()
+			}(mount)
+		}
+		wg.Wait()
+		return mountHealthResultMsg{vmName: vmName, health: health}
+	}
+}
+
+// reconcileMountsCmd mounts any saved profile entries that are missing from the VM.
+func reconcileMountsCmd(vmName string, auto bool) tea.Cmd {
+	return func() tea.Msg {
+		desired := mountProfilesFor(vmName)
+		if len(desired) == 0 {
+			return mountReconcileResultMsg{vmName: vmName, auto: auto}
+		}
+		current, err := getVMMounts(vmName)
+		if err != nil {
+			return mountReconcileResultMsg{vmName: vmName, err: err, auto: auto}
+		}
+		mounted, err := reconcileMounts(runMultipassCommand, vmName, desired, current)
+		return mountReconcileResultMsg{vmName: vmName, mounted: mounted, err: err, auto: auto}
+	}
+}
+
+// autoReconcileMountsCmd reconciles mount profiles for VMs that just reached Running.
+// Returns nil when auto-reconcile is disabled or none of the VMs have a profile.
+func autoReconcileMountsCmd(vmNames []string) tea.Cmd {
+	if len(vmNames) == 0 {
+		return nil
+	}
+	mountProfilesMu.Lock()
+	profiles, err := loadMountProfiles()
+	mountProfilesMu.Unlock()
+	if err != nil || !profiles.AutoReconcile {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for _, name := range vmNames {
+		if len(profiles.VMs[name]) > 0 {
+			cmds = append(cmds, reconcileMountsCmd(name, true))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// logMountProfileError logs a failure to persist a mount profile change.
+// The mount itself succeeded, so this is not surfaced as an operation error.
+func logMountProfileError(err error) {
+	if err != nil && appLogger != nil {
+		appLogger.Printf("failed to update mount profiles: %v", err)
+	}
+}
+
 func runBulkVMOperation(opName string, names []string, operation func(string) (string, error)) error {
 	var opErrs []error
 	for _, name := range names {
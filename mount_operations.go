@@ -1,10 +1,17 @@
-// mount_operations.go - Mount data helpers (JSON parsing, no UI code)
+// mount_operations.go - Mount data helpers (JSON parsing, profile reconcile, no UI code)
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // MountInfo represents a mount point between local filesystem and VM.
@@ -34,7 +41,12 @@ type multipassMountDetail struct {
 
 // getVMMounts retrieves the current mounts for a VM using JSON output.
 func getVMMounts(vmName string) ([]MountInfo, error) {
-	output, err := runMultipassCommand("info", vmName, "--format", "json")
+	return getVMMountsContext(context.Background(), vmName)
+}
+
+// getVMMountsContext is getVMMounts that stops when ctx is cancelled.
+func getVMMountsContext(ctx context.Context, vmName string) ([]MountInfo, error) {
+	output, err := runMultipassCommandContext(ctx, "info", vmName, "--format", "json")
 	if err != nil {
 		return nil, err
 	}
@@ -65,3 +77,224 @@ func getVMMounts(vmName string) ([]MountInfo, error) {
 
 	return mounts, nil
 }
+
+// missingMounts returns the profile entries that are not currently mounted.
+// A profile entry counts as present when a mount with the same target exists.
+func missingMounts(desired []MountProfile, current []MountInfo) []MountProfile {
+	mounted := make(map[string]bool, len(current))
+	for _, m := range current {
+		mounted[m.TargetPath] = true
+	}
+	var missing []MountProfile
+	for _, d := range desired {
+		if !mounted[d.Target] {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
+
+// buildMountArgs builds the multipass mount arguments for a profile entry.
+func buildMountArgs(vmName string, mount MountProfile) []string {
+	args := []string{"mount"}
+	args = append(args, mountMapArgs("--uid-map", mount.UIDMaps)...)
+	args = append(args, mountMapArgs("--gid-map", mount.GIDMaps)...)
+	if mount.Type != "" {
+		args = append(args, "--type", mount.Type)
+	}
+	return append(args, mount.Source, vmName+":"+mount.Target)
+}
+
+func mountMapArgs(flag string, maps []string) []string {
+	var args []string
+	for _, m := range maps {
+		host, instance, ok := strings.Cut(m, ":")
+		if !ok || host == "" || instance == "" {
+			continue
+		}
+		args = append(args, flag, host+":"+resolveMountInstanceID(instance))
+	}
+	return args
+}
+
+// resolveMountInstanceID turns the "default" that multipass info reports for
+// the instance side of a mapping into the default user's ID, which
+// multipass mount accepts.
+func resolveMountInstanceID(instance string) string {
+	if instance == "default" {
+		return strconv.Itoa(DefaultMountInstanceID)
+	}
+	return instance
+}
+
+// parseMountMaps parses a comma-separated list of "host:instance" ID mappings.
+// The instance side may be "default", as reported by multipass info; it is
+// stored as the default user's ID.
+func parseMountMaps(raw string) ([]string, error) {
+	var maps []string
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		host, instance, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("%q is not in host:instance form", part)
+		}
+		if _, err := strconv.Atoi(host); err != nil {
+			return nil, fmt.Errorf("%q: host ID must be a number", part)
+		}
+		instance = resolveMountInstanceID(instance)
+		if _, err := strconv.Atoi(instance); err != nil {
+			return nil, fmt.Errorf("%q: instance ID must be a number", part)
+		}
+		maps = append(maps, host+":"+instance)
+	}
+	return maps, nil
+}
+
+// formatMountMaps renders ID mappings for editing in a text field.
+func formatMountMaps(maps []string) string {
+	return strings.Join(maps, ", ")
+}
+
+// defaultMountIDMap maps a host uid/gid to the default VM user.
+// Returns nil when the host has no numeric IDs (Windows reports -1).
+func defaultMountIDMap(hostID int) []string {
+	if hostID < 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%d:%d", hostID, DefaultMountInstanceID)}
+}
+
+// reconcileMounts mounts every profile entry missing from current.
+// Returns the target paths that were mounted; failures are joined into err.
+func reconcileMounts(runCmd func(args ...string) (string, error), vmName string, desired []MountProfile, current []MountInfo) ([]string, error) {
+	var mounted []string
+	var errs []error
+	for _, mount := range missingMounts(desired, current) {
+		if _, err := runCmd(buildMountArgs(vmName, mount)...); err != nil {
+			errs = append(errs, fmt.Errorf("mount %s to %s:%s: %w", mount.Source, vmName, mount.Target, err))
+			continue
+		}
+		mounted = append(mounted, mount.Target)
+	}
+	return mounted, errors.Join(errs...)
+}
+
+// runningTransitions returns the names of VMs that are Running in next but were
+// not Running in prev. A VM absent from prev counts, since it may have been
+// purged and launched again under the same name; callers skip the initial load
+// so it does not trigger work for every VM.
+func runningTransitions(prev, next []vmData) []string {
+	prevState := make(map[string]string, len(prev))
+	for _, vm := range prev {
+		prevState[vm.info.Name] = vm.info.State
+	}
+	var names []string
+	for _, vm := range next {
+		if prevState[vm.info.Name] != "Running" && vm.info.State == "Running" {
+			names = append(names, vm.info.Name)
+		}
+	}
+	return names
+}
+
+// mountHealthTimeout bounds each command of a health check; a stat on a dead
+// sshfs mount can otherwise hang forever.
+const mountHealthTimeout = 10 * time.Second
+
+// mountHealth is the result of checking one mount on both sides.
+type mountHealth struct {
+	sourceOK bool   // source directory exists on the host
+	targetOK bool   // target path is reachable inside the VM
+	vmDown   bool   // the VM is not running, so the target was not checked
+	detail   string // first failure reason, empty when healthy
+}
+
+func (h mountHealth) healthy() bool { return h.sourceOK && h.targetOK && !h.vmDown }
+
+// label returns a short status for the mount manager table.
+func (h mountHealth) label() string {
+	switch {
+	case !h.sourceOK:
+		return "✗ source"
+	case h.vmDown:
+		return "VM not running"
+	case !h.targetOK:
+		return "✗ target"
+	default:
+		return "✓ ok"
+	}
+}
+
+// checkMountHealth checks that the host source is an existing directory and
+// that the target can be stat'ed inside the VM (catches dead sshfs servers).
+// The target is only checked when vmState is Running.
+func checkMountHealth(vmName, vmState string, mount MountInfo,
+	statHost func(string) (os.FileInfo, error),
+	execInVM func(vmName string, args ...string) (string, error)) mountHealth {
+
+	h := mountHealth{sourceOK: true, targetOK: true}
+	if fi, err := statHost(mount.SourcePath); err != nil {
+		h.sourceOK = false
+		h.detail = fmt.Sprintf("source %s: %v", mount.SourcePath, err)
+	} else if !fi.IsDir() {
+		h.sourceOK = false
+		h.detail = fmt.Sprintf("source %s is not a directory", mount.SourcePath)
+	}
+
+	if vmState != "Running" {
+		h.vmDown = true
+		if h.detail == "" {
+			h.detail = fmt.Sprintf("%s is %s; start it to check the target", vmName, strings.ToLower(vmState))
+		}
+		return h
+	}
+
+	if _, err := execInVM(vmName, "stat", mount.TargetPath); err != nil {
+		h.targetOK = false
+		if h.detail == "" {
+			if errors.Is(err, context.DeadlineExceeded) {
+				h.detail = fmt.Sprintf("target %s in %s did not respond within %s", mount.TargetPath, vmName, mountHealthTimeout)
+			} else {
+				h.detail = fmt.Sprintf("target %s unreachable in %s", mount.TargetPath, vmName)
+			}
+		}
+	}
+	return h
+}
+
+// bulkMountResult is the outcome of mounting into one VM of a bulk mount.
+type bulkMountResult struct {
+	vmName string
+	err    error
+}
+
+// runBulkMount mounts the same source and target into each VM in order.
+func runBulkMount(runCmd func(args ...string) (string, error), vmNames []string, mount MountProfile) []bulkMountResult {
+	results := make([]bulkMountResult, 0, len(vmNames))
+	for _, name := range vmNames {
+		_, err := runCmd(buildMountArgs(name, mount)...)
+		results = append(results, bulkMountResult{vmName: name, err: err})
+	}
+	return results
+}
+
+// vmsSharingSource returns the VMs whose profile mounts the given host directory,
+// sorted by name. Multipass mounts are read-write, so more than one entry means
+// several VMs can write to the same host files.
+func vmsSharingSource(profiles MountProfiles, source string) []string {
+	source = filepath.Clean(source)
+	var names []string
+	for vmName, mounts := range profiles.VMs {
+		for _, mount := range mounts {
+			if filepath.Clean(mount.Source) == source {
+				names = append(names, vmName)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMissingMounts(t *testing.T) {
+	desired := []MountProfile{
+		{Source: "/src/a", Target: "/home/ubuntu/a"},
+		{Source: "/src/b", Target: "/home/ubuntu/b"},
+		{Source: "/src/c", Target: "/home/ubuntu/c"},
+	}
+	current := []MountInfo{
+		{SourcePath: "/src/b", TargetPath: "/home/ubuntu/b"},
+	}
+
+	got := missingMounts(desired, current)
+	if len(got) != 2 || got[0].Target != "/home/ubuntu/a" || got[1].Target != "/home/ubuntu/c" {
+		t.Fatalf("unexpected missing mounts: %+v", got)
+	}
+
+	if got := missingMounts(desired[1:2], current); len(got) != 0 {
+		t.Fatalf("expected nothing missing, got %+v", got)
+	}
+}
+
+func TestBuildMountArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		mount MountProfile
+		want  []string
+	}{
+		{
+			name:  "plain mount",
+			mount: MountProfile{Source: "/src", Target: "/dst"},
+			want:  []string{"mount", "/src", "vm1:/dst"},
+		},
+		{
+			name:  "uid and gid maps",
+			mount: MountProfile{Source: "/src", Target: "/dst", UIDMaps: []string{"501:1000"}, GIDMaps: []string{"20:1000"}},
+			want:  []string{"mount", "--uid-map", "501:1000", "--gid-map", "20:1000", "/src", "vm1:/dst"},
+		},
+		{
+			name:  "native mount type",
+			mount: MountProfile{Source: "/src", Target: "/dst", Type: "native"},
+			want:  []string{"mount", "--type", "native", "/src", "vm1:/dst"},
+		},
+		{
+			name:  "default mappings use the default user's ID",
+			mount: MountProfile{Source: "/src", Target: "/dst", UIDMaps: []string{"501:default"}, GIDMaps: []string{"bogus"}},
+			want:  []string{"mount", "--uid-map", "501:1000", "/src", "vm1:/dst"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildMountArgs("vm1", tt.mount)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMountMaps(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{raw: "", want: nil},
+		{raw: "501:1000", want: []string{"501:1000"}},
+		{raw: " 501:1000 , 20:1000,", want: []string{"501:1000", "20:1000"}},
+		{raw: "501:default", want: []string{"501:1000"}},
+		{raw: "501", wantErr: true},
+		{raw: "abc:1000", wantErr: true},
+		{raw: "501:ubuntu", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMountMaps(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("parseMountMaps(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("parseMountMaps(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultMountIDMap(t *testing.T) {
+	if got := defaultMountIDMap(501); !reflect.DeepEqual(got, []string{"501:1000"}) {
+		t.Fatalf("unexpected default map: %v", got)
+	}
+	if got := defaultMountIDMap(-1); got != nil {
+		t.Fatalf("expected no map for hosts without numeric IDs, got %v", got)
+	}
+}
+
+func TestReconcileMounts(t *testing.T) {
+	desired := []MountProfile{
+		{Source: "/src/a", Target: "/a"},
+		{Source: "/src/b", Target: "/b"},
+		{Source: "/src/c", Target: "/c"},
+	}
+	current := []MountInfo{{SourcePath: "/src/a", TargetPath: "/a"}}
+
+	var calls [][]string
+	runCmd := func(args ...string) (string, error) {
+		calls = append(calls, append([]string(nil), args...))
+		if args[len(args)-1] == "vm1:/c" {
+			return "", errors.New("boom")
+		}
+		return "", nil
+	}
+
+	mounted, err := reconcileMounts(runCmd, "vm1", desired, current)
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 mount calls, got %d", len(calls))
+	}
+	if !reflect.DeepEqual(mounted, []string{"/b"}) {
+		t.Fatalf("unexpected mounted targets: %v", mounted)
+	}
+	if err == nil || !strings.Contains(err.Error(), "vm1:/c") {
+		t.Fatalf("expected error mentioning failed target, got %v", err)
+	}
+}
+
+func TestRunningTransitions(t *testing.T) {
+	prev := []vmData{
+		{info: VMInfo{Name: "vm1", State: "Stopped"}},
+		{info: VMInfo{Name: "vm2", State: "Running"}},
+		{info: VMInfo{Name: "vm3", State: "Creating"}},
+	}
+	next := []vmData{
+		{info: VMInfo{Name: "vm1", State: "Running"}},
+		{info: VMInfo{Name: "vm2", State: "Running"}},
+		{info: VMInfo{Name: "vm3", State: "Running"}},
+		{info: VMInfo{Name: "vm4", State: "Running"}},
+	}
+
+	got := runningTransitions(prev, next)
+	// vm4 is new, e.g. purged and launched again under the same name
+	if !reflect.DeepEqual(got, []string{"vm1", "vm3", "vm4"}) {
+		t.Fatalf("unexpected transitions: %v", got)
+	}
+}
+
+func TestMountProfilesRememberAndForget(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if err := rememberMount("vm1", MountProfile{Source: "/src/a", Target: "/a"}); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	if err := rememberMount("vm1", MountProfile{Source: "/src/b", Target: "/b"}); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	// Same target replaces the existing entry
+	if err := rememberMount("vm1", MountProfile{Source: "/src/a2", Target: "/a"}); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+
+	got := mountProfilesFor("vm1")
+	if len(got) != 2 || got[0].Source != "/src/a2" || got[1].Target != "/b" {
+		t.Fatalf("unexpected profile: %+v", got)
+	}
+
+	if err := forgetMount("vm1", "/a"); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	if err := forgetMount("vm1", "/b"); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	profiles, err := loadMountProfiles()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, ok := profiles.VMs["vm1"]; ok {
+		t.Fatalf("expected empty profile to be removed, got %+v", profiles.VMs)
+	}
+	if !profiles.AutoReconcile {
+		t.Fatalf("expected auto-reconcile to default to true")
+	}
+}
+
+func TestCheckMountHealth(t *testing.T) {
+	dir := t.TempDir()
+	file := dir + "/file"
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	execOK := func(string, ...string) (string, error) { return "", nil }
+	execFail := func(string, ...string) (string, error) {
+		return "", errors.New("Transport endpoint is not connected")
+	}
+
+	tests := []struct {
+		name      string
+		source    string
+		state     string
+		exec      func(string, ...string) (string, error)
+		wantLabel string
+	}{
+		{"healthy", dir, "Running", execOK, "✓ ok"},
+		{"source missing", dir + "/gone", "Running", execOK, "✗ source"},
+		{"source is a file", file, "Running", execOK, "✗ source"},
+		{"target unreachable", dir, "Running", execFail, "✗ target"},
+		{"vm stopped", dir, "Stopped", execFail, "VM not running"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := checkMountHealth("vm1", tt.state, MountInfo{SourcePath: tt.source, TargetPath: "/mnt"}, os.Stat, tt.exec)
+			if h.label() != tt.wantLabel {
+				t.Fatalf("label = %q, want %q (detail %q)", h.label(), tt.wantLabel, h.detail)
+			}
+			if h.healthy() != (tt.wantLabel == "✓ ok") {
+				t.Fatalf("healthy() mismatch for %q", tt.name)
+			}
+		})
+	}
+}
+
+func TestCheckMountHealthTimeout(t *testing.T) {
+	hang := func(string, ...string) (string, error) {
+		return "", fmt.Errorf("multipass exec: %w", context.DeadlineExceeded)
+	}
+	h := checkMountHealth("vm1", "Running", MountInfo{SourcePath: t.TempDir(), TargetPath: "/mnt"}, os.Stat, hang)
+	if h.label() != "✗ target" || !strings.Contains(h.detail, "did not respond") {
+		t.Fatalf("unexpected result for a hung stat: %q, %q", h.label(), h.detail)
+	}
+}
+
+func TestRunBulkMount(t *testing.T) {
+	runCmd := func(args ...string) (string, error) {
+		if args[len(args)-1] == "vm2:/dst" {
+			return "", errors.New("instance vm2 is not running")
+		}
+		return "", nil
+	}
+
+	results := runBulkMount(runCmd, []string{"vm1", "vm2", "vm3"}, MountProfile{Source: "/src", Target: "/dst"})
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(results))
+	}
+	for i, want := range []bool{false, true, false} {
+		if (results[i].err != nil) != want {
+			t.Fatalf("result %d (%s) err = %v", i, results[i].vmName, results[i].err)
+		}
+	}
+	if !bulkMountFailed(results) {
+		t.Fatalf("expected bulk mount to report a failure")
+	}
+	if got := bulkMountToastMessage(results); got != "✗ Mounted into 2 of 3 VMs" {
+		t.Fatalf("unexpected toast: %q", got)
+	}
+}
+
+func TestVMsSharingSource(t *testing.T) {
+	profiles := MountProfiles{VMs: map[string][]MountProfile{
+		"vm2": {{Source: "/src/shared/", Target: "/a"}},
+		"vm1": {{Source: "/src/shared", Target: "/b"}, {Source: "/src/shared", Target: "/c"}},
+		"vm3": {{Source: "/src/other", Target: "/a"}},
+	}}
+
+	got := vmsSharingSource(profiles, "/src/shared")
+	if !reflect.DeepEqual(got, []string{"vm1", "vm2"}) {
+		t.Fatalf("unexpected sharing VMs: %v", got)
+	}
+}
+
+func TestBuildMountTargetGroups(t *testing.T) {
+	tm := newTableModel()
+	tm.vms = []vmData{
+		{info: VMInfo{Name: "web-1", State: "Running"}},
+		{info: VMInfo{Name: "web-2", State: "Running"}},
+		{info: VMInfo{Name: "db", State: "Running"}},
+		{info: VMInfo{Name: "web-3", State: "Stopped"}},
+	}
+	tm.filterText = "web"
+	tm.filteredVMs = []vmData{tm.vms[0], tm.vms[1], tm.vms[3]}
+	tm.marked = map[string]bool{"db": true, "web-3": true}
+
+	groups := buildMountTargetGroups("web-1", tm)
+	want := []mountTargetGroup{
+		{label: "web-1", vms: []string{"web-1"}},
+		{label: "All running (3)", vms: []string{"web-1", "web-2", "db"}},
+		{label: `Filter "web" (2)`, vms: []string{"web-1", "web-2"}},
+		{label: "Marked (1)", vms: []string{"db"}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Fatalf("got %+v\nwant %+v", groups, want)
+	}
+}
+
+func TestSetVMsPrunesMarks(t *testing.T) {
+	tm := newTableModel()
+	tm.marked = map[string]bool{"web-1": true, "gone": true}
+	tm.setVMs([]vmData{{info: VMInfo{Name: "web-1"}}, {info: VMInfo{Name: "db"}}})
+	if !reflect.DeepEqual(tm.marked, map[string]bool{"web-1": true}) {
+		t.Fatalf("marks of deleted VMs must be dropped, got %v", tm.marked)
+	}
+}
+
+func TestMountFormDefaultType(t *testing.T) {
+	f := newMountFormFields(MountProfile{Source: "/src", Target: "/dst"})
+	got, err := f.profile()
+	if err != nil || got.Type != "" {
+		t.Fatalf("a new mount should leave the type to multipass, got %q, %v", got.Type, err)
+	}
+
+	f = newMountFormFields(MountProfile{Source: "/src", Target: "/dst", Type: "native"})
+	if got, _ := f.profile(); got.Type != "native" {
+		t.Fatalf("existing type not kept, got %q", got.Type)
+	}
+}
@@ -4,6 +4,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,7 +16,12 @@ import (
 
 // runMultipassCommand executes multipass commands with variadic arguments
 func runMultipassCommand(args ...string) (string, error) {
-	cmd := exec.Command("multipass", args...) // #nosec G204 -- multipass CLI wrapper
+	return runMultipassCommandContext(context.Background(), args...)
+}
+
+// runMultipassCommandContext is runMultipassCommand that kills the process when ctx is cancelled.
+func runMultipassCommandContext(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "multipass", args...) // #nosec G204 -- multipass CLI wrapper
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -27,6 +33,9 @@ func runMultipassCommand(args ...string) (string, error) {
 		if appLogger != nil {
 			appLogger.Printf("exec error: %v; stderr: %s", err, strings.TrimSpace(stderr.String()))
 		}
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("multipass %s: %w", args[0], ctx.Err())
+		}
 		return "", fmt.Errorf("command failed: %v\nStderr: %s", err, stderr.String())
 	}
 	return strings.TrimSpace(stdout.String()), nil
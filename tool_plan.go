@@ -0,0 +1,98 @@
+// tool_plan.go - Plan mode: mutating tool calls are collected into a plan instead of run
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// plannedToolResult is the simulated result of a call intercepted in plan mode.
+const plannedToolResult = "Not executed (plan mode): the call was added to a plan the user will review. " +
+	"Assume it will succeed, continue planning, and do not retry it."
+
+// plannedCall is a mutating or destructive tool call recorded in plan mode.
+type plannedCall struct {
+	call  ToolCall
+	args  map[string]interface{}
+	risk  toolRisk
+	model string // the model that chose the call, for the audit log
+}
+
+// toolPlan collects the calls intercepted during one plan-mode run. It is only
+// touched by the agent goroutine until the run's result is delivered.
+type toolPlan struct {
+	calls []plannedCall
+}
+
+type toolPlanKey struct{}
+
+// withToolPlan puts ctx in plan mode: RunAgent records mutating calls in plan
+// instead of executing them.
+func withToolPlan(ctx context.Context, plan *toolPlan) context.Context {
+	return context.WithValue(ctx, toolPlanKey{}, plan)
+}
+
+// toolPlanFrom returns the plan of a plan-mode run, nil otherwise.
+func toolPlanFrom(ctx context.Context) *toolPlan {
+	plan, _ := ctx.Value(toolPlanKey{}).(*toolPlan)
+	return plan
+}
+
+// formatPlan lists the planned calls, numbered, for the chat log.
+func formatPlan(calls []plannedCall) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Plan (%d steps, nothing executed yet):", len(calls))
+	for i, pc := range calls {
+		fmt.Fprintf(&b, "\n  %d. %s [%s]", i+1, toolCallLabel(pc.call.Function.Name, pc.call.Function.Arguments), pc.risk)
+	}
+	b.WriteString("\n/plan run executes it, /plan discard drops it.")
+	return b.String()
+}
+
+// executePlanCmd runs the planned calls in order through executor. The user
+// approved the plan as a whole, so calls are not confirmed one by one. The
+// first failure stops the plan, since later steps usually depend on earlier ones.
+func executePlanCmd(ctx context.Context, program *tea.Program, executor ToolExecutor, calls []plannedCall) tea.Cmd {
+	return func() tea.Msg {
+		var results []string
+		var err error
+		for i, pc := range calls {
+			if ctx.Err() != nil {
+				err = fmt.Errorf("%w: %w", errAgentCancelled, ctx.Err())
+				break
+			}
+			started := time.Now()
+			notifyProgram(program, chatToolStartMsg{name: pc.call.Function.Name, args: pc.call.Function.Arguments})
+			output, callErr := executor.CallTool(ctx, pc.call.Function.Name, pc.args)
+			auditToolCall(withAuditModel(ctx, pc.model), pc.call.Function.Name, pc.args, output, callErr, started)
+			notifyProgram(program, chatToolDoneMsg{name: pc.call.Function.Name, args: pc.call.Function.Arguments, result: output, err: callErr})
+			if callErr != nil {
+				results = append(results, "Error: "+callErr.Error())
+				err = fmt.Errorf("step %d (%s) failed: %w", i+1, pc.call.Function.Name, callErr)
+				break
+			}
+			results = append(results, output)
+		}
+		return chatPlanDoneMsg{calls: calls, results: results, err: err}
+	}
+}
+
+// planSummary describes an executed plan for the conversation history, so the
+// model knows which of the calls it planned actually ran.
+func planSummary(calls []plannedCall, results []string) string {
+	var b strings.Builder
+	b.WriteString("The user executed the plan.")
+	for i, pc := range calls {
+		call := fmt.Sprintf("%s %s", pc.call.Function.Name, pc.call.Function.Arguments)
+		if i < len(results) {
+			fmt.Fprintf(&b, "\nExecuted: %s → %s", call, truncate(results[i], 80))
+		} else {
+			fmt.Fprintf(&b, "\nNot executed: %s", call)
+		}
+	}
+	return b.String()
+}
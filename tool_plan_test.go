@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunAgentPlanModeInterceptsMutatingCalls(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // tool calls are audited
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if requests.Add(1) == 1 {
+			calls := []ToolCall{
+				{ID: "id0", Type: "function", Function: FunctionCall{Name: "get_instance_info", Arguments: `{"name":"web"}`}},
+				{ID: "id1", Type: "function", Function: FunctionCall{Name: "stop_instance", Arguments: `{"name":"web"}`}},
+				{ID: "id2", Type: "function", Function: FunctionCall{Name: "delete_instance", Arguments: `{"name":"web"}`}},
+			}
+			resp, _ := json.Marshal(map[string]interface{}{
+				"choices": []interface{}{map[string]interface{}{"message": ChatMessage{Role: "assistant", ToolCalls: calls}}},
+			})
+			w.Write(resp)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"planned"}}]}`)
+	}))
+	defer srv.Close()
+
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m"})
+	var tools []ToolDef
+	for _, n := range []string{"get_instance_info", "stop_instance", "delete_instance"} {
+		tools = append(tools, ToolDef{Type: "function", Function: ToolDefFunction{Name: n}})
+	}
+	exec := &fakeExecutor{}
+	ctx := withToolPlan(context.Background(), &toolPlan{})
+	res := RunAgent(ctx, nil, client, exec, []ChatMessage{{Role: "system"}, {Role: "user", Content: "retire web"}}, tools)
+	if res.Err != nil || res.Response != "planned" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if len(exec.order) != 1 || exec.order[0] != "get_instance_info web" {
+		t.Fatalf("only the read-only call should run, ran %v", exec.order)
+	}
+	if len(res.Plan) != 2 || res.Plan[0].call.Function.Name != "stop_instance" || res.Plan[1].risk != toolDestructive {
+		t.Fatalf("unexpected plan %+v", res.Plan)
+	}
+	if res.Messages[2].Content != plannedToolResult || res.Messages[3].Content != plannedToolResult {
+		t.Fatalf("planned calls should get the simulated result, got %+v", res.Messages[2:4])
+	}
+}
+
+// failingExecutor fails every call to the named tool.
+type failingExecutor struct {
+	fakeExecutor
+	fail string
+}
+
+func (f *failingExecutor) CallTool(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+	if name == f.fail {
+		return "", fmt.Errorf("boom")
+	}
+	return f.fakeExecutor.CallTool(ctx, name, args)
+}
+
+func TestExecutePlanStopsAtFirstFailure(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	plan := []plannedCall{
+		{call: ToolCall{Function: FunctionCall{Name: "stop_instance"}}, args: map[string]interface{}{"name": "a"}},
+		{call: ToolCall{Function: FunctionCall{Name: "create_snapshot"}}, args: map[string]interface{}{"name": "a"}},
+		{call: ToolCall{Function: FunctionCall{Name: "start_instance"}}, args: map[string]interface{}{"name": "a"}},
+	}
+	exec := &failingExecutor{fail: "create_snapshot"}
+	msg := executePlanCmd(context.Background(), nil, exec, plan)().(chatPlanDoneMsg)
+	if msg.err == nil || len(msg.results) != 2 || len(exec.order) != 1 {
+		t.Fatalf("expected a stop at step 2, got %+v (ran %v)", msg, exec.order)
+	}
+	summary := planSummary(msg.calls, msg.results)
+	if !strings.Contains(summary, "Executed: create_snapshot") || !strings.Contains(summary, "Not executed: start_instance") {
+		t.Fatalf("unexpected summary:\n%s", summary)
+	}
+}
+
+func TestChatPlanCommands(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	m := newChatModel()
+	m.focused = true
+
+	m, _ = sendChatCommand(m, "/plan")
+	if !m.planMode || !strings.Contains(m.chatTitleText(), "plan mode") {
+		t.Fatalf("expected plan mode on, title %q", m.chatTitleText())
+	}
+
+	calls := []plannedCall{{call: ToolCall{Function: FunctionCall{Name: "stop_instance", Arguments: `{"name":"a"}`}}, risk: toolMutating}}
+	m, _ = m.Update(chatAgentResultMsg{response: "ok", plan: calls})
+	if len(m.plan) != 1 || !strings.Contains(m.entries[len(m.entries)-1].content, "1. stop_instance") {
+		t.Fatalf("expected the plan to be listed, entries %+v", m.entries)
+	}
+
+	m, _ = sendChatCommand(m, "/plan discard")
+	if len(m.plan) != 0 || !strings.Contains(m.messages[len(m.messages)-1].Content, "discarded") {
+		t.Fatalf("expected the plan to be discarded")
+	}
+	if _, cmd := sendChatCommand(m, "/plan run"); cmd != nil {
+		t.Fatalf("running an empty plan should do nothing")
+	}
+}
@@ -0,0 +1,122 @@
+// tool_policy.go - Tool risk classification and the approval gate for agent tool calls
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// toolRisk classifies what a tool call can do to the user's machines.
+type toolRisk int
+
+const (
+	toolReadOnly    toolRisk = iota // inspects state only; runs without asking
+	toolMutating                    // changes VM state (start, stop, mount, snapshot…)
+	toolDestructive                 // can lose data or run arbitrary commands
+)
+
+func (r toolRisk) String() string {
+	switch r {
+	case toolReadOnly:
+		return "read-only"
+	case toolDestructive:
+		return "destructive"
+	default:
+		return "mutating"
+	}
+}
+
+// readOnlyToolWords mark a tool as read-only when they are the tool name's first word.
+var readOnlyToolWords = map[string]bool{
+	"list": true, "get": true, "info": true, "show": true, "describe": true,
+	"find": true, "search": true, "status": true, "version": true, "inspect": true,
+}
+
+// destructiveToolWords mark a tool as destructive when they appear anywhere in its name.
+var destructiveToolWords = map[string]bool{
+	"delete": true, "purge": true, "remove": true, "destroy": true,
+	"exec": true, "restore": true, "transfer": true,
+}
+
+// classifyTool decides the risk of a tool from its name, ignoring the server
+// prefix of extra MCP servers' tools. Unknown tools are treated as mutating so
+// new server tools are never run silently.
+func classifyTool(name string) toolRisk {
+	words := strings.FieldsFunc(strings.ToLower(unqualifiedToolName(name)), func(r rune) bool {
+		return r == '_' || r == '-' || r == '.' || r == ' '
+	})
+	if len(words) == 0 {
+		return toolMutating
+	}
+	for _, w := range words {
+		if destructiveToolWords[w] {
+			return toolDestructive
+		}
+	}
+	if readOnlyToolWords[words[0]] {
+		return toolReadOnly
+	}
+	return toolMutating
+}
+
+// needsApproval reports whether a call of the given risk must be confirmed by the user.
+func needsApproval(risk toolRisk) bool {
+	return risk != toolReadOnly
+}
+
+// formatToolArgs renders parsed tool arguments one per line, sorted by key,
+// for display in the approval prompt.
+func formatToolArgs(args map[string]interface{}) string {
+	if len(args) == 0 {
+		return "(no arguments)"
+	}
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		var val string
+		switch v := args[k].(type) {
+		case string:
+			val = v
+		default:
+			b, err := json.Marshal(v)
+			if err != nil {
+				val = fmt.Sprintf("%v", v)
+			} else {
+				val = string(b)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", k, val))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// deniedToolResult is the tool result fed back to the model when the user denies a call.
+func deniedToolResult(name string) string {
+	return fmt.Sprintf("The user denied the %s call. It was not executed. Do not retry it unless the user asks.", name)
+}
+
+// awaitApproval asks the chat panel to approve a tool call and blocks until the
+// user answers or ctx is cancelled (which counts as a deny).
+func awaitApproval(ctx context.Context, p *tea.Program, name string, args map[string]interface{}, risk toolRisk) bool {
+	if p == nil {
+		return false
+	}
+	reply := make(chan bool, 1)
+	p.Send(chatApprovalRequestMsg{name: name, args: args, risk: risk, reply: reply})
+	select {
+	case approved := <-reply:
+		return approved
+	case <-ctx.Done():
+		return false
+	}
+}
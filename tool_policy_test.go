@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestClassifyTool(t *testing.T) {
+	tests := []struct {
+		name string
+		want toolRisk
+	}{
+		{"list_instances", toolReadOnly},
+		{"get_instance_info", toolReadOnly},
+		{"version", toolReadOnly},
+		{"start_instance", toolMutating},
+		{"stop_instance", toolMutating},
+		{"create_snapshot", toolMutating},
+		{"launch_instance", toolMutating},
+		{"some_new_tool", toolMutating},
+		{"", toolMutating},
+		{"delete_instance", toolDestructive},
+		{"exec_command", toolDestructive},
+		{"restore_snapshot", toolDestructive},
+		{"list_and_delete", toolDestructive},
+		{"docs__search_pages", toolReadOnly},
+		{"fs__write_file", toolMutating},
+		{"fs__delete_file", toolDestructive},
+	}
+	for _, tt := range tests {
+		if got := classifyTool(tt.name); got != tt.want {
+			t.Errorf("classifyTool(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatToolArgs(t *testing.T) {
+	got := formatToolArgs(map[string]interface{}{
+		"name":    "vm1",
+		"command": []interface{}{"rm", "-rf", "/tmp/x"},
+		"force":   true,
+	})
+	want := "command: [\"rm\",\"-rf\",\"/tmp/x\"]\nforce: true\nname: vm1"
+	if got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+	if got := formatToolArgs(nil); got != "(no arguments)" {
+		t.Fatalf("unexpected empty args rendering %q", got)
+	}
+}
+
+func TestChatApprovalAnswer(t *testing.T) {
+	m := newChatModel()
+	m.focused = true
+	reply := make(chan bool, 1)
+	m, _ = m.Update(chatApprovalRequestMsg{name: "delete_instance", args: map[string]interface{}{"name": "vm1"}, risk: toolDestructive, reply: reply})
+	if m.pendingApproval == nil {
+		t.Fatalf("expected a pending approval")
+	}
+
+	// Unrelated keys are swallowed while waiting
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if m.pendingApproval == nil || m.input.Value() != "" {
+		t.Fatalf("expected approval to stay pending and input untouched")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if m.pendingApproval != nil {
+		t.Fatalf("expected approval to be answered")
+	}
+	if approved := <-reply; approved {
+		t.Fatalf("expected deny")
+	}
+}
@@ -0,0 +1,159 @@
+// tool_runner.go - Validation, approval and concurrent execution of one turn's tool calls
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// toolJob is an approved tool call waiting to run. index is its position in
+// the assistant message, where its result goes.
+type toolJob struct {
+	index int
+	call  ToolCall
+	args  map[string]interface{}
+}
+
+// prepareToolCalls validates each call and asks for approval where needed, one
+// at a time so the user sees a single prompt at once. Calls that fail or are
+// denied get their output set immediately, as do mutating calls in plan mode;
+// the rest are returned as jobs.
+// On cancellation the remaining calls are left without output.
+func prepareToolCalls(ctx context.Context, p *tea.Program, calls []ToolCall,
+	allowedTools map[string]bool, outputs []string) []toolJob {
+
+	var jobs []toolJob
+	for j, tc := range calls {
+		started := time.Now()
+		if ctx.Err() != nil {
+			break
+		}
+
+		// Validate tool name against known tools
+		if !allowedTools[tc.Function.Name] {
+			outputs[j] = fmt.Sprintf("Unknown tool '%s' — not calling it", tc.Function.Name)
+			err := fmt.Errorf("unknown tool")
+			notifyProgram(p, chatToolDoneMsg{name: tc.Function.Name, args: tc.Function.Arguments, result: outputs[j], err: err})
+			auditToolCall(ctx, tc.Function.Name, nil, "", err, started)
+			continue
+		}
+
+		// Parse arguments
+		var args map[string]interface{}
+		if tc.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+				outputs[j] = fmt.Sprintf("Invalid arguments for %s: %s", tc.Function.Name, err.Error())
+				notifyProgram(p, chatToolDoneMsg{name: tc.Function.Name, args: tc.Function.Arguments, result: outputs[j], err: err})
+				auditToolCall(ctx, tc.Function.Name, nil, "", err, started)
+				continue
+			}
+		}
+
+		// In plan mode mutating and destructive tools are recorded, not run
+		risk := classifyTool(tc.Function.Name)
+		if plan := toolPlanFrom(ctx); plan != nil && risk != toolReadOnly {
+			plan.calls = append(plan.calls, plannedCall{call: tc, args: args, risk: risk, model: auditModel(ctx)})
+			outputs[j] = plannedToolResult
+			notifyProgram(p, chatToolPlannedMsg{name: tc.Function.Name, args: tc.Function.Arguments})
+			continue
+		}
+
+		// Mutating and destructive tools wait for the user to approve them
+		if needsApproval(risk) && !awaitApproval(ctx, p, tc.Function.Name, args, risk) {
+			if ctx.Err() != nil {
+				break
+			}
+			outputs[j] = deniedToolResult(tc.Function.Name)
+			notifyProgram(p, chatToolDoneMsg{name: tc.Function.Name, args: tc.Function.Arguments, result: outputs[j], err: errToolDenied})
+			auditToolCall(ctx, tc.Function.Name, args, "", errToolDenied, started)
+			continue
+		}
+
+		jobs = append(jobs, toolJob{index: j, call: tc, args: args})
+	}
+	return jobs
+}
+
+// runToolCalls executes jobs with at most limit running at once and stores each
+// output at its job's index. Calls on the same instance (the "name" argument)
+// run in order, since e.g. a snapshot must not race a stop of the same VM.
+// Jobs not started before ctx is cancelled are left without output.
+func runToolCalls(ctx context.Context, p *tea.Program, executor ToolExecutor,
+	jobs []toolJob, outputs []string, limit int) {
+
+	if limit <= 1 || len(jobs) <= 1 {
+		for _, job := range jobs {
+			outputs[job.index] = runToolCall(ctx, p, executor, job)
+		}
+		return
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, group := range groupToolJobs(jobs) {
+		wg.Add(1)
+		go func(group []toolJob) {
+			defer wg.Done()
+			for _, job := range group {
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				outputs[job.index] = runToolCall(ctx, p, executor, job)
+				<-sem
+			}
+		}(group)
+	}
+	wg.Wait()
+}
+
+// groupToolJobs splits jobs into groups that may run concurrently: jobs naming
+// the same instance share a group, in their original order.
+func groupToolJobs(jobs []toolJob) [][]toolJob {
+	var groups [][]toolJob
+	byInstance := make(map[string]int)
+	for _, job := range jobs {
+		name, _ := job.args["name"].(string)
+		if name == "" {
+			groups = append(groups, []toolJob{job})
+			continue
+		}
+		if g, ok := byInstance[name]; ok {
+			groups[g] = append(groups[g], job)
+			continue
+		}
+		byInstance[name] = len(groups)
+		groups = append(groups, []toolJob{job})
+	}
+	return groups
+}
+
+// runToolCall executes one call, notifying the UI before and after. It returns
+// "" without running anything if ctx is already cancelled.
+func runToolCall(ctx context.Context, p *tea.Program, executor ToolExecutor, job toolJob) string {
+	if ctx.Err() != nil {
+		return ""
+	}
+	name := job.call.Function.Name
+	notifyProgram(p, chatToolStartMsg{name: name, args: job.call.Function.Arguments})
+
+	// Execute tool via MCP or the built-in tools
+	started := time.Now()
+	output, err := executor.CallTool(ctx, name, job.args)
+	auditToolCall(ctx, name, job.args, output, err, started)
+	if err != nil {
+		output = fmt.Sprintf("Error: %s", err.Error())
+		if ctx.Err() != nil {
+			output = interruptedToolResult
+		}
+	}
+
+	notifyProgram(p, chatToolDoneMsg{name: name, args: job.call.Function.Arguments, result: output, err: err})
+	return output
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeExecutor records calls and how many ran at once.
+type fakeExecutor struct {
+	delay   time.Duration
+	active  atomic.Int32
+	maxSeen atomic.Int32
+
+	mu    sync.Mutex
+	order []string
+}
+
+func (f *fakeExecutor) CallTool(ctx context.Context, name string, args map[string]interface{}) (string, error) {
+	n := f.active.Add(1)
+	defer f.active.Add(-1)
+	for {
+		m := f.maxSeen.Load()
+		if n <= m || f.maxSeen.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	f.mu.Lock()
+	f.order = append(f.order, fmt.Sprintf("%s %v", name, args["name"]))
+	f.mu.Unlock()
+	time.Sleep(f.delay)
+	return fmt.Sprintf("%s %v done", name, args["name"]), nil
+}
+
+func testJobs(names ...string) []toolJob {
+	jobs := make([]toolJob, len(names))
+	for i, n := range names {
+		jobs[i] = toolJob{
+			index: i,
+			call:  ToolCall{ID: fmt.Sprintf("c%d", i), Function: FunctionCall{Name: "stop_instance"}},
+			args:  map[string]interface{}{"name": n},
+		}
+	}
+	return jobs
+}
+
+func TestRunToolCallsConcurrentKeepsOrder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // tool calls are audited
+	exec := &fakeExecutor{delay: 30 * time.Millisecond}
+	jobs := testJobs("web1", "web2", "web3", "web4")
+	outputs := make([]string, len(jobs))
+
+	runToolCalls(context.Background(), nil, exec, jobs, outputs, 2)
+
+	for i, n := range []string{"web1", "web2", "web3", "web4"} {
+		if want := "stop_instance " + n + " done"; outputs[i] != want {
+			t.Fatalf("outputs[%d] = %q, want %q", i, outputs[i], want)
+		}
+	}
+	if got := exec.maxSeen.Load(); got != 2 {
+		t.Fatalf("expected 2 calls at once with limit 2, saw %d", got)
+	}
+}
+
+func TestRunToolCallsSerializesSameInstance(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // tool calls are audited
+	exec := &fakeExecutor{delay: 10 * time.Millisecond}
+	jobs := testJobs("vm1", "vm1", "vm1")
+	jobs[1].call.Function.Name = "create_snapshot"
+	outputs := make([]string, len(jobs))
+
+	runToolCalls(context.Background(), nil, exec, jobs, outputs, 4)
+
+	if got := exec.maxSeen.Load(); got != 1 {
+		t.Fatalf("calls on one instance should not overlap, saw %d at once", got)
+	}
+	want := []string{"stop_instance vm1", "create_snapshot vm1", "stop_instance vm1"}
+	for i := range want {
+		if exec.order[i] != want[i] {
+			t.Fatalf("call order = %v, want %v", exec.order, want)
+		}
+	}
+}
+
+func TestRunToolCallsCancelled(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // tool calls are audited
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	jobs := testJobs("web1", "web2")
+	outputs := make([]string, len(jobs))
+
+	runToolCalls(ctx, nil, &fakeExecutor{}, jobs, outputs, 4)
+
+	for i, out := range outputs {
+		if out != "" {
+			t.Fatalf("outputs[%d] = %q, want nothing run after cancel", i, out)
+		}
+	}
+}
+
+func TestRunAgentParallelToolResultsInOrder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // tool calls are audited
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if requests.Add(1) == 1 {
+			var calls []ToolCall
+			for i, n := range []string{"a", "b", "c"} {
+				args, _ := json.Marshal(map[string]string{"name": n})
+				calls = append(calls, ToolCall{ID: fmt.Sprintf("id%d", i), Type: "function",
+					Function: FunctionCall{Name: "get_instance_info", Arguments: string(args)}})
+			}
+			resp, _ := json.Marshal(map[string]interface{}{
+				"choices": []interface{}{map[string]interface{}{"message": ChatMessage{Role: "assistant", ToolCalls: calls}}},
+			})
+			w.Write(resp)
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"all done"}}]}`)
+	}))
+	defer srv.Close()
+
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m", ParallelTools: 3})
+	tools := []ToolDef{{Type: "function", Function: ToolDefFunction{Name: "get_instance_info"}}}
+	res := RunAgent(context.Background(), nil, client, &fakeExecutor{delay: 10 * time.Millisecond},
+		[]ChatMessage{{Role: "system"}, {Role: "user", Content: "info"}}, tools)
+	if res.Err != nil || res.Response != "all done" {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if len(res.Messages) != 4 {
+		t.Fatalf("expected assistant + 3 tool messages, got %d", len(res.Messages))
+	}
+	for i, n := range []string{"a", "b", "c"} {
+		msg := res.Messages[i+1]
+		if msg.ToolCallID != fmt.Sprintf("id%d", i) || msg.Content != "get_instance_info "+n+" done" {
+			t.Fatalf("tool message %d out of order: %+v", i, msg)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// tools_native.go - Built-in agent tools backed by the multipass CLI (no MCP server needed)
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// nativeNamePattern is what multipass accepts as an instance or snapshot name.
+// Checking it keeps a model-chosen name such as "--all" from reaching the
+// command line as a flag.
+var nativeNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*$`)
+
+// ToolExecutor runs a named tool call. MCPClient and NativeTools both implement it,
+// so RunAgent does not care where a tool lives.
+type ToolExecutor interface {
+	CallTool(ctx context.Context, name string, arguments map[string]interface{}) (string, error)
+}
+
+// nativeTool is one built-in tool: its schema for the LLM and the handler that runs it.
+type nativeTool struct {
+	description string
+	params      map[string]string // property name → description; all are required strings
+	optional    map[string]string // optional string properties
+	run         func(ctx context.Context, n *NativeTools, args map[string]string) (string, error)
+}
+
+// NativeTools exposes PassGo's own VM operations as agent tools.
+type NativeTools struct {
+	runCmd func(ctx context.Context, args ...string) (string, error)
+}
+
+// newNativeTools returns built-in tools that shell out to multipass.
+func newNativeTools() *NativeTools {
+	return &NativeTools{runCmd: runMultipassCommandContext}
+}
+
+var nativeToolSet = map[string]nativeTool{
+	"list_instances": {
+		description: "List all Multipass instances with their state, IPv4 address and image as JSON.",
+		run: func(ctx context.Context, n *NativeTools, _ map[string]string) (string, error) {
+			return n.runCmd(ctx, "list", "--format", "json")
+		},
+	},
+	"get_instance_info": {
+		description: "Show detailed information about one instance (resources, mounts, snapshots count).",
+		params:      map[string]string{"name": "Instance name"},
+		run: func(ctx context.Context, n *NativeTools, a map[string]string) (string, error) {
+			return n.runCmd(ctx, "info", a["name"])
+		},
+	},
+	"start_instance": {
+		description: "Start a stopped or suspended instance.",
+		params:      map[string]string{"name": "Instance name"},
+		run: func(ctx context.Context, n *NativeTools, a map[string]string) (string, error) {
+			return n.action(ctx, fmt.Sprintf("Started %s", a["name"]), "start", a["name"])
+		},
+	},
+	"stop_instance": {
+		description: "Stop a running instance.",
+		params:      map[string]string{"name": "Instance name"},
+		run: func(ctx context.Context, n *NativeTools, a map[string]string) (string, error) {
+			return n.action(ctx, fmt.Sprintf("Stopped %s", a["name"]), "stop", a["name"])
+		},
+	},
+	"suspend_instance": {
+		description: "Suspend a running instance.",
+		params:      map[string]string{"name": "Instance name"},
+		run: func(ctx context.Context, n *NativeTools, a map[string]string) (string, error) {
+			return n.action(ctx, fmt.Sprintf("Suspended %s", a["name"]), "suspend", a["name"])
+		},
+	},
+	"create_snapshot": {
+		description: "Take a snapshot of a stopped instance.",
+		params:      map[string]string{"name": "Instance name", "snapshot": "Snapshot name"},
+		optional:    map[string]string{"comment": "Snapshot description"},
+		run: func(ctx context.Context, n *NativeTools, a map[string]string) (string, error) {
+			return n.action(ctx, fmt.Sprintf("Created snapshot %s of %s", a["snapshot"], a["name"]),
+				"snapshot", "--name", a["snapshot"], "--comment", a["comment"], a["name"])
+		},
+	},
+	"restore_snapshot": {
+		description: "Restore a stopped instance to a snapshot, discarding its current state.",
+		params:      map[string]string{"name": "Instance name", "snapshot": "Snapshot name"},
+		run: func(ctx context.Context, n *NativeTools, a map[string]string) (string, error) {
+			return n.action(ctx, fmt.Sprintf("Restored %s to snapshot %s", a["name"], a["snapshot"]),
+				"restore", "--destructive", a["name"]+"."+a["snapshot"])
+		},
+	},
+	"mount_directory": {
+		description: "Mount a host directory into an instance.",
+		params:      map[string]string{"name": "Instance name", "source": "Absolute host directory path", "target": "Absolute path inside the instance"},
+		run: func(ctx context.Context, n *NativeTools, a map[string]string) (string, error) {
+			mount := MountProfile{Source: a["source"], Target: a["target"]}
+			out, err := n.action(ctx, fmt.Sprintf("Mounted %s at %s:%s", mount.Source, a["name"], mount.Target),
+				buildMountArgs(a["name"], mount)...)
+			if err == nil {
+				logMountProfileError(rememberMount(a["name"], mount))
+			}
+			return out, err
+		},
+	},
+	"exec_command": {
+		description: "Run a shell command inside a running instance and return its output.",
+		params:      map[string]string{"name": "Instance name", "command": "Shell command, run with sh -c"},
+		run: func(ctx context.Context, n *NativeTools, a map[string]string) (string, error) {
+			return n.runCmd(ctx, "exec", a["name"], "--", "sh", "-c", a["command"])
+		},
+	},
+}
+
+// Definitions returns the tool schemas sent to the LLM, sorted by name.
+func (n *NativeTools) Definitions() []ToolDef {
+	names := make([]string, 0, len(nativeToolSet))
+	for name := range nativeToolSet {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	defs := make([]ToolDef, 0, len(names))
+	for _, name := range names {
+		tool := nativeToolSet[name]
+		props := map[string]interface{}{}
+		required := []string{}
+		for p, desc := range tool.params {
+			props[p] = map[string]string{"type": "string", "description": desc}
+			required = append(required, p)
+		}
+		for p, desc := range tool.optional {
+			props[p] = map[string]string{"type": "string", "description": desc}
+		}
+		sort.Strings(required)
+
+		defs = append(defs, ToolDef{
+			Type: "function",
+			Function: ToolDefFunction{
+				Name:        name,
+				Description: tool.description,
+				Parameters: map[string]interface{}{
+					"type":       "object",
+					"properties": props,
+					"required":   required,
+				},
+			},
+		})
+	}
+	return defs
+}
+
+// CallTool validates the arguments against the tool's schema and runs it.
+func (n *NativeTools) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	tool, ok := nativeToolSet[name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+
+	args := make(map[string]string, len(tool.params)+len(tool.optional))
+	for p := range tool.params {
+		v, _ := arguments[p].(string)
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return "", fmt.Errorf("%s: missing required argument %q", name, p)
+		}
+		args[p] = v
+	}
+	for p := range tool.optional {
+		v, _ := arguments[p].(string)
+		args[p] = strings.TrimSpace(v)
+	}
+	if err := validateNativeArgs(args); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return tool.run(ctx, n, args)
+}
+
+// validateNativeArgs rejects names that are not valid instance or snapshot
+// names and paths that would be read as flags.
+func validateNativeArgs(args map[string]string) error {
+	for _, p := range []string{"name", "snapshot"} {
+		if v, ok := args[p]; ok && !nativeNamePattern.MatchString(v) {
+			return fmt.Errorf("invalid %s %q: use letters, digits and hyphens, starting with a letter", p, v)
+		}
+	}
+	for _, p := range []string{"source", "target"} {
+		if strings.HasPrefix(args[p], "-") {
+			return fmt.Errorf("invalid %s %q: must not start with -", p, args[p])
+		}
+	}
+	return nil
+}
+
+// action runs a state-changing command and reports done when multipass prints nothing.
+func (n *NativeTools) action(ctx context.Context, done string, args ...string) (string, error) {
+	out, err := n.runCmd(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out) == "" {
+		return done, nil
+	}
+	return out, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNativeToolsCallTool(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tests := []struct {
+		name     string
+		tool     string
+		args     map[string]interface{}
+		wantArgs []string
+		want     string
+	}{
+		{
+			name:     "list",
+			tool:     "list_instances",
+			wantArgs: []string{"list", "--format", "json"},
+			want:     "output",
+		},
+		{
+			name:     "start reports success when multipass is silent",
+			tool:     "start_instance",
+			args:     map[string]interface{}{"name": "vm1"},
+			wantArgs: []string{"start", "vm1"},
+			want:     "Started vm1",
+		},
+		{
+			name:     "snapshot with optional comment omitted",
+			tool:     "create_snapshot",
+			args:     map[string]interface{}{"name": "vm1", "snapshot": "s1"},
+			wantArgs: []string{"snapshot", "--name", "s1", "--comment", "", "vm1"},
+			want:     "Created snapshot s1 of vm1",
+		},
+		{
+			name:     "restore",
+			tool:     "restore_snapshot",
+			args:     map[string]interface{}{"name": "vm1", "snapshot": "s1"},
+			wantArgs: []string{"restore", "--destructive", "vm1.s1"},
+			want:     "Restored vm1 to snapshot s1",
+		},
+		{
+			name:     "mount",
+			tool:     "mount_directory",
+			args:     map[string]interface{}{"name": "vm1", "source": "/src", "target": "/dst"},
+			wantArgs: []string{"mount", "/src", "vm1:/dst"},
+			want:     "Mounted /src at vm1:/dst",
+		},
+		{
+			name:     "exec",
+			tool:     "exec_command",
+			args:     map[string]interface{}{"name": "vm1", "command": "uptime"},
+			wantArgs: []string{"exec", "vm1", "--", "sh", "-c", "uptime"},
+			want:     "output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			n := &NativeTools{runCmd: func(_ context.Context, args ...string) (string, error) {
+				got = args
+				if args[0] == "list" || args[0] == "exec" {
+					return "output", nil
+				}
+				return "", nil
+			}}
+
+			result, err := n.CallTool(context.Background(), tt.tool, tt.args)
+			if err != nil {
+				t.Fatalf("CallTool: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Fatalf("args = %v, want %v", got, tt.wantArgs)
+			}
+			if result != tt.want {
+				t.Fatalf("result = %q, want %q", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestNativeToolsValidation(t *testing.T) {
+	n := &NativeTools{runCmd: func(_ context.Context, args ...string) (string, error) {
+		t.Fatalf("runCmd should not be called, got %v", args)
+		return "", nil
+	}}
+
+	if _, err := n.CallTool(context.Background(), "stop_instance", map[string]interface{}{"name": "  "}); err == nil ||
+		!strings.Contains(err.Error(), `"name"`) {
+		t.Fatalf("expected missing name error, got %v", err)
+	}
+	if _, err := n.CallTool(context.Background(), "delete_instance", nil); err == nil {
+		t.Fatalf("expected unknown tool error")
+	}
+	for _, args := range []map[string]interface{}{
+		{"name": "--all"},
+		{"name": "-h"},
+		{"name": "vm1", "snapshot": "--help"},
+		{"name": "vm1.snap"},
+	} {
+		tool := "stop_instance"
+		if _, ok := args["snapshot"]; ok {
+			tool = "restore_snapshot"
+		}
+		if _, err := n.CallTool(context.Background(), tool, args); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Fatalf("expected %v to be rejected, got %v", args, err)
+		}
+	}
+	mount := map[string]interface{}{"name": "vm1", "source": "--type=native", "target": "/mnt"}
+	if _, err := n.CallTool(context.Background(), "mount_directory", mount); err == nil {
+		t.Fatalf("expected a flag-like source to be rejected")
+	}
+}
+
+func TestNativeToolsDefinitions(t *testing.T) {
+	defs := (&NativeTools{}).Definitions()
+	if len(defs) != len(nativeToolSet) {
+		t.Fatalf("expected %d definitions, got %d", len(nativeToolSet), len(defs))
+	}
+	for _, d := range defs {
+		params, ok := d.Function.Parameters.(map[string]interface{})
+		if !ok || params["type"] != "object" {
+			t.Fatalf("%s: expected object schema, got %#v", d.Function.Name, d.Function.Parameters)
+		}
+		// Every built-in tool must be classified so approval applies to it
+		if strings.HasPrefix(d.Function.Name, "list") || strings.HasPrefix(d.Function.Name, "get") {
+			if classifyTool(d.Function.Name) != toolReadOnly {
+				t.Fatalf("%s should be read-only", d.Function.Name)
+			}
+		} else if classifyTool(d.Function.Name) == toolReadOnly {
+			t.Fatalf("%s should require approval", d.Function.Name)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// usage.go - LLM token usage, per-model pricing and the daily usage ledger (~/.passgo/usage.json)
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Usage is the token count reported by the API for one or more requests.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *Usage) add(o Usage) {
+	u.PromptTokens += o.PromptTokens
+	u.CompletionTokens += o.CompletionTokens
+}
+
+// Total returns prompt plus completion tokens.
+func (u Usage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// cost returns the USD cost of u at this price.
+func (p ModelPrice) cost(u Usage) float64 {
+	return (float64(u.PromptTokens)*p.Input + float64(u.CompletionTokens)*p.Output) / 1e6
+}
+
+// usageTotals accumulates usage and its cost (0 when the model has no price).
+type usageTotals struct {
+	Usage
+	Requests int     `json:"requests"`
+	Cost     float64 `json:"cost"`
+}
+
+func (t *usageTotals) add(o usageTotals) {
+	t.Usage.add(o.Usage)
+	t.Requests += o.Requests
+	t.Cost += o.Cost
+}
+
+// usageCost prices usage for a model using the configured price table.
+func usageCost(prices map[string]ModelPrice, model string, u Usage, requests int) usageTotals {
+	t := usageTotals{Usage: u, Requests: requests}
+	if p, ok := prices[model]; ok {
+		t.Cost = p.cost(u)
+	}
+	return t
+}
+
+// modelUsage is the unpriced usage of a run by the model that served it; with
+// fallback models one run can use several.
+type modelUsage map[string]usageTotals
+
+func (mu modelUsage) add(model string, u Usage) {
+	t := mu[model]
+	t.Usage.add(u)
+	t.Requests++
+	mu[model] = t
+}
+
+// priced sums the usage, pricing each model's share at its own rate.
+func (mu modelUsage) priced(prices map[string]ModelPrice) usageTotals {
+	var total usageTotals
+	for model, t := range mu {
+		total.add(usageCost(prices, model, t.Usage, t.Requests))
+	}
+	return total
+}
+
+const usageLedgerFile = "usage.json"
+
+// usageLedger is the per-day usage file, keyed by local date (2006-01-02).
+type usageLedger struct {
+	Days map[string]usageTotals `json:"days"`
+}
+
+// usageLedgerMu serializes read-modify-write of the ledger file.
+var usageLedgerMu sync.Mutex
+
+func usageLedgerPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".passgo", usageLedgerFile), nil
+}
+
+// usageDay returns the ledger key for t.
+func usageDay(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
+
+func loadUsageLedger() (usageLedger, error) {
+	ledger := usageLedger{Days: map[string]usageTotals{}}
+	path, err := usageLedgerPath()
+	if err != nil {
+		return ledger, err
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- path from UserHomeDir
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ledger, nil
+		}
+		return ledger, err
+	}
+	if err := json.Unmarshal(data, &ledger); err != nil {
+		return usageLedger{Days: map[string]usageTotals{}}, fmt.Errorf("parse %s: %w", usageLedgerFile, err)
+	}
+	if ledger.Days == nil {
+		ledger.Days = map[string]usageTotals{}
+	}
+	return ledger, nil
+}
+
+// loadDailyUsage returns the recorded totals for one day.
+func loadDailyUsage(day string) (usageTotals, error) {
+	ledger, err := loadUsageLedger()
+	return ledger.Days[day], err
+}
+
+// recordDailyUsage adds t to the day's totals and returns the new totals.
+func recordDailyUsage(day string, t usageTotals) (usageTotals, error) {
+	usageLedgerMu.Lock()
+	defer usageLedgerMu.Unlock()
+
+	ledger, err := loadUsageLedger()
+	if err != nil {
+		return t, err
+	}
+	total := ledger.Days[day]
+	total.add(t)
+	ledger.Days[day] = total
+
+	path, err := usageLedgerPath()
+	if err != nil {
+		return total, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return total, err
+	}
+	data, err := json.MarshalIndent(ledger, "", "  ")
+	if err != nil {
+		return total, err
+	}
+	return total, os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// formatTokenCount renders a token count compactly: 950, 12.3k, 1.2M.
+func formatTokenCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// formatUsage renders totals for the chat title, e.g. "12.3k tok $0.04".
+func formatUsage(t usageTotals) string {
+	s := formatTokenCount(t.Total()) + " tok"
+	if t.Cost > 0 {
+		s += fmt.Sprintf(" $%.2f", t.Cost)
+	}
+	return s
+}
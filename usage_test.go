@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChatRecordsUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":100,\"completion_tokens\":7}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":10,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	client := NewLLMClient(LLMConfig{BaseURL: srv.URL, Model: "m", Stream: true})
+	msgs := []ChatMessage{{Role: "user", Content: "hey"}}
+	if _, err := client.Chat(context.Background(), msgs, nil); err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if _, err := client.ChatStream(context.Background(), msgs, nil, nil); err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+
+	usage := client.TakeUsage()["m"]
+	if usage.Requests != 2 || usage.PromptTokens != 110 || usage.CompletionTokens != 9 {
+		t.Fatalf("usage = %+v", usage)
+	}
+	if usage := client.TakeUsage(); len(usage) != 0 {
+		t.Fatalf("TakeUsage should reset, got %+v", usage)
+	}
+}
+
+func TestAnthropicStreamUsage(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"type":"message_start","message":{"usage":{"input_tokens":50,"output_tokens":1}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"ok"}}`,
+		`data: {"type":"message_delta","delta":{},"usage":{"output_tokens":12}}`,
+		`data: {"type":"message_stop"}`,
+	}, "\n\n")
+	_, usage, err := readAnthropicStream(strings.NewReader(stream), nil)
+	if err != nil {
+		t.Fatalf("readAnthropicStream: %v", err)
+	}
+	if usage.PromptTokens != 50 || usage.CompletionTokens != 12 {
+		t.Fatalf("usage = %+v", usage)
+	}
+}
+
+func TestUsageCost(t *testing.T) {
+	prices := map[string]ModelPrice{"deepseek/deepseek-v3.2": {Input: 0.28, Output: 0.42}}
+	u := Usage{PromptTokens: 1_000_000, CompletionTokens: 500_000}
+
+	got := usageCost(prices, "deepseek/deepseek-v3.2", u, 3)
+	if math.Abs(got.Cost-0.49) > 1e-9 || got.Requests != 3 {
+		t.Fatalf("cost = %+v", got)
+	}
+	if unpriced := usageCost(prices, "other", u, 1); unpriced.Cost != 0 || unpriced.Total() != 1_500_000 {
+		t.Fatalf("unpriced model = %+v", unpriced)
+	}
+}
+
+func TestModelUsagePricedPerModel(t *testing.T) {
+	prices := map[string]ModelPrice{"primary": {Input: 10, Output: 10}, "fallback": {Input: 1, Output: 1}}
+	run := modelUsage{}
+	run.add("primary", Usage{PromptTokens: 100_000})
+	run.add("fallback", Usage{PromptTokens: 1_000_000})
+	run.add("fallback", Usage{CompletionTokens: 1_000_000})
+
+	got := run.priced(prices)
+	if got.Requests != 3 || got.Total() != 2_100_000 || math.Abs(got.Cost-3) > 1e-9 {
+		t.Fatalf("expected $1 + $2 over 3 requests, got %+v", got)
+	}
+}
+
+func TestRecordDailyUsage(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	run := usageTotals{Usage: Usage{PromptTokens: 10, CompletionTokens: 5}, Requests: 1, Cost: 0.25}
+	if _, err := recordDailyUsage("2026-01-02", run); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	total, err := recordDailyUsage("2026-01-02", run)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if total.Requests != 2 || total.Total() != 30 || math.Abs(total.Cost-0.5) > 1e-9 {
+		t.Fatalf("day total = %+v", total)
+	}
+
+	other, err := loadDailyUsage("2026-01-03")
+	if err != nil || other.Requests != 0 {
+		t.Fatalf("other day = %+v, %v", other, err)
+	}
+}
+
+func TestLLMConfigPricesRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cfg, err := parseLLMConfig(strings.NewReader("spend-limit=2.5\n\n[prices]\nvendor/model:free = 0, 0\nclaude-sonnet-4-5=3,15\nbad=abc\n"))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.SpendLimit != 2.5 || len(cfg.Prices) != 2 || cfg.Prices["claude-sonnet-4-5"] != (ModelPrice{Input: 3, Output: 15}) {
+		t.Fatalf("unexpected config: limit=%v prices=%v", cfg.SpendLimit, cfg.Prices)
+	}
+
+	if err := saveLLMConfig(cfg); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	got, err := loadLLMConfig()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got.SpendLimit != 2.5 || len(got.Prices) != 2 || got.Prices["vendor/model:free"] != (ModelPrice{}) {
+		t.Fatalf("round trip lost prices: %+v", got.Prices)
+	}
+}
+
+func TestFormatUsage(t *testing.T) {
+	if got := formatUsage(usageTotals{Usage: Usage{PromptTokens: 12000, CompletionTokens: 345}, Cost: 0.041}); got != "12.3k tok $0.04" {
+		t.Fatalf("formatUsage = %q", got)
+	}
+	if got := formatUsage(usageTotals{Usage: Usage{PromptTokens: 900}}); got != "900 tok" {
+		t.Fatalf("formatUsage = %q", got)
+	}
+}
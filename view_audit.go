@@ -0,0 +1,218 @@
+// view_audit.go - Audit log viewer: agent tool calls, filterable by VM or tool
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type auditLogModel struct {
+	entries     []auditEntry // newest first
+	filtered    []auditEntry
+	cursor      int
+	filterInput textinput.Model
+	filtering   bool
+	width       int
+	height      int
+}
+
+func newAuditLogModel(entries []auditEntry, w, h int) auditLogModel {
+	ti := textinput.New()
+	ti.Placeholder = "VM or tool"
+	ti.CharLimit = 64
+	ti.Width = 30
+	m := auditLogModel{filterInput: ti, width: w, height: h}
+	m.setEntries(entries)
+	return m
+}
+
+// setEntries replaces the log and reapplies the filter.
+func (m *auditLogModel) setEntries(entries []auditEntry) {
+	m.entries = entries
+	m.applyFilter()
+}
+
+func (m *auditLogModel) applyFilter() {
+	m.filtered = filterAuditEntries(m.entries, m.filterInput.Value())
+	if m.cursor >= len(m.filtered) {
+		m.cursor = max(0, len(m.filtered)-1)
+	}
+}
+
+func (m auditLogModel) selected() (auditEntry, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.filtered) {
+		return auditEntry{}, false
+	}
+	return m.filtered[m.cursor], true
+}
+
+// visibleRows is how many log rows fit between the header and the detail panel.
+func (m auditLogModel) visibleRows() int {
+	return max(3, m.height-22)
+}
+
+func (m auditLogModel) Update(msg tea.Msg) (auditLogModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		if m.filtering {
+			var cmd tea.Cmd
+			m.filterInput, cmd = m.filterInput.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	if m.filtering {
+		switch keyMsg.String() {
+		case "esc":
+			m.filterInput.SetValue("")
+			m.filtering = false
+			m.filterInput.Blur()
+			m.applyFilter()
+			return m, nil
+		case "enter":
+			m.filtering = false
+			m.filterInput.Blur()
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.filterInput, cmd = m.filterInput.Update(keyMsg)
+		m.cursor = 0
+		m.applyFilter()
+		return m, cmd
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		if m.filterInput.Value() != "" {
+			m.filterInput.SetValue("")
+			m.applyFilter()
+			return m, nil
+		}
+		return m, func() tea.Msg { return backToTableMsg{} }
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.filtered)-1 {
+			m.cursor++
+		}
+	case "pgup":
+		m.cursor = max(0, m.cursor-m.visibleRows())
+	case "pgdown":
+		m.cursor = max(0, min(len(m.filtered)-1, m.cursor+m.visibleRows()))
+	case "f", "/":
+		m.filtering = true
+		return m, m.filterInput.Focus()
+	case "r":
+		return m, loadAuditLogCmd()
+	}
+	return m, nil
+}
+
+// auditOutcome summarizes how a call ended.
+func auditOutcome(e auditEntry) string {
+	switch {
+	case e.Error == errToolDenied.Error():
+		return "denied"
+	case e.Error != "":
+		return "error"
+	}
+	return "ok"
+}
+
+func (m auditLogModel) View() string {
+	title := formTitleStyle.Render(fmt.Sprintf("Audit Log (%d)", len(m.filtered)))
+	if m.filterInput.Value() != "" && len(m.filtered) != len(m.entries) {
+		title = formTitleStyle.Render(fmt.Sprintf("Audit Log (%d/%d)", len(m.filtered), len(m.entries)))
+	}
+
+	filterLine := formLabelStyle.Render("Filter: ") + m.filterInput.View()
+	if m.filtering {
+		filterLine = formActiveLabelStyle.Render("Filter: ") + m.filterInput.View()
+	}
+
+	if len(m.filtered) == 0 {
+		empty := "No agent tool calls recorded yet"
+		if len(m.entries) > 0 {
+			empty = "No entries match the filter"
+		}
+		content := title + "\n\n" + filterLine + "\n\n" +
+			tableEmptyStyle.Render(empty) + "\n\n" +
+			formHintStyle.Render("f: filter  Esc: return")
+		box := modalStyle.Render(content)
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+	}
+
+	modalW := min(110, m.width-4)
+	innerW := modalW - 8 // padding(3*2) + border(1*2)
+	prefixW := 2
+	timeW := 17
+	outcomeW := 8
+	durW := 8
+	vmW := (innerW - prefixW - timeW - outcomeW - durW) / 3
+	toolW := innerW - prefixW - timeW - outcomeW - durW - vmW
+
+	header := "  " + tableHeaderStyle.Width(timeW).Render("Time") +
+		tableHeaderStyle.Width(toolW).Render("Tool") +
+		tableHeaderStyle.Width(vmW).Render("VM") +
+		tableHeaderStyle.Width(outcomeW).Render("Result") +
+		tableHeaderStyle.Width(durW).Render("Took")
+
+	// Keep the cursor inside the visible window
+	rowsShown := m.visibleRows()
+	start := 0
+	if m.cursor >= rowsShown {
+		start = m.cursor - rowsShown + 1
+	}
+	end := min(len(m.filtered), start+rowsShown)
+
+	var rows []string
+	for i := start; i < end; i++ {
+		e := m.filtered[i]
+		selected := i == m.cursor
+		style := tableCellStyle
+		if selected {
+			style = tableSelectedCellStyle
+		}
+		row := style.Width(timeW).Render(e.Time.Local().Format("2006-01-02 15:04")) +
+			style.Width(toolW).Render(truncateToRunes(e.Tool, max(1, toolW-2))) +
+			style.Width(vmW).Render(truncateToRunes(e.vm(), max(1, vmW-2))) +
+			style.Width(outcomeW).Render(auditOutcome(e)) +
+			style.Width(durW).Render(fmt.Sprintf("%.1fs", float64(e.DurationMs)/1000))
+
+		prefix := "  "
+		if selected {
+			prefix = tableCursorStyle.Render("▎ ")
+		}
+		rows = append(rows, prefix+row)
+	}
+
+	var detail string
+	if e, ok := m.selected(); ok {
+		outcome := e.Result
+		if e.Error != "" {
+			outcome = "error: " + e.Error
+		}
+		valW := max(10, innerW-14)
+		detail = detailKeyStyle.Render("User:    ") + detailValStyle.Render(e.User) + "\n" +
+			detailKeyStyle.Render("Session: ") + detailValStyle.Render(e.Session) + "\n" +
+			detailKeyStyle.Render("Model:   ") + detailValStyle.Render(e.Model) + "\n" +
+			detailKeyStyle.Render("Args:    ") + detailValStyle.Render(truncateToRunes(strings.ReplaceAll(formatToolArgs(e.Args), "\n", ", "), valW)) + "\n" +
+			detailKeyStyle.Render("Result:  ") + detailValStyle.Render(truncateToRunes(strings.ReplaceAll(outcome, "\n", " "), valW))
+	}
+
+	hint := formHintStyle.Render("↑/↓: select  f: filter by VM or tool  r: reload  Esc: return")
+
+	content := title + "\n\n" + filterLine + "\n\n" + header + "\n" +
+		strings.Join(rows, "\n") + "\n\n" +
+		detailPanelStyle.Render(detail) + "\n\n" + hint
+
+	box := modalStyle.Render(content)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
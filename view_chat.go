@@ -3,8 +3,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
@@ -15,7 +18,7 @@ import (
 
 // chatEntry is a single item in the chat log.
 type chatEntry struct {
-	role    string // "user", "assistant", "tool-start", "tool-done", "error", "system"
+	role    string // "user", "assistant", "tool-start", "tool-done", "approval", "error", "system"
 	content string
 }
 
@@ -38,10 +41,23 @@ type chatModel struct {
 	// Spinner for agent processing
 	spinner  spinner.Model
 	thinking bool
+	// streaming is true while the last entry is an assistant reply still receiving deltas
+	streaming bool
+	// pendingApproval is the tool call waiting on a y/n answer (nil when none)
+	pendingApproval *chatApprovalRequestMsg
+	// cancel stops the running agent (nil when idle); cancelling is set once it was called
+	cancel     context.CancelFunc
+	cancelling bool
+	// planMode collects mutating tool calls into plan instead of running them (/plan)
+	planMode bool
+	plan     []plannedCall
+	// history keeps the summary of trimmed messages across agent runs
+	history *historySummary
 
 	// Infrastructure (set from rootModel)
 	llmClient *LLMClient
-	mcpClient *MCPClient
+	router    *ToolRouter  // multipass-mcp or built-in tools plus the extra MCP servers
+	toolExec  ToolExecutor // router, or built-in NativeTools alone, once tools are ready
 	mcpTools  []ToolDef
 	program   *tea.Program
 	config    LLMConfig
@@ -53,6 +69,17 @@ type chatModel struct {
 
 	// Current VM state (updated from rootModel's table)
 	currentVMs []vmData
+
+	// Saved session this conversation belongs to (ID empty until the first message)
+	sessionID      string
+	sessionTitle   string // user-chosen title; derived from the first message when empty
+	sessionCreated time.Time
+
+	// Token usage: this session, and today's total across sessions (usage.json)
+	sessionUsage   usageTotals
+	todayUsage     usageTotals
+	usageDay       string
+	spendWarnedDay string // day the spend-limit warning was shown; the next send goes through
 }
 
 func newChatModel() chatModel {
@@ -76,11 +103,12 @@ func newChatModel() chatModel {
 		spinner:  sp,
 		viewport: vp,
 		entries: []chatEntry{
-			{role: "system", content: "Enter to send, Alt+Enter for newline. ? or Tab to switch focus."},
+			{role: "system", content: "Enter to send, Alt+Enter for newline. ? or Tab to switch focus. Type /help for commands, @vm to attach a VM's details."},
 		},
 		messages: []ChatMessage{
 			{Role: "system", Content: LLMSystemPrompt},
 		},
+		history: &historySummary{},
 	}
 }
 
@@ -96,47 +124,65 @@ func (m chatModel) Update(msg tea.Msg) (chatModel, tea.Cmd) {
 			return m, nil
 		}
 
+		// ctrl+c cancels a running request, including one waiting for approval
+		if msg.String() == "ctrl+c" {
+			m.cancelRun()
+			return m, nil
+		}
+
+		// A pending approval captures y/n until answered
+		if m.pendingApproval != nil {
+			switch msg.String() {
+			case "y", "Y":
+				m.answerApproval(true)
+			case "n", "N":
+				m.answerApproval(false)
+			}
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "enter":
 			// Enter sends the message; alt+enter / shift+enter add newlines
 			text := strings.TrimSpace(m.input.Value())
-			if text == "" || m.thinking {
-				return m, nil
+			if isChatCommand(text) {
+				return m, m.runChatCommand(text)
 			}
-
-			// Check if LLM is configured
-			if m.config.APIKey == "" && !isLocalEndpoint(m.config.BaseURL) {
-				m.entries = append(m.entries, chatEntry{
-					role:    "error",
-					content: "No API key configured. Edit ~/.passgo/llm.conf or press L to open settings.",
-				})
-				m.refreshViewport()
+			// "//" escapes a message that starts with a slash
+			if strings.HasPrefix(text, "//") {
+				text = text[1:]
+			}
+			if text == "" || m.thinking || m.sendBlocked() {
 				return m, nil
 			}
 
 			// Add user message
 			m.entries = append(m.entries, chatEntry{role: "user", content: text})
-			m.messages = append(m.messages, ChatMessage{Role: "user", Content: text})
 			m.input.Reset()
 			m.input.SetHeight(3)
 			m.recalcLayout()
-			m.thinking = true
-			m.refreshViewport()
-
-			// Update system prompt with current VM state before each run
-			m.messages[0] = ChatMessage{Role: "system", Content: buildSystemPrompt(m.currentVMs)}
 
-			// Initialize MCP if needed, then run agent
-			if !m.mcpReady && !m.mcpInitFailed {
-				return m, tea.Batch(m.spinner.Tick, m.initMCPAndRunCmd())
-			}
-			if m.mcpInitFailed {
-				return m, tea.Batch(m.spinner.Tick, m.runAgentWithoutToolsCmd())
+			// @vmname mentions attach that VM's details, fetched before the run starts
+			if names := parseVMMentions(text, m.vmNames()); len(names) > 0 {
+				m.thinking = true
+				m.addChatSystem("Attaching details for @" + strings.Join(names, ", @"))
+				return m, tea.Batch(m.spinner.Tick, resolveMentionsCmd(m.newRunContext(), text, names))
 			}
-			return m, tea.Batch(m.spinner.Tick, m.runAgentCmd())
+			m.messages = append(m.messages, ChatMessage{Role: "user", Content: text})
+			return m, m.startRun(m.newRunContext())
 
-		case "ctrl+c":
+		case "tab":
+			// Only reaches the chat when the input is a slash command or ends in an @mention
+			m.completeInput()
+			m.resizeInputToContent()
 			return m, nil
+
+		case "ctrl+p":
+			// Switch provider profile; the next request uses it
+			if m.thinking {
+				return m, nil
+			}
+			return m, m.switchProfile(m.config.nextProfile())
 		}
 
 		// Forward to textarea
@@ -148,14 +194,89 @@ func (m chatModel) Update(msg tea.Msg) (chatModel, tea.Cmd) {
 
 		return m, cmd
 
+	case chatMentionsResolvedMsg:
+		if msg.ctx.Err() != nil {
+			// ctrl+c while fetching: keep the message, but do not send it
+			m.messages = append(m.messages, ChatMessage{Role: "user", Content: msg.text})
+			m.thinking = false
+			m.cancel = nil
+			m.cancelling = false
+			m.addChatSystem("Cancelled before sending.")
+			return m, nil
+		}
+		m.messages = append(m.messages, ChatMessage{Role: "user", Content: withMentionDetails(msg.text, msg.details)})
+		return m, m.startRun(msg.ctx)
+
+	case chatStreamDeltaMsg:
+		if !m.streaming {
+			m.entries = append(m.entries, chatEntry{role: "assistant"})
+			m.streaming = true
+		}
+		m.entries[len(m.entries)-1].content += msg.content
+		m.refreshViewport()
+		return m, nil
+
+	case chatCopiedMsg:
+		if msg.err != nil {
+			m.addChatError("Copy failed: " + msg.err.Error())
+			return m, nil
+		}
+		m.addChatSystem(msg.what + " copied to the clipboard" + msg.via)
+		return m, nil
+
+	case chatLLMRetryMsg:
+		m.streaming = false
+		m.addChatSystem(msg.text)
+		return m, nil
+
+	case chatApprovalRequestMsg:
+		m.streaming = false
+		m.pendingApproval = &msg
+		m.entries = append(m.entries, chatEntry{
+			role: "approval",
+			content: fmt.Sprintf("Allow %s tool %s?\n%s\n[y] approve  [n] deny",
+				msg.risk, msg.name, formatToolArgs(msg.args)),
+		})
+		m.refreshViewport()
+		return m, nil
+
 	case chatToolStartMsg:
+		// Text streamed before a tool call stays as its own entry
+		m.streaming = false
 		m.entries = append(m.entries, chatEntry{
 			role:    "tool-start",
-			content: fmt.Sprintf("Calling %s...", msg.name),
+			content: fmt.Sprintf("Calling %s...", toolCallLabel(msg.name, msg.args)),
+		})
+		m.refreshViewport()
+		return m, nil
+
+	case chatToolPlannedMsg:
+		m.streaming = false
+		m.entries = append(m.entries, chatEntry{
+			role:    "tool-done",
+			content: fmt.Sprintf("%s planned (not executed)", toolCallLabel(msg.name, msg.args)),
 		})
 		m.refreshViewport()
 		return m, nil
 
+	case chatPlanDoneMsg:
+		m.thinking = false
+		if m.cancel != nil {
+			m.cancel()
+			m.cancel = nil
+		}
+		m.cancelling = false
+		m.messages = append(m.messages, ChatMessage{Role: "assistant", Content: planSummary(msg.calls, msg.results)})
+		switch {
+		case errors.Is(msg.err, errAgentCancelled):
+			m.addChatSystem(fmt.Sprintf("Plan cancelled after %d of %d steps.", len(msg.results), len(msg.calls)))
+		case msg.err != nil:
+			m.addChatError(fmt.Sprintf("Plan stopped: %s. Later steps were not executed.", msg.err))
+		default:
+			m.addChatSystem(fmt.Sprintf("Plan executed (%d steps).", len(msg.calls)))
+		}
+		return m, saveChatSessionCmd(m.sessionSnapshot())
+
 	case chatToolDoneMsg:
 		status := "completed"
 		if msg.err != nil {
@@ -163,29 +284,65 @@ func (m chatModel) Update(msg tea.Msg) (chatModel, tea.Cmd) {
 		}
 		m.entries = append(m.entries, chatEntry{
 			role:    "tool-done",
-			content: fmt.Sprintf("%s %s", msg.name, status),
+			content: fmt.Sprintf("%s %s", toolCallLabel(msg.name, msg.args), status),
 		})
 		m.refreshViewport()
 		return m, nil
 
 	case chatAgentResultMsg:
 		m.thinking = false
-		if msg.err != nil {
+		m.pendingApproval = nil
+		if m.cancel != nil {
+			m.cancel()
+			m.cancel = nil
+		}
+		m.cancelling = false
+		streamed := m.streaming
+		m.streaming = false
+		// Keep tool calls and results in the history so follow-ups and saved sessions see them
+		m.messages = append(m.messages, msg.messages...)
+		var usageCmd tea.Cmd
+		if len(msg.usage) > 0 {
+			run := msg.usage.priced(m.config.Prices)
+			m.sessionUsage.add(run)
+			usageCmd = recordUsageCmd(usageDay(time.Now()), run)
+		}
+		if errors.Is(msg.err, errAgentCancelled) {
+			// Record what already ran so the model does not redo or assume it next time
+			summary := cancelSummary(msg.messages)
+			m.entries = append(m.entries, chatEntry{role: "system", content: summary})
+			m.messages = append(m.messages, ChatMessage{Role: "assistant", Content: summary})
+		} else if msg.err != nil {
 			m.entries = append(m.entries, chatEntry{
 				role:    "error",
 				content: msg.err.Error(),
 			})
 		} else {
-			m.entries = append(m.entries, chatEntry{
-				role:    "assistant",
-				content: msg.response,
-			})
+			if streamed {
+				// Replace the live entry with the final text in case any delta was dropped
+				m.entries[len(m.entries)-1].content = msg.response
+			} else {
+				m.entries = append(m.entries, chatEntry{
+					role:    "assistant",
+					content: msg.response,
+				})
+			}
 			m.messages = append(m.messages, ChatMessage{
 				Role:    "assistant",
 				Content: msg.response,
 			})
 		}
+		// Planned calls accumulate until the plan is run or discarded
+		if len(msg.plan) > 0 {
+			m.plan = append(m.plan, msg.plan...)
+			m.entries = append(m.entries, chatEntry{role: "system", content: formatPlan(m.plan)})
+		}
 		m.refreshViewport()
+		return m, tea.Batch(saveChatSessionCmd(m.sessionSnapshot()), usageCmd)
+
+	case chatUsageRecordedMsg:
+		m.usageDay = msg.day
+		m.todayUsage = msg.today
 		return m, nil
 
 	case chatMCPReadyMsg:
@@ -209,12 +366,23 @@ func (m chatModel) Update(msg tea.Msg) (chatModel, tea.Cmd) {
 			m.mcpInitFailed = true
 			m.mcpInitErr = msg.err.Error()
 		} else {
-			m.mcpClient = msg.client
+			// A router replaced after a server went down is stopped here, between runs
+			if m.router != nil && m.router != msg.router {
+				m.router.Close()
+			}
+			m.router = msg.router
+			m.toolExec = msg.router
 			m.mcpReady = true
 			m.mcpTools = msg.tools
 		}
 		return m, nil
 
+	case chatMCPDownMsg:
+		// The title marks the server ✗; tools are set up again with the next message
+		m.mcpReady = false
+		m.addChatError(fmt.Sprintf("MCP server %s %v. Its tools are unavailable until they are started again with your next message.", msg.server, msg.err))
+		return m, nil
+
 	case chatMCPDownloadProgressMsg:
 		m.entries = append(m.entries, chatEntry{
 			role:    "system",
@@ -238,6 +406,99 @@ func (m chatModel) Update(msg tea.Msg) (chatModel, tea.Cmd) {
 	return m, cmd
 }
 
+// answerApproval unblocks the agent waiting on the pending approval.
+func (m *chatModel) answerApproval(approved bool) {
+	req := m.pendingApproval
+	m.pendingApproval = nil
+	req.reply <- approved
+
+	verdict := "Denied"
+	if approved {
+		verdict = "Approved"
+	}
+	m.entries = append(m.entries, chatEntry{role: "system", content: fmt.Sprintf("%s %s", verdict, req.name)})
+	m.refreshViewport()
+}
+
+// sessionSnapshot captures the conversation for saving to ~/.passgo/chats.
+func (m chatModel) sessionSnapshot() ChatSession {
+	title := m.sessionTitle
+	if title == "" {
+		title = chatSessionTitle(m.messages)
+	}
+	entries := make([]chatSessionEntry, len(m.entries))
+	for i, e := range m.entries {
+		entries[i] = chatSessionEntry{Role: e.role, Content: e.content}
+	}
+	return ChatSession{
+		ID:       m.sessionID,
+		Title:    title,
+		Model:    m.config.Model,
+		Endpoint: m.config.BaseURL,
+		Created:  m.sessionCreated,
+		Updated:  time.Now(),
+		Messages: append([]ChatMessage(nil), m.messages...),
+		Entries:  entries,
+		Usage:    m.sessionUsage,
+	}
+}
+
+// resumeSession replaces the conversation with a saved session.
+func (m *chatModel) resumeSession(s ChatSession) {
+	m.sessionID = s.ID
+	m.sessionTitle = s.Title
+	m.sessionCreated = s.Created
+	m.sessionUsage = s.Usage
+	m.plan = nil // plans are not saved with the session
+
+	m.messages = append([]ChatMessage(nil), s.Messages...)
+	if len(m.messages) == 0 || m.messages[0].Role != "system" {
+		m.messages = append([]ChatMessage{{Role: "system", Content: LLMSystemPrompt}}, m.messages...)
+	}
+
+	m.entries = make([]chatEntry, 0, len(s.Entries)+1)
+	for _, e := range s.Entries {
+		m.entries = append(m.entries, chatEntry{role: e.Role, content: e.Content})
+	}
+	m.entries = append(m.entries, chatEntry{
+		role:    "system",
+		content: fmt.Sprintf("Resumed %q (recorded with %s at %s)", s.Title, s.Model, s.Endpoint),
+	})
+	m.refreshViewport()
+}
+
+// sessionRenamed takes on a title given in the session picker when id is the open session.
+func (m *chatModel) sessionRenamed(id, title string) {
+	if id == m.sessionID {
+		m.sessionTitle = title
+	}
+}
+
+// sessionDeleted detaches the conversation from its saved session when that
+// session was deleted in the picker. The conversation stays on screen and is
+// saved as a new session from now on.
+func (m *chatModel) sessionDeleted(id string) {
+	if id != m.sessionID {
+		return
+	}
+	m.sessionID = newChatSessionID()
+	m.sessionTitle = ""
+	m.sessionCreated = time.Now()
+	m.addChatSystem("The saved copy of this chat was deleted; it is saved as a new session from now on.")
+}
+
+// newSession clears the conversation; the next message starts a new saved session.
+func (m *chatModel) newSession() {
+	fresh := newChatModel()
+	m.sessionID = ""
+	m.sessionTitle = ""
+	m.sessionUsage = usageTotals{}
+	m.messages = fresh.messages
+	m.entries = fresh.entries
+	m.plan = nil
+	m.refreshViewport()
+}
+
 // maxChatEntries caps the displayed chat history to prevent unbounded memory growth.
 const maxChatEntries = 200
 
@@ -261,6 +522,7 @@ func (m chatModel) renderEntries() string {
 		contentWidth = 10
 	}
 
+	block := 1 // code blocks are numbered across the conversation for /copy
 	for _, e := range m.entries {
 		switch e.role {
 		case "user":
@@ -269,8 +531,9 @@ func (m chatModel) renderEntries() string {
 			lines = append(lines, label+text)
 		case "assistant":
 			label := lipgloss.NewStyle().Foreground(t.Running).Bold(true).Render("AI: ")
-			text := lipgloss.NewStyle().Foreground(t.Text).Width(contentWidth - 4).Render(e.content)
-			lines = append(lines, label+text)
+			text, n := renderMarkdown(e.content, contentWidth-4, t, block)
+			block += n
+			lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, label, text))
 		case "tool-start":
 			icon := lipgloss.NewStyle().Foreground(t.Suspended).Render("  > ")
 			text := lipgloss.NewStyle().Foreground(t.TextMuted).Italic(true).Render(e.content)
@@ -279,6 +542,10 @@ func (m chatModel) renderEntries() string {
 			icon := lipgloss.NewStyle().Foreground(t.Running).Render("  < ")
 			text := lipgloss.NewStyle().Foreground(t.TextMuted).Render(e.content)
 			lines = append(lines, icon+text)
+		case "approval":
+			icon := lipgloss.NewStyle().Foreground(t.Suspended).Bold(true).Render("  ? ")
+			text := lipgloss.NewStyle().Foreground(t.Text).Width(contentWidth - 4).Render(e.content)
+			lines = append(lines, icon+text)
 		case "error":
 			label := lipgloss.NewStyle().Foreground(t.Stopped).Bold(true).Render("Error: ")
 			text := lipgloss.NewStyle().Foreground(t.Stopped).Width(contentWidth - 7).Render(e.content)
@@ -293,15 +560,167 @@ func (m chatModel) renderEntries() string {
 	return strings.Join(lines, "\n")
 }
 
+// sendBlocked reports whether a message may not be sent now, explaining why
+// in the chat log: no API key, or the first send past the daily spend limit.
+func (m *chatModel) sendBlocked() bool {
+	// Check if LLM is configured
+	if !m.config.hasAPIKey() && !isLocalEndpoint(m.config.BaseURL) {
+		m.addChatError("No API key configured. Edit ~/.passgo/llm.conf or press L to open settings.")
+		return true
+	}
+
+	// Past the daily spend limit, warn once and let the next Enter send anyway
+	if today := usageDay(time.Now()); m.overSpendLimit(today) && m.spendWarnedDay != today {
+		m.spendWarnedDay = today
+		m.addChatError(fmt.Sprintf("Today's LLM spend is $%.2f, over the $%.2f limit (spend-limit in llm.conf). Press Enter again to send anyway.",
+			m.todayUsage.Cost, m.config.SpendLimit))
+		return true
+	}
+	return false
+}
+
+// newRunContext returns the context for a new run and keeps its cancel for ctrl+c.
+func (m *chatModel) newRunContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	return ctx
+}
+
+// startRun sends the conversation, which must end with the user's message,
+// to the agent. ctx comes from newRunContext.
+func (m *chatModel) startRun(ctx context.Context) tea.Cmd {
+	if m.sessionID == "" {
+		m.sessionID = newChatSessionID()
+		m.sessionCreated = time.Now()
+	}
+	// The spinner is already ticking when mentions were resolved first
+	tick := m.spinner.Tick
+	if m.thinking {
+		tick = nil
+	}
+	m.thinking = true
+	m.refreshViewport()
+
+	ctx = withAuditScope(ctx, auditScope{session: m.sessionID, model: m.config.Model})
+	program := m.program
+	ctx = withLLMRetryReporter(ctx, func(text string) { notifyProgram(program, chatLLMRetryMsg{text: text}) })
+	ctx = withHistorySummary(ctx, m.history)
+	if m.planMode {
+		ctx = withToolPlan(ctx, &toolPlan{})
+	}
+
+	// Update system prompt with current VM state before each run
+	m.messages[0] = ChatMessage{Role: "system", Content: buildSystemPrompt(m.currentVMs)}
+
+	// Built-in tools need no setup, unless extra MCP servers are configured
+	if !m.mcpReady && m.config.Tools == toolBackendNative && len(m.config.MCPServers) == 0 {
+		m.useNativeTools()
+	}
+
+	// Initialize MCP if needed, then run agent
+	if !m.mcpReady && !m.mcpInitFailed {
+		return tea.Batch(tick, m.initMCPAndRunCmd(ctx))
+	}
+	if m.mcpInitFailed {
+		return tea.Batch(tick, m.runAgentWithoutToolsCmd(ctx))
+	}
+	return tea.Batch(tick, m.runAgentCmd(ctx))
+}
+
+// wantsTab reports whether Tab should complete the input instead of switching focus.
+func (m chatModel) wantsTab() bool {
+	_, mention := trailingMention(m.input.Value())
+	return completesAsCommand(m.input.Value()) || mention
+}
+
+// vmNames lists the names of the VMs known to the chat.
+func (m chatModel) vmNames() []string {
+	names := make([]string, len(m.currentVMs))
+	for i, vm := range m.currentVMs {
+		names[i] = vm.info.Name
+	}
+	return names
+}
+
+// cancelRun stops the running request. The agent result arrives as usual and
+// records what had already been executed.
+func (m *chatModel) cancelRun() {
+	if !m.thinking || m.cancel == nil || m.cancelling {
+		return
+	}
+	m.cancel()
+	m.cancelling = true
+	m.entries = append(m.entries, chatEntry{role: "system", content: "Cancelling..."})
+	m.refreshViewport()
+}
+
+// overSpendLimit reports whether today's priced spend has reached the soft limit.
+func (m chatModel) overSpendLimit(today string) bool {
+	return m.config.SpendLimit > 0 && m.usageDay == today && m.todayUsage.Cost >= m.config.SpendLimit
+}
+
+// switchProfile makes the named provider profile active for the rest of the
+// session and persists the choice in llm.conf. Tools stay as they are.
+func (m *chatModel) switchProfile(name string) tea.Cmd {
+	if !m.config.setActiveProfile(name) {
+		return nil
+	}
+	m.llmClient = NewLLMClient(m.config)
+	m.entries = append(m.entries, chatEntry{
+		role:    "system",
+		content: fmt.Sprintf("Switched to profile %s (%s)", m.config.Profile, m.config.Model),
+	})
+	m.refreshViewport()
+
+	cfg := m.config
+	return func() tea.Msg {
+		if err := saveLLMConfig(cfg); err != nil && appLogger != nil {
+			appLogger.Printf("failed to save LLM config: %v", err)
+		}
+		return nil
+	}
+}
+
 // chatTitleText returns the current title string for the chat panel.
 func (m chatModel) chatTitleText() string {
+	if m.cancelling {
+		return m.spinner.View() + " Cancelling..."
+	}
+	if m.pendingApproval != nil {
+		return "Approve tool call? (y/n, ctrl+c: cancel)"
+	}
+	if m.streaming {
+		return m.spinner.View() + " Responding...  ctrl+c: cancel"
+	}
 	if m.thinking {
-		return m.spinner.View() + " Thinking..."
+		return m.spinner.View() + " Thinking...  ctrl+c: cancel"
+	}
+	title := "AI Chat"
+	if m.config.Profile != "" {
+		title += " · " + m.config.Profile
+	}
+	if len(m.plan) > 0 {
+		title += fmt.Sprintf(" · plan: %d steps", len(m.plan))
+	} else if m.planMode {
+		title += " · plan mode"
+	}
+	if m.sessionUsage.Requests > 0 {
+		title += " · " + formatUsage(m.sessionUsage)
+	}
+	if m.todayUsage.Requests > 0 && m.usageDay == usageDay(time.Now()) {
+		title += " · today " + formatUsage(m.todayUsage)
 	}
 	if m.mcpReady {
-		return "AI Chat (MCP)"
+		if m.router != nil {
+			title += " (" + m.router.Status() + ")"
+		} else {
+			title += " (built-in tools)"
+		}
 	}
-	return "AI Chat"
+	if m.focused && len(m.config.Profiles) > 1 {
+		title += "  ctrl+p: profile"
+	}
+	return title
 }
 
 // ViewContent renders the chat content area (viewport + input) without title or outer border.
@@ -471,98 +890,159 @@ func (m *chatModel) Blur() {
 	m.input.Blur()
 }
 
-// initMCPAndRunCmd initializes MCP client, then runs the agent.
+// useNativeTools switches the chat to the built-in tools without starting MCP.
+func (m *chatModel) useNativeTools() {
+	native := newNativeTools()
+	m.toolExec = native
+	m.mcpTools = native.Definitions()
+	m.mcpReady = true
+}
+
+// initMCPAndRunCmd starts the tools, then runs the agent: multipass-mcp (or the
+// built-in tools with `tools=native`) and the extra MCP servers from llm.conf.
+// If multipass-mcp is unavailable, the built-in tools are used unless the config pins `tools=mcp`.
 // All model mutations happen via messages — no direct field writes from the goroutine.
-func (m *chatModel) initMCPAndRunCmd() tea.Cmd {
+func (m *chatModel) initMCPAndRunCmd(ctx context.Context) tea.Cmd {
 	// Capture values needed by the goroutine (avoid reading m.* during execution)
 	program := m.program
 	llmClient := m.llmClient
 	configMCPBinary := m.config.MCPBinary
+	backend := m.config.Tools
+	extraServers := m.config.MCPServers
 	messages := make([]ChatMessage, len(m.messages))
 	copy(messages, m.messages)
 
 	return func() tea.Msg {
-		// Find or download MCP binary
-		binaryPath := findMCPBinary(configMCPBinary)
-		if binaryPath == "" {
-			var downloadErr error
-			binaryPath, downloadErr = downloadMCPBinary(func(msg string) {
-				if program != nil {
-					program.Send(chatMCPDownloadProgressMsg{message: msg})
-				}
-			})
-			if downloadErr != nil {
-				if program != nil {
-					program.Send(chatMCPReadyMsg{err: downloadErr})
-				}
-				return runWithoutTools(llmClient, messages)
-			}
+		progress := func(msg string) {
+			notifyProgram(program, chatMCPDownloadProgressMsg{message: msg})
 		}
 
-		// Start MCP client
-		client, err := NewMCPClient(binaryPath)
-		if err != nil {
-			if program != nil {
-				program.Send(chatMCPReadyMsg{err: err})
-			}
-			return runWithoutTools(llmClient, messages)
+		// Extra servers start while multipass-mcp is found or downloaded
+		extraDone := make(chan []*mcpServer, 1)
+		go func() { extraDone <- startMCPServers(extraServers) }()
+
+		var base ToolExecutor
+		var baseTools []ToolDef
+		var baseErr error
+		if backend == toolBackendNative {
+			native := newNativeTools()
+			base, baseTools = native, native.Definitions()
+		} else if client, tools, err := startMultipassMCP(configMCPBinary, progress); err == nil {
+			base, baseTools = client, tools
+		} else if backend != toolBackendMCP {
+			native := newNativeTools()
+			base, baseTools = native, native.Definitions()
+			progress("MCP tools unavailable (" + err.Error() + "); using built-in tools")
+		} else {
+			baseErr = err
 		}
 
-		// List tools
-		tools, err := client.ListTools()
-		if err != nil {
-			if program != nil {
-				program.Send(chatMCPReadyMsg{err: err})
+		router := newToolRouter(base, baseTools, <-extraDone)
+		router.SetDownHandler(func(server string, err error) {
+			notifyProgram(program, chatMCPDownMsg{server: server, err: err})
+		})
+		for _, s := range router.servers {
+			if s.err != nil {
+				progress(fmt.Sprintf("MCP server %s unavailable: %s", s.name, s.err))
 			}
-			return runWithoutTools(llmClient, messages)
 		}
+		tools := router.Definitions()
 
-		// Notify UI about MCP + store the client via message
-		if program != nil {
-			program.Send(chatMCPInitDoneMsg{client: client, tools: tools})
-			program.Send(chatMCPReadyMsg{tools: tools})
+		// Nothing started: run without tools, as before extra servers existed
+		if len(tools) == 0 && baseErr != nil {
+			router.Close()
+			notifyProgram(program, chatMCPReadyMsg{err: baseErr})
+			return runWithoutTools(ctx, program, llmClient, messages)
 		}
+		if baseErr != nil {
+			progress("MCP tools unavailable: " + baseErr.Error())
+		}
+
+		// Notify UI about the tools + store the router via message
+		notifyProgram(program, chatMCPInitDoneMsg{router: router, tools: tools})
+		notifyProgram(program, chatMCPReadyMsg{tools: tools})
 
 		// Run agent with tools
-		result := RunAgent(context.Background(), program, llmClient, client, messages, tools)
-		return chatAgentResultMsg{response: result.Response, err: result.Err}
+		return newChatAgentResultMsg(RunAgent(ctx, program, llmClient, router, messages, tools))
 	}
 }
 
-// runAgentCmd runs the agent with MCP tools.
-func (m *chatModel) runAgentCmd() tea.Cmd {
+// startMultipassMCP finds (or downloads) multipass-mcp, starts it and lists its tools.
+func startMultipassMCP(configMCPBinary string, progress func(string)) (*MCPClient, []ToolDef, error) {
+	binaryPath := findMCPBinary(configMCPBinary)
+	if binaryPath == "" {
+		var err error
+		binaryPath, err = downloadMCPBinary(progress)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	client, err := NewMCPClient(binaryPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	tools, err := client.ListTools()
+	if err != nil {
+		client.Close()
+		return nil, nil, err
+	}
+	return client, tools, nil
+}
+
+// runAgentCmd runs the agent with the ready tool executor.
+func (m *chatModel) runAgentCmd(ctx context.Context) tea.Cmd {
 	// Capture values
 	program := m.program
 	llmClient := m.llmClient
-	mcpClient := m.mcpClient
+	executor := m.toolExec
+	router := m.router
 	tools := m.mcpTools
 	messages := make([]ChatMessage, len(m.messages))
 	copy(messages, m.messages)
 
 	return func() tea.Msg {
-		result := RunAgent(context.Background(), program, llmClient, mcpClient, messages, tools)
-		return chatAgentResultMsg{response: result.Response, err: result.Err}
+		// Servers may have been restarted or changed their tools since the last run
+		if router != nil {
+			tools = router.Definitions()
+		}
+		return newChatAgentResultMsg(RunAgent(ctx, program, llmClient, executor, messages, tools))
 	}
 }
 
 // runAgentWithoutToolsCmd runs the agent without tools.
-func (m *chatModel) runAgentWithoutToolsCmd() tea.Cmd {
+func (m *chatModel) runAgentWithoutToolsCmd(ctx context.Context) tea.Cmd {
+	program := m.program
 	llmClient := m.llmClient
 	messages := make([]ChatMessage, len(m.messages))
 	copy(messages, m.messages)
 
 	return func() tea.Msg {
-		return runWithoutTools(llmClient, messages)
+		return runWithoutTools(ctx, program, llmClient, messages)
 	}
 }
 
 // runWithoutTools calls the LLM with no tools available. Safe to call from goroutines.
-func runWithoutTools(llmClient *LLMClient, messages []ChatMessage) chatAgentResultMsg {
-	resp, err := llmClient.Chat(context.Background(), messages, nil)
+func runWithoutTools(ctx context.Context, program *tea.Program, llmClient *LLMClient, messages []ChatMessage) chatAgentResultMsg {
+	resp, err := llmClient.ChatStream(ctx, messages, nil, streamToProgram(program))
+	usage := llmClient.TakeUsage()
 	if err != nil {
-		return chatAgentResultMsg{err: err}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("%w: %w", errAgentCancelled, ctx.Err())
+		}
+		return chatAgentResultMsg{usage: usage, err: err}
+	}
+	return chatAgentResultMsg{response: resp.Content, usage: usage}
+}
+
+// toolCallLabel names a tool call with its arguments inline, so concurrent
+// calls to the same tool can be told apart in the chat.
+func toolCallLabel(name, argsJSON string) string {
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(argsJSON), &args); err != nil || len(args) == 0 {
+		return name
 	}
-	return chatAgentResultMsg{response: resp.Content}
+	return truncateToRunes(name+" ("+strings.ReplaceAll(formatToolArgs(args), "\n", ", ")+")", 80)
 }
 
 // isLocalEndpoint checks if the URL points to a local server (no API key needed).
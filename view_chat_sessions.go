@@ -0,0 +1,191 @@
+// view_chat_sessions.go - Saved chat session picker (resume, rename, delete, export)
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type chatSessionsModel struct {
+	sessions      []ChatSession
+	cursor        int
+	renaming      bool
+	renameInput   textinput.Model
+	confirmDelete bool
+	width         int
+	height        int
+}
+
+func newChatSessionsModel(sessions []ChatSession, w, h int) chatSessionsModel {
+	ti := textinput.New()
+	ti.CharLimit = 80
+	ti.Width = 40
+	m := chatSessionsModel{renameInput: ti, width: w, height: h}
+	m.setSessions(sessions)
+	return m
+}
+
+// setSessions replaces the list, keeping the cursor in range.
+func (m *chatSessionsModel) setSessions(sessions []ChatSession) {
+	m.sessions = sessions
+	if m.cursor >= len(sessions) {
+		m.cursor = max(0, len(sessions)-1)
+	}
+}
+
+func (m chatSessionsModel) selected() (ChatSession, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.sessions) {
+		return ChatSession{}, false
+	}
+	return m.sessions[m.cursor], true
+}
+
+func (m chatSessionsModel) Update(msg tea.Msg) (chatSessionsModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		if m.renaming {
+			var cmd tea.Cmd
+			m.renameInput, cmd = m.renameInput.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	if m.renaming {
+		switch keyMsg.String() {
+		case "esc":
+			m.renaming = false
+			m.renameInput.Blur()
+			return m, nil
+		case "enter":
+			title := strings.TrimSpace(m.renameInput.Value())
+			s, ok := m.selected()
+			m.renaming = false
+			m.renameInput.Blur()
+			if !ok || title == "" {
+				return m, nil
+			}
+			return m, renameChatSessionCmd(s.ID, title)
+		}
+		var cmd tea.Cmd
+		m.renameInput, cmd = m.renameInput.Update(keyMsg)
+		return m, cmd
+	}
+
+	if m.confirmDelete {
+		m.confirmDelete = false
+		if s, ok := m.selected(); ok && (keyMsg.String() == "y" || keyMsg.String() == "Y") {
+			return m, deleteChatSessionCmd(s.ID)
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		return m, func() tea.Msg { return backToTableMsg{} }
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.sessions)-1 {
+			m.cursor++
+		}
+	case "n":
+		return m, func() tea.Msg { return chatNewSessionMsg{} }
+	case "enter":
+		if s, ok := m.selected(); ok {
+			return m, loadChatSessionCmd(s.ID)
+		}
+	case "r":
+		if s, ok := m.selected(); ok {
+			m.renaming = true
+			m.renameInput.SetValue(s.Title)
+			m.renameInput.CursorEnd()
+			return m, m.renameInput.Focus()
+		}
+	case "d":
+		if _, ok := m.selected(); ok {
+			m.confirmDelete = true
+		}
+	case "e":
+		if s, ok := m.selected(); ok {
+			return m, exportChatSessionCmd(s.ID)
+		}
+	}
+	return m, nil
+}
+
+func (m chatSessionsModel) View() string {
+	title := formTitleStyle.Render(fmt.Sprintf("Chat Sessions (%d)", len(m.sessions)))
+
+	if len(m.sessions) == 0 {
+		content := title + "\n\n" +
+			tableEmptyStyle.Render("No saved chats yet") + "\n\n" +
+			formHintStyle.Render("n: new chat  Esc: return")
+		box := modalStyle.Render(content)
+		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+	}
+
+	modalW := min(90, m.width-4)
+	innerW := modalW - 8 // padding(3*2) + border(1*2)
+	prefixW := 2
+	updatedW := 17
+	countW := 6
+	modelW := (innerW - prefixW - updatedW - countW) / 3
+	titleW := innerW - prefixW - updatedW - countW - modelW
+
+	header := "  " + tableHeaderStyle.Width(titleW).Render("Title") +
+		tableHeaderStyle.Width(modelW).Render("Model") +
+		tableHeaderStyle.Width(updatedW).Render("Updated") +
+		tableHeaderStyle.Width(countW).Render("Msgs")
+
+	var rows []string
+	for i, s := range m.sessions {
+		selected := i == m.cursor
+		style := tableCellStyle
+		if selected {
+			style = tableSelectedCellStyle
+		}
+		row := style.Width(titleW).Render(truncateToRunes(s.Title, max(1, titleW-2))) +
+			style.Width(modelW).Render(truncateTailToRunes(s.Model, max(1, modelW-2))) +
+			style.Width(updatedW).Render(s.Updated.Local().Format("2006-01-02 15:04")) +
+			style.Width(countW).Render(fmt.Sprintf("%d", len(s.Messages)))
+
+		prefix := "  "
+		if selected {
+			prefix = tableCursorStyle.Render("▎ ")
+		}
+		rows = append(rows, prefix+row)
+	}
+
+	var detail string
+	if s, ok := m.selected(); ok {
+		detail = detailKeyStyle.Render("Model:    ") + detailValStyle.Render(s.Model) + "\n" +
+			detailKeyStyle.Render("Endpoint: ") + detailValStyle.Render(s.Endpoint) + "\n" +
+			detailKeyStyle.Render("Created:  ") + detailValStyle.Render(s.Created.Local().Format("2006-01-02 15:04"))
+	}
+
+	var prompt string
+	switch {
+	case m.renaming:
+		prompt = "\n" + formActiveLabelStyle.Render("New title: ") + m.renameInput.View()
+	case m.confirmDelete:
+		s, _ := m.selected()
+		prompt = "\n" + lipgloss.NewStyle().Foreground(stoppedClr).Render(fmt.Sprintf("Delete %q? y to confirm", s.Title))
+	}
+
+	hint := formHintStyle.Render("Enter: resume  n: new  r: rename  d: delete  e: export Markdown  Esc: return")
+
+	content := title + "\n\n" + header + "\n" +
+		strings.Join(rows, "\n") + "\n\n" +
+		detailPanelStyle.Render(detail) +
+		prompt + "\n\n" + hint
+
+	box := modalStyle.Render(content)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -2,7 +2,10 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,22 +15,65 @@ import (
 // llmSettingsSavedMsg is sent when settings are saved successfully.
 type llmSettingsSavedMsg struct{ config LLMConfig }
 
+// llmSettingsErrMsg reports a save that failed (e.g. a wrong passphrase).
+type llmSettingsErrMsg struct{ err error }
+
+// llmTestMsg carries the result of the Test Connection button.
+type llmTestMsg struct{ result connectionResult }
+
+// llmSettingsKeyMsg carries the current key, resolved from its source, for display.
+type llmSettingsKeyMsg struct {
+	key string
+	err error
+}
+
+// Form rows, in display order.
+const (
+	settingsFieldURL = iota
+	settingsFieldKey
+	settingsFieldKeySource
+	settingsFieldKeyDetail // passphrase, variable name or command, depending on the source
+	settingsFieldModel
+)
+
+// keySourceChoices are the options of the key source row.
+var keySourceChoices = []struct{ source, label string }{
+	{keySourcePlain, "llm.conf (plaintext)"},
+	{keySourceEncrypted, "Encrypted file"},
+	{keySourceEnv, "Environment variable"},
+	{keySourceCommand, "Command"},
+}
+
 type llmSettingsModel struct {
-	fields []llmSettingsField
-	cursor int
-	width  int
-	height int
-	err    string // validation or save error
+	base      LLMConfig // settings not shown in the form are carried through on save
+	fields    []llmSettingsField
+	cursor    int
+	keySource int    // index into keySourceChoices
+	keyStatus string // masked current key and where it came from
+	// picker lists the endpoint's models while open (nil when closed)
+	picker     *modelPickerModel
+	testStatus string // outcome of the last Test Connection
+	width      int
+	height     int
+	err        string // validation or save error
 }
 
 type llmSettingsField struct {
 	label    string
 	input    textinput.Model
 	masked   bool // for API key
+	isChoice bool // cycled with ←/→ instead of typed into
+	isPicker bool
+	isTest   bool
 	isSubmit bool
 	isCancel bool
 }
 
+// isButton reports whether the row is one of the buttons under the form.
+func (f llmSettingsField) isButton() bool {
+	return f.isPicker || f.isTest || f.isSubmit || f.isCancel
+}
+
 func newLLMSettingsModel(cfg LLMConfig, width, height int) llmSettingsModel {
 	urlInput := textinput.New()
 	urlInput.Placeholder = "https://openrouter.ai/api/v1"
@@ -36,9 +82,9 @@ func newLLMSettingsModel(cfg LLMConfig, width, height int) llmSettingsModel {
 	urlInput.Width = 50
 	urlInput.Focus()
 
+	// The key is never shown; typing one replaces it
 	keyInput := textinput.New()
-	keyInput.Placeholder = "(not required for local endpoints)"
-	keyInput.SetValue(cfg.APIKey)
+	keyInput.Placeholder = "(type a new key; empty keeps the current one)"
 	keyInput.CharLimit = 200
 	keyInput.Width = 50
 	keyInput.EchoMode = textinput.EchoPassword
@@ -50,26 +96,145 @@ func newLLMSettingsModel(cfg LLMConfig, width, height int) llmSettingsModel {
 	modelInput.CharLimit = 100
 	modelInput.Width = 50
 
+	detailInput := textinput.New()
+	detailInput.CharLimit = 200
+	detailInput.Width = 50
+
 	fields := []llmSettingsField{
 		{label: "Base URL", input: urlInput},
 		{label: "API Key", input: keyInput, masked: true},
+		{label: "Key Source", isChoice: true},
+		{label: "", input: detailInput},
 		{label: "Model", input: modelInput},
+		{label: "[ Pick Model ]", isPicker: true},
+		{label: "[ Test Connection ]", isTest: true},
 		{label: "[ Save ]", isSubmit: true},
 		{label: "[ Cancel ]", isCancel: true},
 	}
 
-	return llmSettingsModel{
-		fields: fields,
-		width:  width,
-		height: height,
+	m := llmSettingsModel{
+		base:      cfg,
+		fields:    fields,
+		keyStatus: "checking…",
+		width:     width,
+		height:    height,
+	}
+	for i, c := range keySourceChoices {
+		if c.source == cfg.keySource() {
+			m.keySource = i
+		}
 	}
+	m.setKeySource(m.keySource)
+	return m
 }
 
 func (m llmSettingsModel) Init() tea.Cmd {
-	return textinput.Blink
+	cfg := m.base
+	return tea.Batch(textinput.Blink, func() tea.Msg {
+		key, err := resolveAPIKey(cfg)
+		return llmSettingsKeyMsg{key: key, err: err}
+	})
+}
+
+// source returns the selected key source.
+func (m llmSettingsModel) source() string {
+	return keySourceChoices[m.keySource].source
+}
+
+// setKeySource selects a key source and relabels the detail row for it.
+func (m *llmSettingsModel) setKeySource(i int) {
+	m.keySource = (i + len(keySourceChoices)) % len(keySourceChoices)
+	f := &m.fields[settingsFieldKeyDetail]
+	f.input.EchoMode = textinput.EchoNormal
+	f.masked = false
+	switch m.source() {
+	case keySourceEncrypted:
+		f.label = "Passphrase"
+		f.input.Placeholder = "encrypts the key; unlocks it for this session"
+		f.input.EchoMode = textinput.EchoPassword
+		f.input.EchoCharacter = '•'
+		f.masked = true
+		f.input.SetValue("")
+	case keySourceEnv:
+		f.label = "Env Var"
+		f.input.Placeholder = "OPENROUTER_API_KEY"
+		f.input.SetValue(m.base.APIKeyEnv)
+	case keySourceCommand:
+		f.label = "Command"
+		f.input.Placeholder = "pass show openrouter"
+		f.input.SetValue(m.base.APIKeyCommand)
+	default:
+		f.label = ""
+		f.input.Placeholder = ""
+		f.input.SetValue("")
+	}
+}
+
+// skipped reports whether row i is hidden for the selected key source.
+func (m llmSettingsModel) skipped(i int) bool {
+	return i == settingsFieldKeyDetail && m.source() == keySourcePlain
+}
+
+// move shifts the cursor by delta, skipping hidden rows.
+func (m *llmSettingsModel) move(delta int) {
+	m.blurCurrent()
+	m.cursor = (m.cursor + delta + len(m.fields)) % len(m.fields)
+	if m.skipped(m.cursor) {
+		m.cursor = (m.cursor + delta + len(m.fields)) % len(m.fields)
+	}
+	m.focusCurrent()
 }
 
 func (m llmSettingsModel) Update(msg tea.Msg) (llmSettingsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case llmSettingsKeyMsg:
+		switch {
+		case msg.err != nil:
+			m.keyStatus = msg.err.Error()
+		case msg.key == "":
+			m.keyStatus = "none"
+		default:
+			m.keyStatus = fmt.Sprintf("%s (%s)", maskAPIKey(msg.key), m.base.keySourceLabel())
+		}
+		return m, nil
+
+	case llmSettingsErrMsg:
+		m.err = msg.err.Error()
+		return m, nil
+
+	case llmTestMsg:
+		m.testStatus = msg.result.String()
+		return m, nil
+
+	case llmModelsMsg:
+		if m.picker != nil {
+			if msg.err != nil {
+				m.picker.loading = false
+				m.picker.err = "Could not list models: " + msg.err.Error()
+			} else {
+				m.picker.setModels(msg.models, m.fields[settingsFieldModel].input.Value())
+			}
+		}
+		return m, nil
+
+	case modelPickedMsg:
+		m.picker = nil
+		if msg.model != "" {
+			m.fields[settingsFieldModel].input.SetValue(msg.model)
+			m.blurCurrent()
+			m.cursor = settingsFieldModel
+			m.focusCurrent()
+		}
+		return m, nil
+	}
+
+	// The open picker takes all other input
+	if m.picker != nil {
+		var cmd tea.Cmd
+		*m.picker, cmd = m.picker.Update(msg)
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -77,15 +242,11 @@ func (m llmSettingsModel) Update(msg tea.Msg) (llmSettingsModel, tea.Cmd) {
 			return m, func() tea.Msg { return backToTableMsg{} }
 
 		case "tab", "down":
-			m.blurCurrent()
-			m.cursor = (m.cursor + 1) % len(m.fields)
-			m.focusCurrent()
+			m.move(1)
 			return m, nil
 
 		case "shift+tab", "up":
-			m.blurCurrent()
-			m.cursor = (m.cursor - 1 + len(m.fields)) % len(m.fields)
-			m.focusCurrent()
+			m.move(-1)
 			return m, nil
 
 		case "enter":
@@ -93,19 +254,47 @@ func (m llmSettingsModel) Update(msg tea.Msg) (llmSettingsModel, tea.Cmd) {
 			if f.isCancel {
 				return m, func() tea.Msg { return backToTableMsg{} }
 			}
+			if f.isPicker || f.isTest {
+				cfg, err := m.probeConfig()
+				if err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
+				m.err = ""
+				if f.isTest {
+					m.testStatus = "Testing " + cfg.Model + "…"
+					return m, testConnectionCmd(cfg)
+				}
+				picker := newModelPickerModel(m.width, m.height)
+				m.picker = &picker
+				return m, tea.Batch(textinput.Blink, listModelsCmd(cfg))
+			}
 			if f.isSubmit {
-				return m, m.save()
+				cmd, err := m.save()
+				if err != nil {
+					m.err = err.Error()
+					return m, nil
+				}
+				return m, cmd
 			}
 			// Move to next field
-			m.blurCurrent()
-			m.cursor = (m.cursor + 1) % len(m.fields)
-			m.focusCurrent()
+			m.move(1)
 			return m, nil
 		}
 
-		// Forward to current text input
 		f := &m.fields[m.cursor]
-		if !f.isSubmit && !f.isCancel {
+		if f.isChoice {
+			switch msg.String() {
+			case "left", "h":
+				m.setKeySource(m.keySource - 1)
+			case "right", "l", " ":
+				m.setKeySource(m.keySource + 1)
+			}
+			return m, nil
+		}
+
+		// Forward to current text input
+		if !f.isButton() {
 			var cmd tea.Cmd
 			f.input, cmd = f.input.Update(msg)
 			return m, cmd
@@ -114,7 +303,7 @@ func (m llmSettingsModel) Update(msg tea.Msg) (llmSettingsModel, tea.Cmd) {
 
 	// Forward tick messages for cursor blink
 	f := &m.fields[m.cursor]
-	if !f.isSubmit && !f.isCancel {
+	if !f.isButton() && !f.isChoice {
 		var cmd tea.Cmd
 		f.input, cmd = f.input.Update(msg)
 		return m, cmd
@@ -125,22 +314,59 @@ func (m llmSettingsModel) Update(msg tea.Msg) (llmSettingsModel, tea.Cmd) {
 
 func (m *llmSettingsModel) blurCurrent() {
 	f := &m.fields[m.cursor]
-	if !f.isSubmit && !f.isCancel {
+	if !f.isButton() && !f.isChoice {
 		f.input.Blur()
 	}
 }
 
 func (m *llmSettingsModel) focusCurrent() {
 	f := &m.fields[m.cursor]
-	if !f.isSubmit && !f.isCancel {
+	if !f.isButton() && !f.isChoice {
 		f.input.Focus()
 	}
 }
 
-func (m llmSettingsModel) save() tea.Cmd {
-	baseURL := strings.TrimSpace(m.fields[0].input.Value())
-	apiKey := strings.TrimSpace(m.fields[1].input.Value())
-	model := strings.TrimSpace(m.fields[2].input.Value())
+// save validates the form and returns the command that writes it. Only the
+// selected key source is kept; the others are cleared.
+func (m llmSettingsModel) save() (tea.Cmd, error) {
+	cfg, key, err := m.formConfig()
+	if err != nil {
+		return nil, err
+	}
+	return func() tea.Msg {
+		if m.source() == keySourceEncrypted {
+			if err := storeEncryptedKey(&cfg, key); err != nil {
+				return llmSettingsErrMsg{err: err}
+			}
+		}
+		if err := saveLLMConfig(cfg); err != nil {
+			return llmSettingsErrMsg{err: err}
+		}
+		return llmSettingsSavedMsg{config: cfg}
+	}, nil
+}
+
+// probeConfig is the form's config for listing models and testing the
+// connection before anything is saved. A newly typed key is used directly,
+// even when it is going to be encrypted.
+func (m llmSettingsModel) probeConfig() (LLMConfig, error) {
+	cfg, key, err := m.formConfig()
+	if err != nil {
+		return cfg, err
+	}
+	if m.source() == keySourceEncrypted && key != "" {
+		cfg.APIKey, cfg.APIKeyFile = key, ""
+	}
+	return cfg, nil
+}
+
+// formConfig validates the form and returns the config it describes, with the
+// plaintext key to encrypt when the encrypted source is selected.
+func (m llmSettingsModel) formConfig() (LLMConfig, string, error) {
+	baseURL := strings.TrimSpace(m.fields[settingsFieldURL].input.Value())
+	typedKey := strings.TrimSpace(m.fields[settingsFieldKey].input.Value())
+	detail := strings.TrimSpace(m.fields[settingsFieldKeyDetail].input.Value())
+	model := strings.TrimSpace(m.fields[settingsFieldModel].input.Value())
 
 	if baseURL == "" {
 		baseURL = DefaultLLMBaseURL
@@ -149,22 +375,93 @@ func (m llmSettingsModel) save() tea.Cmd {
 		model = DefaultLLMModel
 	}
 
-	cfg := LLMConfig{
-		BaseURL: baseURL,
-		APIKey:  apiKey,
-		Model:   model,
+	cfg := m.base
+	cfg.BaseURL = baseURL
+	cfg.Model = model
+	// A key typed here, or else a plaintext one already in llm.conf
+	key := typedKey
+	if key == "" {
+		key = m.base.APIKey
 	}
+	cfg.APIKey, cfg.APIKeyEnv, cfg.APIKeyCommand = "", "", ""
+	oldFile := cfg.APIKeyFile
+	cfg.APIKeyFile = ""
+
+	switch m.source() {
+	case keySourcePlain:
+		cfg.APIKey = key
+	case keySourceEnv, keySourceCommand:
+		if typedKey != "" {
+			return cfg, "", fmt.Errorf("a typed key can only be stored in llm.conf or an encrypted file")
+		}
+		if detail == "" {
+			return cfg, "", fmt.Errorf("enter the %s", strings.ToLower(m.fields[settingsFieldKeyDetail].label))
+		}
+		if m.source() == keySourceEnv {
+			cfg.APIKeyEnv = detail
+		} else {
+			cfg.APIKeyCommand = detail
+		}
+	case keySourceEncrypted:
+		if detail == "" {
+			return cfg, "", fmt.Errorf("enter the passphrase")
+		}
+		if key == "" && oldFile == "" {
+			return cfg, "", fmt.Errorf("enter the API key to encrypt")
+		}
+		cfg.keyPassphrase = detail
+		cfg.APIKeyFile = oldFile
+	}
+	cfg.syncActiveProfile()
+	return cfg, key, nil
+}
 
+// testConnectionCmd runs the connection test for cfg.
+func testConnectionCmd(cfg LLMConfig) tea.Cmd {
 	return func() tea.Msg {
-		if err := saveLLMConfig(cfg); err != nil {
-			return llmSettingsSavedMsg{config: cfg}
-		}
-		return llmSettingsSavedMsg{config: cfg}
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		return llmTestMsg{result: NewLLMClient(cfg).TestConnection(ctx)}
+	}
+}
+
+// listModelsCmd fetches the models offered by cfg's endpoint.
+func listModelsCmd(cfg LLMConfig) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		models, err := NewLLMClient(cfg).ListModels(ctx)
+		return llmModelsMsg{models: models, err: err}
 	}
 }
 
+// storeEncryptedKey encrypts key into the profile's key file with the
+// config's passphrase or, when key is empty, checks the passphrase against
+// the existing file.
+func storeEncryptedKey(cfg *LLMConfig, key string) error {
+	if key == "" {
+		_, err := readEncryptedAPIKey(cfg.APIKeyFile, cfg.keyPassphrase)
+		return err
+	}
+	path, err := apiKeyFilePath(cfg.Profile)
+	if err != nil {
+		return err
+	}
+	if err := writeEncryptedAPIKey(path, key, cfg.keyPassphrase); err != nil {
+		return fmt.Errorf("encrypt API key: %w", err)
+	}
+	cfg.APIKeyFile = path
+	cfg.syncActiveProfile()
+	return nil
+}
+
 func (m llmSettingsModel) View() string {
-	titleLabel := " ◆ LLM Settings"
+	if m.picker != nil {
+		picker := *m.picker
+		picker.width, picker.height = m.width, m.height
+		return picker.View()
+	}
+	titleLabel := " ◆ LLM Settings — profile: " + m.base.Profile
 	w := min(m.width-4, 70)
 	if w < 40 {
 		w = 40
@@ -201,7 +498,7 @@ func (m llmSettingsModel) View() string {
 	for i, f := range m.fields {
 		active := i == m.cursor
 
-		if f.isSubmit || f.isCancel {
+		if f.isButton() {
 			style := formButtonStyle
 			if active {
 				style = formActiveButtonStyle
@@ -210,6 +507,9 @@ func (m llmSettingsModel) View() string {
 			continue
 		}
 
+		if m.skipped(i) {
+			continue
+		}
 		prefix := "  "
 		if active {
 			prefix = tableCursorStyle.Render("▎ ")
@@ -222,7 +522,12 @@ func (m llmSettingsModel) View() string {
 		label := labelStyle.Render(f.label)
 
 		var value string
-		if active {
+		if f.isChoice {
+			value = "‹ " + keySourceChoices[m.keySource].label + " ›"
+			if !active {
+				value = lipgloss.NewStyle().Foreground(subtle).Render(value)
+			}
+		} else if active {
 			value = f.input.View()
 		} else {
 			display := f.input.Value()
@@ -244,11 +549,18 @@ func (m llmSettingsModel) View() string {
 	tableBox := tableBorderStyle.Width(w + 2).Render(tableContent)
 
 	buttonRow := "  " + strings.Join(buttons, "  ")
+	keyLine := formHintStyle.Render("  Current key: " + m.keyStatus)
+	if m.testStatus != "" {
+		keyLine += "\n" + formHintStyle.Render("  Connection: "+m.testStatus)
+	}
+	if m.err != "" {
+		buttonRow += "\n" + lipgloss.NewStyle().Foreground(currentTheme().Stopped).Render("  "+m.err)
+	}
 
 	presets := formHintStyle.Render("  Presets: OpenRouter → openrouter.ai/api/v1 | Ollama → localhost:11434/v1")
-	hint := formHintStyle.Render("  Tab/↑↓: navigate  Enter: submit  Esc: cancel")
+	hint := formHintStyle.Render("  Tab/↑↓: navigate  ←/→: key source  Enter: submit  Esc: cancel")
 
-	content := titleText + "\n" + tableBox + "\n" + buttonRow + "\n\n" + presets + "\n" + hint
+	content := titleText + "\n" + tableBox + "\n" + keyLine + "\n" + buttonRow + "\n\n" + presets + "\n" + hint
 
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, content)
 }
@@ -43,9 +43,12 @@ func (m helpModel) View() string {
 		{"n", "Create snapshot"},
 		{"m", "Manage snapshots"},
 		{"M", "Manage mounts"},
+		{"␣", "Mark VM (for bulk mount)"},
 		{"v", "Version"},
 		{"?", "Toggle AI chat panel"},
 		{"L", "LLM settings"},
+		{"S", "Chat sessions (resume/rename/export)"},
+		{"A", "Audit log of AI tool calls"},
 		{"1-0", "Switch theme (1-9, 0)"},
 		{"q", "Quit"},
 	}
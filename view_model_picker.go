@@ -0,0 +1,173 @@
+// view_model_picker.go - Searchable list of the endpoint's models, opened from LLM settings
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// modelPickerRows is how many models the picker shows at once.
+const modelPickerRows = 12
+
+// llmModelsMsg carries the models listed by the endpoint.
+type llmModelsMsg struct {
+	models []string
+	err    error
+}
+
+// modelPickedMsg closes the picker; model is "" when it was dismissed.
+type modelPickedMsg struct{ model string }
+
+type modelPickerModel struct {
+	models  []string // all models, sorted
+	matches []string // models matching the filter
+	filter  textinput.Model
+	cursor  int
+	offset  int
+	loading bool
+	err     string
+	width   int
+	height  int
+}
+
+func newModelPickerModel(width, height int) modelPickerModel {
+	ti := textinput.New()
+	ti.Placeholder = "type to filter"
+	ti.CharLimit = 100
+	ti.Width = 40
+	ti.Focus()
+	return modelPickerModel{filter: ti, loading: true, width: width, height: height}
+}
+
+// setModels fills the list once it has loaded and selects current if present.
+func (m *modelPickerModel) setModels(models []string, current string) {
+	m.models = models
+	m.loading = false
+	m.applyFilter()
+	for i, name := range m.matches {
+		if name == current {
+			m.cursor = i
+			m.scroll()
+		}
+	}
+}
+
+// applyFilter keeps the models containing every word of the filter, ignoring case.
+func (m *modelPickerModel) applyFilter() {
+	words := strings.Fields(strings.ToLower(m.filter.Value()))
+	m.matches = m.matches[:0]
+	for _, name := range m.models {
+		lower := strings.ToLower(name)
+		ok := true
+		for _, w := range words {
+			if !strings.Contains(lower, w) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			m.matches = append(m.matches, name)
+		}
+	}
+	m.cursor, m.offset = 0, 0
+}
+
+// scroll keeps the cursor within the visible rows.
+func (m *modelPickerModel) scroll() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+modelPickerRows {
+		m.offset = m.cursor - modelPickerRows + 1
+	}
+}
+
+func (m modelPickerModel) Update(msg tea.Msg) (modelPickerModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		m.filter, cmd = m.filter.Update(msg)
+		return m, cmd
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		return m, func() tea.Msg { return modelPickedMsg{} }
+	case "enter":
+		if m.cursor < len(m.matches) {
+			model := m.matches[m.cursor]
+			return m, func() tea.Msg { return modelPickedMsg{model: model} }
+		}
+		return m, nil
+	case "up", "ctrl+p":
+		if m.cursor > 0 {
+			m.cursor--
+			m.scroll()
+		}
+		return m, nil
+	case "down", "ctrl+n":
+		if m.cursor < len(m.matches)-1 {
+			m.cursor++
+			m.scroll()
+		}
+		return m, nil
+	case "pgup":
+		m.cursor = max(0, m.cursor-modelPickerRows)
+		m.scroll()
+		return m, nil
+	case "pgdown":
+		m.cursor = max(0, min(len(m.matches)-1, m.cursor+modelPickerRows))
+		m.scroll()
+		return m, nil
+	}
+
+	before := m.filter.Value()
+	var cmd tea.Cmd
+	m.filter, cmd = m.filter.Update(keyMsg)
+	if m.filter.Value() != before {
+		m.applyFilter()
+	}
+	return m, cmd
+}
+
+func (m modelPickerModel) View() string {
+	w := min(m.width-8, 70)
+	title := formTitleStyle.Render("Select Model")
+	search := formActiveLabelStyle.Render("Filter: ") + m.filter.View()
+
+	var body string
+	switch {
+	case m.loading:
+		body = tableEmptyStyle.Render("Loading models…")
+	case m.err != "":
+		body = lipgloss.NewStyle().Foreground(currentTheme().Stopped).Render(m.err)
+	case len(m.matches) == 0:
+		body = tableEmptyStyle.Render("No matching models")
+	default:
+		var rows []string
+		end := min(len(m.matches), m.offset+modelPickerRows)
+		for i := m.offset; i < end; i++ {
+			name := truncateTailToRunes(m.matches[i], max(1, w-4))
+			if i == m.cursor {
+				rows = append(rows, tableCursorStyle.Render("▎ ")+tableSelectedCellStyle.Render(name))
+			} else {
+				rows = append(rows, "  "+tableCellStyle.Render(name))
+			}
+		}
+		body = strings.Join(rows, "\n")
+	}
+
+	count := ""
+	if !m.loading && m.err == "" {
+		count = formHintStyle.Render(fmt.Sprintf("%d of %d models", len(m.matches), len(m.models)))
+	}
+	hint := formHintStyle.Render("↑/↓: move  Enter: select  Esc: back")
+
+	content := title + "\n\n" + search + "\n\n" + body + "\n\n" + count + "\n" + hint
+	box := modalStyle.Render(content)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
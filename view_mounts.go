@@ -18,6 +18,7 @@ import (
 type mountManageModel struct {
 	vmName    string
 	mounts    []MountInfo
+	health    map[string]mountHealth // keyed by target path; nil while checking
 	cursor    int
 	action    int // -1=list, 0=modify, 1=delete, 2=cancel
 	inActions bool
@@ -62,6 +63,17 @@ func (m mountManageModel) Update(msg tea.Msg) (mountManageModel, tea.Cmd) {
 			}
 		case "a":
 			return m, func() tea.Msg { return mountAddRequestMsg{vmName: m.vmName} }
+		case "R":
+			return m, reconcileMountsCmd(m.vmName, false)
+		case "H":
+			if len(m.mounts) > 0 {
+				m.health = nil
+				return m, checkMountHealthCmd(m.vmName, m.mounts)
+			}
+		case "r":
+			if len(m.mounts) > 0 && m.cursor < len(m.mounts) {
+				return m, remountCmd(m.vmName, m.mounts[m.cursor])
+			}
 		case "d":
 			if len(m.mounts) > 0 && m.cursor < len(m.mounts) {
 				mount := m.mounts[m.cursor]
@@ -115,7 +127,7 @@ func (m mountManageModel) View() string {
 	if len(m.mounts) == 0 {
 		content := title + "\n\n" +
 			tableEmptyStyle.Render("No mounts configured") + "\n\n" +
-			formHintStyle.Render("a: add mount  Esc: return")
+			formHintStyle.Render("a: add mount  R: reapply saved  Esc: return")
 		box := modalStyle.Render(content)
 		return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
 	}
@@ -125,11 +137,13 @@ func (m mountManageModel) View() string {
 	innerW := modalW - 8 // padding(3*2) + border(1*2)
 	arrowW := 4
 	prefixW := 2
-	srcW := (innerW - prefixW - arrowW) / 2
-	tgtW := innerW - prefixW - arrowW - srcW
+	statusW := 16
+	srcW := (innerW - prefixW - arrowW - statusW) / 2
+	tgtW := innerW - prefixW - arrowW - statusW - srcW
 	header := "  " + tableHeaderStyle.Width(srcW).Render("Source (Local)") +
 		tableHeaderStyle.Width(arrowW).Render(" → ") +
-		tableHeaderStyle.Width(tgtW).Render("Target (VM)")
+		tableHeaderStyle.Width(tgtW).Render("Target (VM)") +
+		tableHeaderStyle.Width(statusW).Render("Status")
 
 	var rows []string
 	for i, mount := range m.mounts {
@@ -144,9 +158,21 @@ func (m mountManageModel) View() string {
 			style = tableSelectedCellStyle
 		}
 
+		status := "…"
+		statusStyle := style.Foreground(subtle)
+		if h, ok := m.health[mount.TargetPath]; ok {
+			status = h.label()
+			if h.healthy() {
+				statusStyle = style.Foreground(runningClr)
+			} else {
+				statusStyle = style.Foreground(stoppedClr)
+			}
+		}
+
 		row := style.Width(srcW).Render(src) +
 			style.Width(4).Render(" → ") +
-			style.Width(tgtW).Render(tgt)
+			style.Width(tgtW).Render(tgt) +
+			statusStyle.Width(statusW).Render(status)
 
 		prefix := "  "
 		if selected {
@@ -161,6 +187,10 @@ func (m mountManageModel) View() string {
 		mount := m.mounts[m.cursor]
 		detail = detailKeyStyle.Render("Source: ") + detailValStyle.Render(mount.SourcePath) + "\n" +
 			detailKeyStyle.Render("Target: ") + detailValStyle.Render(mount.TargetPath)
+		if h, ok := m.health[mount.TargetPath]; ok && !h.healthy() {
+			detail += "\n" + detailKeyStyle.Render("Health: ") +
+				lipgloss.NewStyle().Foreground(stoppedClr).Render(h.detail)
+		}
 	}
 
 	// Actions
@@ -178,7 +208,7 @@ func (m mountManageModel) View() string {
 		actionsLine = "\n" + strings.Join(buttons, "  ")
 	}
 
-	hint := formHintStyle.Render("a: add  e: modify  d: delete  Enter: actions  Esc: return")
+	hint := formHintStyle.Render("a: add  e: modify  d: delete  r: remount  H: recheck  R: reapply saved  Enter: actions  Esc: return")
 
 	content := title + "\n\n" + header + "\n" +
 		strings.Join(rows, "\n") + "\n\n" +
@@ -201,11 +231,51 @@ type mountAddModel struct {
 	dirOffset  int
 	showHidden bool
 	// Target form
-	sourceInput textinput.Model
-	targetInput textinput.Model
-	formCursor  int // 0=source, 1=target, 2=mount, 3=cancel
-	width       int
-	height      int
+	fields       mountFormFields
+	targetGroups []mountTargetGroup // first entry is always just vmName
+	targetIdx    int
+	formCursor   int // 0-4=fields (see mountField*), 5=target VMs, 6=mount, 7=cancel
+	err          string
+	width        int
+	height       int
+}
+
+// mountTargetGroup is a named set of VMs the add form can mount into at once.
+type mountTargetGroup struct {
+	label string
+	vms   []string
+}
+
+// buildMountTargetGroups returns the VM sets offered by the add form: the VM the
+// mount manager was opened for, then all running VMs, running VMs matching the
+// table filter, and running VMs marked in the table. Empty sets are omitted.
+func buildMountTargetGroups(vmName string, t tableModel) []mountTargetGroup {
+	groups := []mountTargetGroup{{label: vmName, vms: []string{vmName}}}
+
+	running := func(vms []vmData, keep func(vmData) bool) []string {
+		var names []string
+		for _, vm := range vms {
+			if vm.info.State == "Running" && keep(vm) {
+				names = append(names, vm.info.Name)
+			}
+		}
+		return names
+	}
+	all := func(vmData) bool { return true }
+
+	if names := running(t.vms, all); len(names) > 1 {
+		groups = append(groups, mountTargetGroup{label: fmt.Sprintf("All running (%d)", len(names)), vms: names})
+	}
+	if t.filterText != "" {
+		if names := running(t.filteredVMs, all); len(names) > 0 {
+			groups = append(groups, mountTargetGroup{label: fmt.Sprintf("Filter %q (%d)", t.filterText, len(names)), vms: names})
+		}
+	}
+	marked := running(t.vms, func(vm vmData) bool { return t.marked[vm.info.Name] })
+	if len(marked) > 0 {
+		groups = append(groups, mountTargetGroup{label: fmt.Sprintf("Marked (%d)", len(marked)), vms: marked})
+	}
+	return groups
 }
 
 func newMountAddModel(vmName string, w, h int) mountAddModel {
@@ -214,20 +284,17 @@ func newMountAddModel(vmName string, w, h int) mountAddModel {
 		homeDir = "/"
 	}
 
-	si := textinput.New()
-	si.CharLimit = 200
-
-	ti := textinput.New()
-	ti.CharLimit = 200
-
 	m := mountAddModel{
-		vmName:      vmName,
-		phase:       0,
-		currentDir:  homeDir,
-		sourceInput: si,
-		targetInput: ti,
-		width:       w,
-		height:      h,
+		vmName:     vmName,
+		phase:      0,
+		currentDir: homeDir,
+		fields: newMountFormFields(MountProfile{
+			UIDMaps: defaultMountIDMap(os.Getuid()),
+			GIDMaps: defaultMountIDMap(os.Getgid()),
+		}),
+		targetGroups: []mountTargetGroup{{label: vmName, vms: []string{vmName}}},
+		width:        w,
+		height:       h,
 	}
 	m.loadDir()
 	return m
@@ -269,16 +336,7 @@ func (m mountAddModel) Update(msg tea.Msg) (mountAddModel, tea.Cmd) {
 	default:
 		// Blink for target form
 		if m.phase == 1 {
-			switch m.formCursor {
-			case 0:
-				var cmd tea.Cmd
-				m.sourceInput, cmd = m.sourceInput.Update(msg)
-				return m, cmd
-			case 1:
-				var cmd tea.Cmd
-				m.targetInput, cmd = m.targetInput.Update(msg)
-				return m, cmd
-			}
+			return m, m.fields.update(m.formCursor, msg)
 		}
 	}
 	return m, nil
@@ -310,11 +368,13 @@ func (m mountAddModel) updateBrowser(msg tea.KeyMsg) (mountAddModel, tea.Cmd) {
 			selectedDir = filepath.Join(m.currentDir, m.entries[m.dirCursor].Name())
 		}
 		m.phase = 1
-		m.sourceInput.SetValue(selectedDir)
+		m.err = ""
+		m.fields.sourceInput.SetValue(selectedDir)
 		baseName := filepath.Base(selectedDir)
-		m.targetInput.SetValue("/home/ubuntu/" + baseName)
-		m.targetInput.Focus()
-		m.formCursor = 1
+		m.fields.targetInput.SetValue("/home/ubuntu/" + baseName)
+		m.fields.blur()
+		m.formCursor = mountFieldTarget
+		m.fields.focus(m.formCursor)
 		return m, textinput.Blink
 	case " ":
 		// Enter selected subdirectory
@@ -338,64 +398,56 @@ func (m mountAddModel) updateBrowser(msg tea.KeyMsg) (mountAddModel, tea.Cmd) {
 }
 
 func (m mountAddModel) updateTargetForm(msg tea.KeyMsg) (mountAddModel, tea.Cmd) {
+	const targetsRow, mountBtn, cancelBtn = mountFieldCount, mountFieldCount + 1, mountFieldCount + 2
+	const positions = mountFieldCount + 3
 	switch msg.String() {
 	case "esc":
 		m.phase = 0
 		return m, nil
 	case "tab", "down":
-		m.blurForm()
-		m.formCursor = (m.formCursor + 1) % 4
-		m.focusForm()
+		m.fields.blur()
+		m.formCursor = (m.formCursor + 1) % positions
+		m.fields.focus(m.formCursor)
 		return m, nil
 	case "shift+tab", "up":
-		m.blurForm()
-		m.formCursor = (m.formCursor - 1 + 4) % 4
-		m.focusForm()
+		m.fields.blur()
+		m.formCursor = (m.formCursor - 1 + positions) % positions
+		m.fields.focus(m.formCursor)
 		return m, nil
 	case "enter":
-		if m.formCursor == 3 { // cancel
+		if m.formCursor == cancelBtn {
 			m.phase = 0
 			return m, nil
 		}
-		if m.formCursor == 2 { // mount
-			source := m.sourceInput.Value()
-			target := m.targetInput.Value()
-			if source == "" || target == "" {
+		if m.formCursor == mountBtn {
+			mount, err := m.fields.profile()
+			if err != nil {
+				m.err = err.Error()
 				return m, nil
 			}
-			return m, mountCmd(source, m.vmName, target)
+			vms := m.targetGroups[m.targetIdx].vms
+			if len(vms) == 1 && vms[0] == m.vmName {
+				return m, mountCmd(m.vmName, mount)
+			}
+			return m, bulkMountCmd(vms, mount)
 		}
-		m.blurForm()
-		m.formCursor = (m.formCursor + 1) % 4
-		m.focusForm()
+		m.fields.blur()
+		m.formCursor = (m.formCursor + 1) % positions
+		m.fields.focus(m.formCursor)
 		return m, nil
+	case "left", "h":
+		if m.formCursor == targetsRow {
+			m.targetIdx = (m.targetIdx - 1 + len(m.targetGroups)) % len(m.targetGroups)
+			return m, nil
+		}
+	case "right", "l", " ":
+		if m.formCursor == targetsRow {
+			m.targetIdx = (m.targetIdx + 1) % len(m.targetGroups)
+			return m, nil
+		}
 	}
 
-	switch m.formCursor {
-	case 0:
-		var cmd tea.Cmd
-		m.sourceInput, cmd = m.sourceInput.Update(msg)
-		return m, cmd
-	case 1:
-		var cmd tea.Cmd
-		m.targetInput, cmd = m.targetInput.Update(msg)
-		return m, cmd
-	}
-	return m, nil
-}
-
-func (m *mountAddModel) blurForm() {
-	m.sourceInput.Blur()
-	m.targetInput.Blur()
-}
-
-func (m *mountAddModel) focusForm() {
-	switch m.formCursor {
-	case 0:
-		m.sourceInput.Focus()
-	case 1:
-		m.targetInput.Focus()
-	}
+	return m, m.fields.update(m.formCursor, msg)
 }
 
 func (m mountAddModel) View() string {
@@ -445,42 +497,35 @@ func (m mountAddModel) viewBrowser() string {
 func (m mountAddModel) viewTargetForm() string {
 	title := formTitleStyle.Render(fmt.Sprintf("Add Mount to: %s", m.vmName))
 
-	srcLabel := formLabelStyle.Render("Source (Local):")
-	tgtLabel := formLabelStyle.Render("Target (VM):")
-	if m.formCursor == 0 {
-		srcLabel = formActiveLabelStyle.Render("Source (Local):")
+	// Target VMs row (add form only)
+	group := m.targetGroups[m.targetIdx]
+	targetsLabel := formLabelStyle.Render("Into VMs:")
+	targetsVal := formValueStyle.Render(group.label)
+	if m.formCursor == mountFieldCount {
+		targetsLabel = formActiveLabelStyle.Render("Into VMs:")
+		arrow := lipgloss.NewStyle().Foreground(accent)
+		targetsVal = arrow.Render("◀ ") + targetsVal + arrow.Render(" ▶")
 	}
-	if m.formCursor == 1 {
-		tgtLabel = formActiveLabelStyle.Render("Target (VM):")
-	}
-
-	var srcVal, tgtVal string
-	if m.formCursor == 0 {
-		srcVal = m.sourceInput.View()
-	} else {
-		srcVal = formValueStyle.Render(m.sourceInput.Value())
-	}
-	if m.formCursor == 1 {
-		tgtVal = m.targetInput.View()
-	} else {
-		tgtVal = formValueStyle.Render(m.targetInput.Value())
+	targetsRow := fmt.Sprintf("  %s  %s\n", lipgloss.NewStyle().Width(16).Render(targetsLabel), targetsVal)
+	if len(group.vms) > 1 {
+		names := truncateToRunes(strings.Join(group.vms, ", "), 60)
+		targetsRow += fmt.Sprintf("  %s  %s\n", lipgloss.NewStyle().Width(16).Render(""), formHintStyle.Render(names))
 	}
 
 	mountStyle := formButtonStyle
 	cancelStyle := formButtonStyle
-	if m.formCursor == 2 {
+	if m.formCursor == mountFieldCount+1 {
 		mountStyle = formActiveButtonStyle
 	}
-	if m.formCursor == 3 {
+	if m.formCursor == mountFieldCount+2 {
 		cancelStyle = formActiveButtonStyle
 	}
 
-	hint := formHintStyle.Render("Tab: navigate  Enter: submit  Esc: back to browser")
+	hint := formHintStyle.Render("Tab: navigate  ←→: change option  Enter: submit  Esc: back to browser")
 
-	content := title + "\n\n" +
-		fmt.Sprintf("  %s  %s\n", lipgloss.NewStyle().Width(16).Render(srcLabel), srcVal) +
-		fmt.Sprintf("  %s  %s\n\n", lipgloss.NewStyle().Width(16).Render(tgtLabel), tgtVal) +
-		"  " + mountStyle.Render("[ Mount ]") + "  " + cancelStyle.Render("[ Cancel ]") + "\n\n" + hint
+	content := title + "\n\n" + m.fields.view(m.formCursor) + targetsRow + "\n" +
+		"  " + mountStyle.Render("[ Mount ]") + "  " + cancelStyle.Render("[ Cancel ]") + "\n" +
+		renderMountFormError(m.err) + "\n" + hint
 
 	box := modalStyle.Render(content)
 	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
@@ -489,32 +534,25 @@ func (m mountAddModel) viewTargetForm() string {
 // ─── Mount Modify ──────────────────────────────────────────────────────────────
 
 type mountModifyModel struct {
-	vmName      string
-	oldMount    MountInfo
-	sourceInput textinput.Model
-	targetInput textinput.Model
-	cursor      int // 0=source, 1=target, 2=save, 3=cancel
-	width       int
-	height      int
+	vmName   string
+	oldMount MountInfo
+	fields   mountFormFields
+	cursor   int // 0-4=fields (see mountField*), 5=save, 6=cancel
+	err      string
+	width    int
+	height   int
 }
 
 func newMountModifyModel(vmName string, mount MountInfo, w, h int) mountModifyModel {
-	si := textinput.New()
-	si.SetValue(mount.SourcePath)
-	si.CharLimit = 200
-	si.Focus()
-
-	ti := textinput.New()
-	ti.SetValue(mount.TargetPath)
-	ti.CharLimit = 200
+	fields := newMountFormFields(profileForMount(vmName, mount))
+	fields.focus(mountFieldSource)
 
 	return mountModifyModel{
-		vmName:      vmName,
-		oldMount:    mount,
-		sourceInput: si,
-		targetInput: ti,
-		width:       w,
-		height:      h,
+		vmName:   vmName,
+		oldMount: mount,
+		fields:   fields,
+		width:    w,
+		height:   h,
 	}
 }
 
@@ -522,132 +560,251 @@ func newMountModifyModel(vmName string, mount MountInfo, w, h int) mountModifyMo
 type mountModifySubmitMsg struct {
 	vmName    string
 	oldTarget string
-	newSource string
-	newTarget string
+	newMount  MountProfile
 }
 
 func (m mountModifyModel) Init() tea.Cmd { return textinput.Blink }
 
 func (m mountModifyModel) Update(msg tea.Msg) (mountModifyModel, tea.Cmd) {
+	const saveBtn, cancelBtn = mountFieldCount, mountFieldCount + 1
+	const positions = mountFieldCount + 2
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "esc":
 			return m, func() tea.Msg { return backToTableMsg{} }
 		case "tab", "down":
-			m.blur()
-			m.cursor = (m.cursor + 1) % 4
-			m.focus()
+			m.fields.blur()
+			m.cursor = (m.cursor + 1) % positions
+			m.fields.focus(m.cursor)
 			return m, nil
 		case "shift+tab", "up":
-			m.blur()
-			m.cursor = (m.cursor - 1 + 4) % 4
-			m.focus()
+			m.fields.blur()
+			m.cursor = (m.cursor - 1 + positions) % positions
+			m.fields.focus(m.cursor)
 			return m, nil
 		case "enter":
-			if m.cursor == 3 {
+			if m.cursor == cancelBtn {
 				return m, func() tea.Msg { return backToTableMsg{} }
 			}
-			if m.cursor == 2 {
-				src := m.sourceInput.Value()
-				tgt := m.targetInput.Value()
-				if src == "" || tgt == "" {
+			if m.cursor == saveBtn {
+				mount, err := m.fields.profile()
+				if err != nil {
+					m.err = err.Error()
 					return m, nil
 				}
 				return m, func() tea.Msg {
 					return mountModifySubmitMsg{
 						vmName:    m.vmName,
 						oldTarget: m.oldMount.TargetPath,
-						newSource: src,
-						newTarget: tgt,
+						newMount:  mount,
 					}
 				}
 			}
-			m.blur()
-			m.cursor = (m.cursor + 1) % 4
-			m.focus()
+			m.fields.blur()
+			m.cursor = (m.cursor + 1) % positions
+			m.fields.focus(m.cursor)
 			return m, nil
 		}
-
-		switch m.cursor {
-		case 0:
-			var cmd tea.Cmd
-			m.sourceInput, cmd = m.sourceInput.Update(msg)
-			return m, cmd
-		case 1:
-			var cmd tea.Cmd
-			m.targetInput, cmd = m.targetInput.Update(msg)
-			return m, cmd
-		}
-	default:
-		if m.cursor == 0 {
-			var cmd tea.Cmd
-			m.sourceInput, cmd = m.sourceInput.Update(msg)
-			return m, cmd
-		}
-		if m.cursor == 1 {
-			var cmd tea.Cmd
-			m.targetInput, cmd = m.targetInput.Update(msg)
-			return m, cmd
-		}
 	}
-	return m, nil
+	return m, m.fields.update(m.cursor, msg)
 }
 
-func (m *mountModifyModel) blur() {
-	m.sourceInput.Blur()
-	m.targetInput.Blur()
+func (m mountModifyModel) View() string {
+	title := formTitleStyle.Render(fmt.Sprintf("Modify Mount for: %s", m.vmName))
+
+	saveStyle := formButtonStyle
+	cancelStyle := formButtonStyle
+	if m.cursor == mountFieldCount {
+		saveStyle = formActiveButtonStyle
+	}
+	if m.cursor == mountFieldCount+1 {
+		cancelStyle = formActiveButtonStyle
+	}
+
+	hint := formHintStyle.Render("Tab: navigate  ←→: mount type  Enter: submit  Esc: cancel")
+
+	content := title + "\n\n" + m.fields.view(m.cursor) + "\n" +
+		"  " + saveStyle.Render("[ Save ]") + "  " + cancelStyle.Render("[ Cancel ]") + "\n" +
+		renderMountFormError(m.err) + "\n" + hint
+
+	box := modalStyle.Render(content)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
 }
 
-func (m *mountModifyModel) focus() {
-	switch m.cursor {
-	case 0:
-		m.sourceInput.Focus()
-	case 1:
-		m.targetInput.Focus()
+// ─── Shared Mount Fields ───────────────────────────────────────────────────────
+
+// mountTypes are the values accepted by multipass mount --type; "" leaves the
+// choice to multipass.
+var mountTypes = []string{"", "classic", "native"}
+
+// mountTypeLabel names a mount type for the type selector.
+func mountTypeLabel(t string) string {
+	if t == "" {
+		return "default"
 	}
+	return t
 }
 
-func (m mountModifyModel) View() string {
-	title := formTitleStyle.Render(fmt.Sprintf("Modify Mount for: %s", m.vmName))
+// Field indexes shared by the add and modify forms; buttons follow the fields.
+const (
+	mountFieldSource = iota
+	mountFieldTarget
+	mountFieldUIDMap
+	mountFieldGIDMap
+	mountFieldType
+	mountFieldCount
+)
 
-	srcLabel := formLabelStyle.Render("Source (Local):")
-	tgtLabel := formLabelStyle.Render("Target (VM):")
-	if m.cursor == 0 {
-		srcLabel = formActiveLabelStyle.Render("Source (Local):")
+// mountFormFields holds the editable mount settings shared by the add and modify forms.
+type mountFormFields struct {
+	sourceInput textinput.Model
+	targetInput textinput.Model
+	uidInput    textinput.Model
+	gidInput    textinput.Model
+	typeIdx     int
+}
+
+func newMountFormFields(mount MountProfile) mountFormFields {
+	newInput := func(value, placeholder string) textinput.Model {
+		ti := textinput.New()
+		ti.CharLimit = 200
+		ti.Placeholder = placeholder
+		ti.SetValue(value)
+		return ti
 	}
-	if m.cursor == 1 {
-		tgtLabel = formActiveLabelStyle.Render("Target (VM):")
+
+	typeIdx := 0
+	for i, t := range mountTypes {
+		if t == mount.Type {
+			typeIdx = i
+		}
 	}
 
-	var srcVal, tgtVal string
-	if m.cursor == 0 {
-		srcVal = m.sourceInput.View()
-	} else {
-		srcVal = formValueStyle.Render(m.sourceInput.Value())
+	return mountFormFields{
+		sourceInput: newInput(mount.Source, ""),
+		targetInput: newInput(mount.Target, ""),
+		uidInput:    newInput(formatMountMaps(mount.UIDMaps), "host:vm, e.g. 501:1000"),
+		gidInput:    newInput(formatMountMaps(mount.GIDMaps), "host:vm, e.g. 20:1000"),
+		typeIdx:     typeIdx,
 	}
-	if m.cursor == 1 {
-		tgtVal = m.targetInput.View()
-	} else {
-		tgtVal = formValueStyle.Render(m.targetInput.Value())
+}
+
+// input returns the text input for a field index, or nil for the type selector and buttons.
+func (f *mountFormFields) input(field int) *textinput.Model {
+	switch field {
+	case mountFieldSource:
+		return &f.sourceInput
+	case mountFieldTarget:
+		return &f.targetInput
+	case mountFieldUIDMap:
+		return &f.uidInput
+	case mountFieldGIDMap:
+		return &f.gidInput
+	}
+	return nil
+}
+
+func (f *mountFormFields) blur() {
+	f.sourceInput.Blur()
+	f.targetInput.Blur()
+	f.uidInput.Blur()
+	f.gidInput.Blur()
+}
+
+func (f *mountFormFields) focus(field int) {
+	if in := f.input(field); in != nil {
+		in.Focus()
 	}
+}
 
-	saveStyle := formButtonStyle
-	cancelStyle := formButtonStyle
-	if m.cursor == 2 {
-		saveStyle = formActiveButtonStyle
+// update forwards msg to the focused field. ←/→ cycle the mount type.
+func (f *mountFormFields) update(field int, msg tea.Msg) tea.Cmd {
+	if field == mountFieldType {
+		if key, ok := msg.(tea.KeyMsg); ok {
+			switch key.String() {
+			case "left", "h":
+				f.typeIdx = (f.typeIdx - 1 + len(mountTypes)) % len(mountTypes)
+			case "right", "l", " ":
+				f.typeIdx = (f.typeIdx + 1) % len(mountTypes)
+			}
+		}
+		return nil
 	}
-	if m.cursor == 3 {
-		cancelStyle = formActiveButtonStyle
+	in := f.input(field)
+	if in == nil {
+		return nil
+	}
+	var cmd tea.Cmd
+	*in, cmd = in.Update(msg)
+	return cmd
+}
+
+// profile validates the fields and returns the mount they describe.
+func (f mountFormFields) profile() (MountProfile, error) {
+	source := strings.TrimSpace(f.sourceInput.Value())
+	target := strings.TrimSpace(f.targetInput.Value())
+	if source == "" || target == "" {
+		return MountProfile{}, fmt.Errorf("source and target are required")
+	}
+	uidMaps, err := parseMountMaps(f.uidInput.Value())
+	if err != nil {
+		return MountProfile{}, fmt.Errorf("UID map: %w", err)
 	}
+	gidMaps, err := parseMountMaps(f.gidInput.Value())
+	if err != nil {
+		return MountProfile{}, fmt.Errorf("GID map: %w", err)
+	}
+	return MountProfile{
+		Source:  source,
+		Target:  target,
+		UIDMaps: uidMaps,
+		GIDMaps: gidMaps,
+		Type:    mountTypes[f.typeIdx],
+	}, nil
+}
 
-	hint := formHintStyle.Render("Tab: navigate  Enter: submit  Esc: cancel")
+func (f mountFormFields) view(cursor int) string {
+	rows := []struct {
+		field int
+		label string
+	}{
+		{mountFieldSource, "Source (Local):"},
+		{mountFieldTarget, "Target (VM):"},
+		{mountFieldUIDMap, "UID map:"},
+		{mountFieldGIDMap, "GID map:"},
+		{mountFieldType, "Mount type:"},
+	}
+
+	var b strings.Builder
+	for _, r := range rows {
+		active := cursor == r.field
+		label := formLabelStyle.Render(r.label)
+		if active {
+			label = formActiveLabelStyle.Render(r.label)
+		}
 
-	content := title + "\n\n" +
-		fmt.Sprintf("  %s  %s\n", lipgloss.NewStyle().Width(16).Render(srcLabel), srcVal) +
-		fmt.Sprintf("  %s  %s\n\n", lipgloss.NewStyle().Width(16).Render(tgtLabel), tgtVal) +
-		"  " + saveStyle.Render("[ Save ]") + "  " + cancelStyle.Render("[ Cancel ]") + "\n\n" + hint
+		var val string
+		switch {
+		case r.field == mountFieldType && active:
+			arrow := lipgloss.NewStyle().Foreground(accent)
+			val = arrow.Render("◀ ") + formValueStyle.Render(mountTypeLabel(mountTypes[f.typeIdx])) + arrow.Render(" ▶")
+		case r.field == mountFieldType:
+			val = formValueStyle.Render(mountTypeLabel(mountTypes[f.typeIdx]))
+		case active:
+			val = f.input(r.field).View()
+		default:
+			val = formValueStyle.Render(f.input(r.field).Value())
+		}
+		fmt.Fprintf(&b, "  %s  %s\n", lipgloss.NewStyle().Width(16).Render(label), val)
+	}
+	return b.String()
+}
 
-	box := modalStyle.Render(content)
-	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+// renderMountFormError renders a validation error line (empty when err is "").
+func renderMountFormError(err string) string {
+	if err == "" {
+		return ""
+	}
+	return "\n" + lipgloss.NewStyle().Foreground(stoppedClr).Render("  "+err) + "\n"
 }
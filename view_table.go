@@ -118,6 +118,9 @@ type tableModel struct {
 
 	// Toast notifications
 	toasts []toast
+
+	// VMs marked with Space for multi-VM actions (keyed by name)
+	marked map[string]bool
 }
 
 // addToast adds a toast notification and returns a command to dismiss it later.
@@ -154,6 +157,7 @@ func newTableModel() tableModel {
 		sortColumn:    0,
 		sortAscending: true,
 		busyVMs:       make(map[string]busyInfo),
+		marked:        make(map[string]bool),
 		spinner:       s,
 		columns: []tableColumn{
 			{title: "Name", width: 12, minWidth: 8, priority: 0}, // width set dynamically
@@ -171,6 +175,19 @@ func newTableModel() tableModel {
 
 func (m *tableModel) setVMs(vms []vmData) {
 	m.vms = vms
+	// Drop marks of VMs that no longer exist, so a new VM with the same name
+	// does not come back marked
+	if len(m.marked) > 0 {
+		present := make(map[string]bool, len(vms))
+		for _, vm := range vms {
+			present[vm.info.Name] = true
+		}
+		for name := range m.marked {
+			if !present[name] {
+				delete(m.marked, name)
+			}
+		}
+	}
 	m.applyFilterAndSort()
 	if m.cursor >= len(m.filteredVMs) {
 		m.cursor = max(0, len(m.filteredVMs)-1)
@@ -195,6 +212,37 @@ func (m *tableModel) selectedVM() (VMInfo, bool) {
 	return VMInfo{}, false
 }
 
+// selectVM moves the cursor to the named VM, clearing a filter that hides it.
+// It reports whether the VM was found.
+func (m *tableModel) selectVM(name string) bool {
+	idx := m.filteredIndex(name)
+	if idx < 0 && m.filterText != "" {
+		m.filterText = ""
+		m.filterInput.SetValue("")
+		m.filterVisible = false
+		m.applyFilterAndSort()
+		idx = m.filteredIndex(name)
+	}
+	if idx < 0 {
+		return false
+	}
+	m.cursor = idx
+	if visible := m.visibleRows(); m.cursor < m.offset || m.cursor >= m.offset+visible {
+		m.offset = max(0, m.cursor-visible/2)
+	}
+	return true
+}
+
+// filteredIndex returns the row of the named VM, or -1 when it is not shown.
+func (m *tableModel) filteredIndex(name string) int {
+	for i, vm := range m.filteredVMs {
+		if vm.info.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
 func (m *tableModel) allVMNames() []string {
 	var names []string
 	for _, vm := range m.vms {
@@ -203,6 +251,28 @@ func (m *tableModel) allVMNames() []string {
 	return names
 }
 
+// toggleMark marks or unmarks the selected VM and moves the cursor down.
+func (m *tableModel) toggleMark() {
+	vm, ok := m.selectedVM()
+	if !ok {
+		return
+	}
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	if m.marked[vm.Name] {
+		delete(m.marked, vm.Name)
+	} else {
+		m.marked[vm.Name] = true
+	}
+	if m.cursor < len(m.filteredVMs)-1 {
+		m.cursor++
+		if visible := m.visibleRows(); m.cursor >= m.offset+visible {
+			m.offset = m.cursor - visible + 1
+		}
+	}
+}
+
 func (m *tableModel) toggleFilter() {
 	if m.filterVisible && m.filterFocused {
 		m.filterFocused = false
@@ -340,6 +410,8 @@ func (m tableModel) Update(msg tea.Msg) (tableModel, tea.Cmd) {
 			if m.cursor >= m.offset+visible {
 				m.offset = m.cursor - visible + 1
 			}
+		case " ":
+			m.toggleMark()
 		case "tab":
 			m.cycleSortColumn()
 		case "shift+tab":
@@ -1012,8 +1084,12 @@ func (m tableModel) renderRow(vm vmData, cols []tableColumn, selected bool, div
 	}
 
 	// ── Normal row ──
+	name := vm.info.Name
+	if m.marked[name] {
+		name = "• " + name
+	}
 	values := []string{
-		vm.info.Name,
+		name,
 		vm.info.State,
 		vm.info.Snapshots,
 		vm.info.IPv4,
@@ -1328,6 +1404,11 @@ func (m tableModel) RenderTitleBar(width int, rightLabel string) string {
 			Bold(true).
 			Render(" " + rightLabel + " ")
 		rightWidth := lipgloss.Width(rightRendered)
+		// Long chat titles (usage, profile) are cut rather than dropped
+		if avail := w - leftWidth - 1; rightWidth > avail && avail > 0 {
+			rightRendered = lipgloss.NewStyle().MaxWidth(avail).Render(rightRendered)
+			rightWidth = lipgloss.Width(rightRendered)
+		}
 		gap := w - leftWidth - rightWidth
 		if gap > 0 {
 			rightPart = bgStyle.Render(strings.Repeat(" ", gap)) + rightRendered